@@ -42,22 +42,35 @@ func NewApp(cfg *config.Config) (*App, error) {
 	app.Scheduler = scheduler.NewScheduler()
 
 	// Initialize sandbox based on config
-	switch cfg.Sandbox.Driver {
-	case "podman":
-		app.Sandbox, err = podman.New()
-		if err != nil {
-			return nil, fmt.Errorf("failed to create podman driver: %w", err)
-		}
-	default:
-		return nil, fmt.Errorf("unsupported sandbox driver: %s", cfg.Sandbox.Driver)
+	app.Sandbox, err = newSandbox(cfg.Sandbox.Driver)
+	if err != nil {
+		return nil, err
 	}
 
 	// Initialize review service
 	app.ReviewService = review.NewService(app.Storage, app.Scheduler, app.Sandbox)
+	app.ReviewService.SetDailyLimits(review.DailyLimits{
+		NewCards: cfg.Review.NewCardsPerDay,
+		Reviews:  cfg.Review.ReviewsPerDay,
+	})
 
 	return app, nil
 }
 
+// newSandbox creates the configured sandbox driver
+func newSandbox(driver string) (sandbox.Sandbox, error) {
+	switch driver {
+	case "podman":
+		sb, err := podman.New()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create podman driver: %w", err)
+		}
+		return sb, nil
+	default:
+		return nil, fmt.Errorf("unsupported sandbox driver: %s", driver)
+	}
+}
+
 // Close cleans up application resources
 func (a *App) Close() error {
 	var errs []error
@@ -73,11 +86,18 @@ func (a *App) Close() error {
 
 	// Cleanup sandbox
 	if a.Sandbox != nil {
-		if err := a.Sandbox.Cleanup(context.Background()); err != nil {
+		if err := a.Sandbox.CleanupAll(context.Background()); err != nil {
 			errs = append(errs, fmt.Errorf("failed to cleanup sandbox: %w", err))
 		}
 	}
 
+	// Remove asset directories once no container mounts them
+	if a.ReviewService != nil {
+		if err := a.ReviewService.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to cleanup review assets: %w", err))
+		}
+	}
+
 	if len(errs) > 0 {
 		return fmt.Errorf("errors during app cleanup: %v", errs)
 	}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/justinlyon12/ancli/internal/deck"
+	"github.com/spf13/cobra"
+)
+
+// NewCardCmd creates the command for managing individual installed cards
+func NewCardCmd(loader ConfigLoader) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "card",
+		Short: "Manage individual cards",
+		Long: `Manage individual installed cards. Cards are given as <deck>/<card-key>, where
+the deck is its name or numeric ID and the key is the card's key from cards.csv
+(see 'ancli deck show').`,
+	}
+
+	cmd.AddCommand(newSuspendCmd(loader, true))
+	cmd.AddCommand(newSuspendCmd(loader, false))
+
+	return cmd
+}
+
+// newSuspendCmd creates the suspend command, or the unsuspend command when suspend is false
+func newSuspendCmd(loader ConfigLoader, suspend bool) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "suspend <deck>/<card-key>",
+		Short: "Keep a card out of review sessions",
+		Long: `Suspend a card so review sessions leave it out until it is unsuspended, for
+example when it is broken or not worth learning. Its review history and schedule
+are kept. During a review the current card can also be suspended, skipped, or
+buried until tomorrow.
+
+Examples:
+  ancli card suspend linux-file-ops/create-directory
+  ancli card suspend 1/create-directory --json`,
+	}
+	if !suspend {
+		cmd.Use = "unsuspend <deck>/<card-key>"
+		cmd.Short = "Return a suspended or buried card to review sessions"
+		cmd.Long = `Unsuspend a card so review sessions include it again when it is due. A card
+buried during a review is unburied too.
+
+Examples:
+  ancli card unsuspend linux-file-ops/create-directory`
+	}
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		db, err := openStorage(loader)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := db.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to close database: %v\n", err)
+			}
+		}()
+
+		card, err := deck.SetSuspended(db, args[0], suspend, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return writeJSON(cmd.OutOrStdout(), card)
+		}
+		if suspend {
+			fmt.Fprintf(cmd.OutOrStdout(), "🚫 Suspended %s (%s)\n", args[0], card.Title)
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "✅ Unsuspended %s (%s)\n", args[0], card.Title)
+		}
+		return nil
+	}
+
+	cmd.Flags().Bool("json", false, "Output the card as JSON")
+
+	return cmd
+}
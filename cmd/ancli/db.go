@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/justinlyon12/ancli/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// NewDBCmd creates the database maintenance command
+func NewDBCmd(loader ConfigLoader) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Maintain the AnCLI database",
+		Long: `Maintain the local AnCLI database that stores decks, cards, and review history.
+
+Schema migrations normally run automatically whenever ancli opens the database;
+these commands let you inspect and apply them explicitly.`,
+	}
+
+	cmd.AddCommand(NewMigrateCmd(loader))
+
+	return cmd
+}
+
+// NewMigrateCmd creates a command that applies or reports schema migrations
+func NewMigrateCmd(loader ConfigLoader) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending schema migrations",
+		Long: `Apply any pending schema migrations to the database.
+
+Migrations are numbered and forward-only. Each runs in its own transaction and
+is recorded in the schema_migrations table. A database that was migrated by a
+newer ancli is refused rather than modified.
+
+Examples:
+  ancli db migrate            # Apply pending migrations
+  ancli db migrate --status   # Show applied and pending migrations
+  ancli db migrate --status --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statusOnly, _ := cmd.Flags().GetBool("status")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+
+			dbPath, err := databasePath(loader)
+			if err != nil {
+				return err
+			}
+
+			db, err := storage.OpenDB(dbPath)
+			if err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+			defer func() {
+				if err := db.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to close database: %v\n", err)
+				}
+			}()
+
+			before, err := db.MigrationStatus()
+			if err != nil {
+				return err
+			}
+
+			if statusOnly {
+				if jsonOutput {
+					return writeJSON(cmd.OutOrStdout(), before)
+				}
+				printMigrationStatus(dbPath, before)
+				return nil
+			}
+
+			if err := storage.MigrateDatabase(db); err != nil {
+				return fmt.Errorf("migration failed: %w", err)
+			}
+
+			applied := 0
+			for _, m := range before {
+				if !m.Applied {
+					fmt.Printf("✅ Applied %03d_%s\n", m.Version, m.Name)
+					applied++
+				}
+			}
+			if applied == 0 {
+				fmt.Printf("Database is up to date (schema version %d)\n", storage.LatestSchemaVersion())
+			} else {
+				fmt.Printf("\nDatabase migrated to schema version %d\n", storage.LatestSchemaVersion())
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("status", false, "Show applied and pending migrations without applying them")
+	cmd.Flags().Bool("json", false, "Output status as JSON (with --status)")
+
+	return cmd
+}
+
+// printMigrationStatus renders the migration table for a database
+func printMigrationStatus(dbPath string, status []storage.MigrationStatus) {
+	fmt.Printf("Database: %s\n\n", dbPath)
+
+	pending := 0
+	for _, m := range status {
+		if m.Applied {
+			appliedAt := "unknown"
+			if m.AppliedAt != nil {
+				appliedAt = m.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("  ✅ %03d_%-24s applied %s\n", m.Version, m.Name, appliedAt)
+		} else {
+			fmt.Printf("  ⏳ %03d_%-24s pending\n", m.Version, m.Name)
+			pending++
+		}
+	}
+
+	if pending > 0 {
+		fmt.Printf("\n%d pending migrations. Run 'ancli db migrate' to apply them.\n", pending)
+	} else {
+		fmt.Printf("\nSchema is up to date.\n")
+	}
+}
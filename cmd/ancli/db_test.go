@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/justinlyon12/ancli/internal/config"
+	"github.com/justinlyon12/ancli/internal/storage"
+)
+
+func TestMigrateCommand_StatusAndApply(t *testing.T) {
+	loader := &TestConfigLoader{
+		Config: &config.Config{
+			Database: config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "ancli.db")},
+		},
+	}
+
+	status := func() []storage.MigrationStatus {
+		t.Helper()
+
+		var out bytes.Buffer
+		cmd := NewMigrateCmd(loader)
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{"--status", "--json"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("migrate --status failed: %v", err)
+		}
+
+		var result []storage.MigrationStatus
+		if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+			t.Fatalf("status output is not valid JSON: %v", err)
+		}
+		return result
+	}
+
+	before := status()
+	if len(before) != storage.LatestSchemaVersion() || before[0].Applied {
+		t.Fatalf("expected all migrations pending on a new database, got %+v", before)
+	}
+
+	cmd := NewMigrateCmd(loader)
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	for _, m := range status() {
+		if !m.Applied {
+			t.Errorf("expected migration %d to be applied", m.Version)
+		}
+	}
+}
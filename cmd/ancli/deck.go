@@ -1,14 +1,23 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/justinlyon12/ancli/internal/deck"
+	"github.com/justinlyon12/ancli/internal/sandbox"
 	"github.com/spf13/cobra"
 )
 
 // NewDeckCmd creates a new deck management command
-func NewDeckCmd() *cobra.Command {
+func NewDeckCmd(loader ConfigLoader) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "deck",
 		Short: "Manage AnCLI decks",
@@ -23,6 +32,13 @@ hands-on practice with spaced repetition. Each deck contains:
 
 	// Add subcommands
 	cmd.AddCommand(NewLintCmd())
+	cmd.AddCommand(NewTestCmd(loader))
+	cmd.AddCommand(NewPackCmd())
+	cmd.AddCommand(NewInstallCmd(loader))
+	cmd.AddCommand(NewUpgradeCmd(loader))
+	cmd.AddCommand(NewListCmd(loader))
+	cmd.AddCommand(NewShowCmd(loader))
+	cmd.AddCommand(NewRemoveCmd(loader))
 
 	return cmd
 }
@@ -33,15 +49,22 @@ func NewLintCmd() *cobra.Command {
 		Use:   "lint [deck-path]",
 		Short: "Validate deck structure and content",
 		Long: `Validate a deck directory for structural integrity, content quality,
-security issues, and usability concerns.
+security issues, and usability concerns. Packed .ancli archives are also
+accepted; their MANIFEST.json hashes are checked before validation.
+
+The command exits with a non-zero status when the deck has errors, so it can
+gate CI. Use --format json or --format sarif for machine-readable output;
+SARIF can be uploaded to GitHub code scanning or read by editors.
 
 Examples:
   ancli deck lint .                    # Validate current directory
   ancli deck lint examples/my-deck     # Validate specific deck
+  ancli deck lint my-deck-1.0.0.ancli  # Validate a packed archive
   ancli deck lint . --verbose         # Show detailed validation info
-  ancli deck lint . --json           # JSON output for automation`,
+  ancli deck lint . --json           # JSON output for automation
+  ancli deck lint . --format sarif > lint.sarif`,
 		Args: cobra.MaximumNArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			deckPath := "."
 			if len(args) > 0 {
 				deckPath = args[0]
@@ -49,31 +72,573 @@ Examples:
 
 			verbose, _ := cmd.Flags().GetBool("verbose")
 			jsonOutput, _ := cmd.Flags().GetBool("json")
+			format, _ := cmd.Flags().GetString("format")
+			if jsonOutput {
+				format = "json"
+			}
 
 			result, err := deck.ValidateDeck(deckPath)
 			if err != nil {
-				fmt.Printf("Error validating deck: %v\n", err)
-				return
+				return fmt.Errorf("failed to validate deck: %w", err)
 			}
 
-			if jsonOutput {
-				// TODO: Output JSON format
-				fmt.Printf("JSON output not yet implemented\n")
-				return
+			switch format {
+			case "text":
+				deck.PrintValidationResult(result, verbose)
+				if !result.Valid {
+					fmt.Printf("\nDeck validation failed. Please fix errors before using this deck.\n")
+				}
+			case "json":
+				err = deck.WriteJSON(cmd.OutOrStdout(), result)
+			case "sarif":
+				err = deck.WriteSARIF(cmd.OutOrStdout(), result, deckPath)
+			default:
+				return fmt.Errorf("unknown output format %q (expected text, json, or sarif)", format)
+			}
+			if err != nil {
+				return err
 			}
-
-			deck.PrintValidationResult(result, verbose)
 
 			if !result.Valid {
 				// Exit with error code for CI/automation
-				fmt.Printf("\nDeck validation failed. Please fix errors before using this deck.\n")
+				return fmt.Errorf("deck failed validation with %d error(s)", len(result.Errors))
 			}
+			return nil
 		},
 	}
 
 	// Add flags
 	cmd.Flags().BoolP("verbose", "v", false, "Show detailed validation information")
-	cmd.Flags().Bool("json", false, "Output validation results in JSON format")
+	cmd.Flags().Bool("json", false, "Output validation results in JSON format (same as --format json)")
+	cmd.Flags().String("format", "text", "Output format: text, json, or sarif")
+
+	return cmd
+}
+
+// NewTestCmd creates a command that runs every card of a deck end-to-end in the sandbox
+func NewTestCmd(loader ConfigLoader) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test [deck-path]",
+		Short: "Run deck cards end-to-end in the sandbox",
+		Long: `Run each card of a deck directory or .ancli archive through the sandbox, in
+prerequisite order, to catch broken cards before learners do.
+
+For every card the runner executes:
+  1. setup
+  2. verify, which should fail because the solution has not run yet
+  3. the solution (the card's command when no solution is given)
+  4. verify, which must now pass
+  5. cleanup
+
+Each card starts in a fresh container. A setup that changes directory
+(e.g. "mkdir -p my_project && cd my_project") makes the later steps start
+there. A verify that passes before the solution is reported as a warning.
+The command exits non-zero if any card fails, so it can gate CI.
+
+Examples:
+  ancli deck test . --dry-run                    # Show the plan, no containers
+  ancli deck test . --card create-dir            # Test one card
+  ancli deck test . --card advanced --with-prereqs
+  ancli deck test . --card create-dir --repeat 3 # Check cleanup restores state
+  ancli deck test . --comprehensive              # Try every solution alternative`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			deckPath := "."
+			if len(args) > 0 {
+				deckPath = args[0]
+			}
+
+			opts := deck.TestOptions{}
+			opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+			opts.Card, _ = cmd.Flags().GetString("card")
+			opts.WithPrereqs, _ = cmd.Flags().GetBool("with-prereqs")
+			opts.Repeat, _ = cmd.Flags().GetInt("repeat")
+			opts.Comprehensive, _ = cmd.Flags().GetBool("comprehensive")
+			verbose, _ := cmd.Flags().GetBool("verbose")
+
+			source, err := deck.Open(deckPath)
+			if err != nil {
+				return fmt.Errorf("failed to open deck: %w", err)
+			}
+			defer source.Close()
+
+			if !source.Validation.Valid {
+				deck.PrintValidationResult(source.Validation, false)
+				return &deck.InvalidDeckError{Result: source.Validation}
+			}
+
+			var sb sandbox.Sandbox
+			if !opts.DryRun {
+				cfg, err := loader.Load()
+				if err != nil {
+					return fmt.Errorf("failed to load configuration: %w", err)
+				}
+				sb, err = newSandbox(cfg.Sandbox.Driver)
+				if err != nil {
+					return err
+				}
+			}
+
+			report, err := deck.NewTester(sb).Run(cmd.Context(), source, opts)
+			if err != nil {
+				return err
+			}
+
+			deck.PrintTestReport(report, verbose)
+			if !report.OK() {
+				return fmt.Errorf("%d card run(s) failed", report.Failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Show the steps that would run without starting containers")
+	cmd.Flags().String("card", "", "Only test the card with this key")
+	cmd.Flags().Bool("with-prereqs", false, "Run the card's prerequisites first to establish their state")
+	cmd.Flags().Int("repeat", 1, "Run each card this many times to check cleanup restores state")
+	cmd.Flags().Bool("comprehensive", false, "Test every pipe-separated solution alternative")
+	cmd.Flags().BoolP("verbose", "v", false, "Show every step and its output")
+
+	return cmd
+}
+
+// NewInstallCmd creates a command that imports a deck directory into the local store
+func NewInstallCmd(loader ConfigLoader) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install <deck-path>",
+		Short: "Install a deck into the local database",
+		Long: `Validate a deck directory or packed .ancli archive and import its metadata,
+cards, and assets into the local database so they can be reviewed.
+
+Invalid decks are refused; run 'ancli deck lint' to see what needs fixing.
+The whole deck is imported in a single transaction.
+
+Examples:
+  ancli deck install examples/decks/linux-file-ops.ancli
+  ancli deck install my-deck-1.0.0.ancli
+  ancli deck install .`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := openStorage(loader)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := db.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to close database: %v\n", err)
+				}
+			}()
+
+			result, err := deck.Install(db, args[0])
+			if err != nil {
+				var invalid *deck.InvalidDeckError
+				if errors.As(err, &invalid) {
+					deck.PrintValidationResult(invalid.Result, false)
+				}
+				return err
+			}
+
+			fmt.Printf("📦 Installed deck %s v%s (ID: %d)\n", result.Deck.Name, result.Deck.Version, result.Deck.ID)
+			fmt.Printf("   %d cards, %d assets\n", result.CardCount, result.AssetCount)
+			if warnings := len(result.Validation.Warnings); warnings > 0 {
+				fmt.Printf("   ⚠️  %d validation warnings (run 'ancli deck lint' for details)\n", warnings)
+			}
+			fmt.Printf("\nStart reviewing with: ancli review --deck-id=%d\n", result.Deck.ID)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// NewUpgradeCmd creates a command that upgrades an installed deck in place
+func NewUpgradeCmd(loader ConfigLoader) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade <deck-path>",
+		Short: "Upgrade an installed deck to a new version",
+		Long: `Upgrade an installed deck from a newer deck directory or .ancli archive
+without losing review progress.
+
+Cards are matched by key:
+- Unchanged cards and cards with content edits keep their FSRS progress
+- Cards whose command changed start over as new cards
+- New cards are added
+- Removed cards are archived: their review history is kept, but they are
+  no longer scheduled
+
+The deck's version must be newer than the installed one. The change summary
+is recorded in the deck's version history.
+
+Examples:
+  ancli deck upgrade my-deck-1.1.0.ancli --dry-run   # Show the plan only
+  ancli deck upgrade my-deck-1.1.0.ancli`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			db, err := openStorage(loader)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := db.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to close database: %v\n", err)
+				}
+			}()
+
+			result, err := deck.Upgrade(db, args[0], dryRun)
+			if err != nil {
+				var invalid *deck.InvalidDeckError
+				if errors.As(err, &invalid) {
+					deck.PrintValidationResult(invalid.Result, false)
+				}
+				return err
+			}
+
+			printUpgradeChanges(result)
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Show the upgrade plan without changing anything")
+
+	return cmd
+}
+
+// printUpgradeChanges renders an upgrade plan or result
+func printUpgradeChanges(result *deck.UpgradeResult) {
+	changes := result.Changes
+	if result.DryRun {
+		fmt.Printf("🔍 Upgrade plan for %s: v%s → v%s (dry run, nothing changed)\n",
+			result.Deck.Name, changes.FromVersion, changes.ToVersion)
+	} else {
+		fmt.Printf("⬆️  Upgraded deck %s: v%s → v%s\n", result.Deck.Name, changes.FromVersion, changes.ToVersion)
+	}
+
+	printCardKeys("➕ Added", changes.Added)
+	printCardKeys("✏️  Updated (progress kept)", changes.Updated)
+	printCardKeys("🔄 Command changed (progress reset)", changes.Reset)
+	printCardKeys("♻️  Restored", changes.Restored)
+	printCardKeys("📁 Archived (history kept)", changes.Archived)
+	fmt.Printf("   %d cards unchanged, %d assets\n", changes.Unchanged, changes.Assets)
+
+	if warnings := len(result.Validation.Warnings); warnings > 0 {
+		fmt.Printf("   ⚠️  %d validation warnings (run 'ancli deck lint' for details)\n", warnings)
+	}
+	if result.DryRun {
+		fmt.Printf("\nRun again without --dry-run to apply.\n")
+	}
+}
+
+// printCardKeys prints a labelled list of card keys, skipping empty lists
+func printCardKeys(label string, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	fmt.Printf("   %s (%d): %s\n", label, len(keys), strings.Join(keys, ", "))
+}
+
+// NewPackCmd creates a command that builds a distributable .ancli archive from a deck directory
+func NewPackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pack [deck-path]",
+		Short: "Build a .ancli archive from a deck directory",
+		Long: `Lint a deck directory and package it as a reproducible .ancli archive.
+
+The archive is a gzip-compressed tarball holding deck.yaml, cards.csv,
+README.md (if present), assets/, and a generated MANIFEST.json with the deck
+name, version, and a SHA-256 hash of every file. Entries are sorted and carry
+fixed timestamps, so packing an unchanged deck always produces the same bytes.
+
+Examples:
+  ancli deck pack .                          # Writes <name>-<version>.ancli
+  ancli deck pack my-deck -o dist/my.ancli   # Choose the output path`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			deckPath := "."
+			if len(args) > 0 {
+				deckPath = args[0]
+			}
+			output, _ := cmd.Flags().GetString("output")
+
+			// Pack into a temporary file first so a failed lint never leaves a partial archive
+			outDir := "."
+			if output != "" {
+				outDir = filepath.Dir(output)
+			}
+			tmp, err := os.CreateTemp(outDir, ".ancli-pack-*")
+			if err != nil {
+				return fmt.Errorf("failed to create temporary archive: %w", err)
+			}
+			defer os.Remove(tmp.Name())
+
+			result, err := deck.Pack(deckPath, tmp)
+			if closeErr := tmp.Close(); err == nil && closeErr != nil {
+				err = fmt.Errorf("failed to write archive: %w", closeErr)
+			}
+			if err != nil {
+				var invalid *deck.InvalidDeckError
+				if errors.As(err, &invalid) {
+					deck.PrintValidationResult(invalid.Result, false)
+				}
+				return err
+			}
+
+			if output == "" {
+				output = deck.ArchiveName(result.Manifest)
+			}
+			if err := os.Chmod(tmp.Name(), 0644); err != nil {
+				return fmt.Errorf("failed to write archive: %w", err)
+			}
+			if err := os.Rename(tmp.Name(), output); err != nil {
+				return fmt.Errorf("failed to write archive: %w", err)
+			}
+
+			fmt.Printf("📦 Packed %s v%s → %s\n", result.Manifest.Name, result.Manifest.Version, output)
+			fmt.Printf("   %d files, sha256 %s\n", len(result.Manifest.Files), result.SHA256)
+			if warnings := len(result.Validation.Warnings); warnings > 0 {
+				fmt.Printf("   ⚠️  %d validation warnings (run 'ancli deck lint' for details)\n", warnings)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "", "archive path (default <name>-<version>.ancli)")
 
 	return cmd
 }
+
+// NewListCmd creates a command that lists installed decks
+func NewListCmd(loader ConfigLoader) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List installed decks",
+		Long: `List every installed deck with its ID, version, and card counts.
+
+Cards are counted by FSRS state: new cards have never been reviewed, learning
+includes relearning cards, and due counts every card whose review is due now.
+
+Examples:
+  ancli deck list
+  ancli deck list --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+
+			db, err := openStorage(loader)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := db.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to close database: %v\n", err)
+				}
+			}()
+
+			summaries, err := deck.List(db, time.Now())
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return writeJSON(cmd.OutOrStdout(), summaries)
+			}
+			printDeckList(cmd.OutOrStdout(), summaries)
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("json", false, "Output as JSON")
+
+	return cmd
+}
+
+// NewShowCmd creates a command that shows one installed deck in detail
+func NewShowCmd(loader ConfigLoader) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show <name|id>",
+		Short: "Show an installed deck's settings and card progress",
+		Long: `Show an installed deck's metadata, sandbox defaults, and the FSRS state of
+each of its cards. The deck can be given by name or by numeric ID.
+
+Examples:
+  ancli deck show linux-file-ops
+  ancli deck show 1 --json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+
+			db, err := openStorage(loader)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := db.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to close database: %v\n", err)
+				}
+			}()
+
+			details, err := deck.Show(db, args[0], time.Now())
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return writeJSON(cmd.OutOrStdout(), details)
+			}
+			printDeckDetails(cmd.OutOrStdout(), details)
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("json", false, "Output as JSON")
+
+	return cmd
+}
+
+// NewRemoveCmd creates a command that deletes an installed deck
+func NewRemoveCmd(loader ConfigLoader) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove <name|id>",
+		Short: "Remove an installed deck and its review history",
+		Long: `Remove an installed deck from the local database. Its cards, review history,
+assets, and version history are deleted with it and cannot be recovered.
+
+You are asked to confirm before anything is deleted; pass --yes to skip the
+prompt in scripts.
+
+Examples:
+  ancli deck remove linux-file-ops
+  ancli deck remove 1 --yes --json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			yes, _ := cmd.Flags().GetBool("yes")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+
+			db, err := openStorage(loader)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := db.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to close database: %v\n", err)
+				}
+			}()
+
+			plan, err := deck.PlanRemoval(db, args[0])
+			if err != nil {
+				return err
+			}
+
+			if !yes {
+				prompt := fmt.Sprintf("Remove deck %s v%s with %d cards and %d reviews? This cannot be undone. [y/N] ",
+					plan.Deck.Name, plan.Deck.Version, plan.Cards, plan.Reviews)
+				if !confirm(cmd.InOrStdin(), cmd.ErrOrStderr(), prompt) {
+					return errors.New("removal cancelled")
+				}
+			}
+
+			if err := deck.Remove(db, plan.Deck); err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return writeJSON(cmd.OutOrStdout(), plan)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "🗑️  Removed deck %s v%s (%d cards, %d reviews)\n",
+				plan.Deck.Name, plan.Deck.Version, plan.Cards, plan.Reviews)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolP("yes", "y", false, "Remove without asking for confirmation")
+	cmd.Flags().Bool("json", false, "Output the removed deck as JSON")
+
+	return cmd
+}
+
+// confirm asks a yes/no question and reports whether the answer was yes
+func confirm(in io.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprint(out, prompt)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// printDeckList renders installed decks as a table
+func printDeckList(w io.Writer, summaries []deck.DeckSummary) {
+	if len(summaries) == 0 {
+		fmt.Fprintln(w, "No decks installed. Install one with: ancli deck install <deck-path>")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tVERSION\tCARDS\tDUE\tNEW\tLEARNING\tREVIEW")
+	for _, s := range summaries {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%d\t%d\t%d\n",
+			s.Deck.ID, s.Deck.Name, s.Deck.Version, s.Counts.Total,
+			s.Counts.Due, s.Counts.New, s.Counts.Learning, s.Counts.Review)
+	}
+	tw.Flush()
+}
+
+// printDeckDetails renders a deck's metadata, sandbox defaults, and card states
+func printDeckDetails(w io.Writer, details *deck.DeckDetails) {
+	d, counts := details.Deck, details.Counts
+
+	fmt.Fprintf(w, "📚 %s v%s (ID: %d)\n", d.Name, d.Version, d.ID)
+	if d.Description != "" {
+		fmt.Fprintf(w, "   %s\n", d.Description)
+	}
+	fmt.Fprintf(w, "   Author: %s\n", d.Author)
+	fmt.Fprintf(w, "   Installed: %s, updated: %s\n",
+		d.CreatedAt.Local().Format("2006-01-02"), d.UpdatedAt.Local().Format("2006-01-02"))
+	fmt.Fprintf(w, "   Assets: %d, upgrades: %d\n", details.Assets, details.Versions)
+
+	fmt.Fprintf(w, "\n🐳 Sandbox defaults\n")
+	fmt.Fprintf(w, "   Image: %s\n", d.DefaultImage)
+	fmt.Fprintf(w, "   Timeout: %ds\n", d.DefaultTimeout)
+	fmt.Fprintf(w, "   Network: %t\n", d.DefaultNetworkEnabled)
+	if d.DefaultShell != "" {
+		fmt.Fprintf(w, "   Shell: %s\n", d.DefaultShell)
+	}
+	if d.DefaultCapabilities != "" && d.DefaultCapabilities != "[]" {
+		fmt.Fprintf(w, "   Capabilities: %s\n", d.DefaultCapabilities)
+	}
+
+	fmt.Fprintf(w, "\n🧠 Cards: %d total, %d due, %d new, %d learning, %d review",
+		counts.Total, counts.Due, counts.New, counts.Learning, counts.Review)
+	if counts.Suspended > 0 {
+		fmt.Fprintf(w, ", %d suspended", counts.Suspended)
+	}
+	if counts.Archived > 0 {
+		fmt.Fprintf(w, ", %d archived", counts.Archived)
+	}
+	fmt.Fprintln(w)
+
+	if len(details.Cards) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tSTATE\tDUE\tREPS\tLAPSES\tSTABILITY\tDIFFICULTY")
+	for _, card := range details.Cards {
+		due := "now"
+		if !card.Due.IsZero() {
+			due = card.Due.Local().Format("2006-01-02 15:04")
+		}
+		state := card.State
+		if card.Suspended {
+			state += " (suspended)"
+		} else if card.BuriedUntil != nil {
+			state += " (buried)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%.2f\t%.2f\n",
+			card.Key, state, due, card.Reps, card.Lapses, card.Stability, card.Difficulty)
+	}
+	tw.Flush()
+}
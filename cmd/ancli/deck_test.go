@@ -1,14 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
+
+	"github.com/justinlyon12/ancli/internal/config"
+	"github.com/justinlyon12/ancli/internal/deck"
+	"github.com/justinlyon12/ancli/internal/storage"
 )
 
 func TestNewDeckCmd(t *testing.T) {
-	cmd := NewDeckCmd()
+	cmd := NewDeckCmd(&TestConfigLoader{})
 
 	if cmd.Use != "deck" {
 		t.Errorf("expected Use='deck', got %s", cmd.Use)
@@ -19,13 +27,19 @@ func TestNewDeckCmd(t *testing.T) {
 	}
 
 	// Check that subcommands are added
+	expected := []string{
+		"install <deck-path>", "lint [deck-path]", "list", "pack [deck-path]",
+		"remove <name|id>", "show <name|id>", "test [deck-path]", "upgrade <deck-path>",
+	}
 	subCmds := cmd.Commands()
-	if len(subCmds) != 1 {
-		t.Errorf("expected 1 subcommand, got %d", len(subCmds))
+	if len(subCmds) != len(expected) {
+		t.Fatalf("expected %d subcommands, got %d", len(expected), len(subCmds))
 	}
 
-	if subCmds[0].Use != "lint [deck-path]" {
-		t.Errorf("expected lint subcommand, got %s", subCmds[0].Use)
+	for i, use := range expected {
+		if subCmds[i].Use != use {
+			t.Errorf("expected subcommand %q, got %q", use, subCmds[i].Use)
+		}
 	}
 }
 
@@ -54,18 +68,93 @@ func TestNewLintCmd(t *testing.T) {
 	}
 }
 
-func TestLintCommand_ExecutesSuccessfully(t *testing.T) {
-	// Test that the lint command executes without crashing
+func TestLintCommand_FailsOnInvalidDeck(t *testing.T) {
+	// A failed lint must exit non-zero so CI can block bad decks
 	cmd := NewLintCmd()
-
-	// Test with non-existent path - should not crash
 	cmd.SetArgs([]string{"/nonexistent/path"})
-	err := cmd.Execute()
-	if err != nil {
-		t.Fatalf("command execution failed: %v", err)
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected error when linting an invalid deck")
+	}
+}
+
+func TestLintCommand_MachineReadableOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+		check   func(t *testing.T, doc map[string]any)
+	}{
+		{
+			name: "json for valid deck",
+			args: []string{"../../examples/decks/linux-file-ops.ancli", "--json"},
+			check: func(t *testing.T, doc map[string]any) {
+				if doc["valid"] != true {
+					t.Errorf("expected valid=true, got %v", doc["valid"])
+				}
+			},
+		},
+		{
+			name:    "json for invalid deck",
+			args:    []string{"/nonexistent/path", "--format", "json"},
+			wantErr: true,
+			check: func(t *testing.T, doc map[string]any) {
+				if errs, _ := doc["errors"].([]any); len(errs) == 0 {
+					t.Error("expected errors in JSON output")
+				}
+			},
+		},
+		{
+			name:    "sarif for invalid deck",
+			args:    []string{"/nonexistent/path", "--format", "sarif"},
+			wantErr: true,
+			check: func(t *testing.T, doc map[string]any) {
+				if doc["version"] != "2.1.0" {
+					t.Errorf("expected SARIF 2.1.0, got %v", doc["version"])
+				}
+				runs, _ := doc["runs"].([]any)
+				if len(runs) != 1 {
+					t.Fatalf("expected one run, got %d", len(runs))
+				}
+				results, _ := runs[0].(map[string]any)["results"].([]any)
+				if len(results) == 0 {
+					t.Error("expected SARIF results for invalid deck")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := NewLintCmd()
+			cmd.SetOut(&out)
+			cmd.SetArgs(tt.args)
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			err := cmd.Execute()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+
+			var doc map[string]any
+			if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+				t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+			}
+			tt.check(t, doc)
+		})
 	}
 
-	// If we get here, the command executed successfully
+	cmd := NewLintCmd()
+	cmd.SetArgs([]string{".", "--format", "xml"})
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for unknown format")
+	}
 }
 
 func TestLintCommand_MaxArgs(t *testing.T) {
@@ -99,7 +188,7 @@ func TestLintCommand_MaxArgs(t *testing.T) {
 func TestDeckCommandIntegration(t *testing.T) {
 	// Test that deck command integrates properly with root command
 	rootCmd := &cobra.Command{Use: "ancli"}
-	deckCmd := NewDeckCmd()
+	deckCmd := NewDeckCmd(&TestConfigLoader{})
 	rootCmd.AddCommand(deckCmd)
 
 	// Check that deck command is added
@@ -134,7 +223,7 @@ func TestCommandHelp(t *testing.T) {
 	}{
 		{
 			name: "deck command help",
-			cmd:  NewDeckCmd(),
+			cmd:  NewDeckCmd(&TestConfigLoader{}),
 			contain: []string{
 				"Manage AnCLI decks",
 				"deck.yaml: Metadata and configuration",
@@ -150,6 +239,7 @@ func TestCommandHelp(t *testing.T) {
 				"ancli deck lint . --verbose",
 				"--verbose",
 				"--json",
+				"--format sarif",
 			},
 		},
 	}
@@ -169,3 +259,143 @@ func TestCommandHelp(t *testing.T) {
 		})
 	}
 }
+
+func TestInstallCommand_ExampleDeck(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "ancli.db")
+	loader := &TestConfigLoader{
+		Config: &config.Config{
+			Database: config.DatabaseConfig{Path: dbPath},
+		},
+	}
+
+	cmd := NewInstallCmd(loader)
+	cmd.SetArgs([]string{"../../examples/decks/linux-file-ops.ancli"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	db, err := storage.NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	installed, err := db.GetDeckByName("linux-file-ops")
+	if err != nil {
+		t.Fatalf("installed deck not found: %v", err)
+	}
+
+	cards, err := db.GetCardsByDeck(installed.ID)
+	if err != nil {
+		t.Fatalf("failed to get cards: %v", err)
+	}
+	if len(cards) != 20 {
+		t.Errorf("expected 20 cards, got %d", len(cards))
+	}
+
+	// Installing the same deck twice must fail
+	cmd = NewInstallCmd(loader)
+	cmd.SetArgs([]string{"../../examples/decks/linux-file-ops.ancli"})
+	cmd.SilenceErrors = true
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error when installing an already installed deck")
+	}
+}
+
+func TestTestCommand_DryRunExampleDeck(t *testing.T) {
+	cmd := NewTestCmd(&TestConfigLoader{})
+	cmd.SetArgs([]string{"../../examples/decks/linux-file-ops.ancli", "--dry-run", "--comprehensive"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+
+	cmd = NewTestCmd(&TestConfigLoader{})
+	cmd.SetArgs([]string{"../../examples/decks/linux-file-ops.ancli", "--dry-run", "--card", "no-such-card"})
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for unknown card")
+	}
+}
+
+func TestListShowRemoveCommands(t *testing.T) {
+	loader := &TestConfigLoader{
+		Config: &config.Config{
+			Database: config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "ancli.db")},
+		},
+	}
+
+	install := NewInstallCmd(loader)
+	install.SetArgs([]string{"../../examples/decks/linux-file-ops.ancli"})
+	if err := install.Execute(); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	run := func(cmd *cobra.Command, stdin string, args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetIn(strings.NewReader(stdin))
+		cmd.SetArgs(args)
+		cmd.SilenceErrors = true
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run(NewListCmd(loader), "", "--json")
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	var summaries []deck.DeckSummary
+	if err := json.Unmarshal([]byte(out), &summaries); err != nil {
+		t.Fatalf("list output is not valid JSON: %v", err)
+	}
+	if len(summaries) != 1 || summaries[0].Counts.New != 20 || summaries[0].Counts.Due != 20 {
+		t.Errorf("expected one deck with 20 new, due cards, got %+v", summaries)
+	}
+
+	out, err = run(NewShowCmd(loader), "", "linux-file-ops", "--json")
+	if err != nil {
+		t.Fatalf("show failed: %v", err)
+	}
+	var details deck.DeckDetails
+	if err := json.Unmarshal([]byte(out), &details); err != nil {
+		t.Fatalf("show output is not valid JSON: %v", err)
+	}
+	if details.Deck.DefaultImage != "alpine:3.18" || len(details.Cards) != 20 {
+		t.Errorf("unexpected deck details: image %s, %d cards", details.Deck.DefaultImage, len(details.Cards))
+	}
+
+	key := details.Cards[0].Key
+	if _, err := run(NewCardCmd(loader), "", "suspend", "linux-file-ops/"+key); err != nil {
+		t.Fatalf("card suspend failed: %v", err)
+	}
+	out, err = run(NewShowCmd(loader), "", "linux-file-ops")
+	if err != nil {
+		t.Fatalf("show failed: %v", err)
+	}
+	if !strings.Contains(out, "1 suspended") || !strings.Contains(out, "(suspended)") {
+		t.Errorf("expected show to list the suspended card, got:\n%s", out)
+	}
+	out, err = run(NewCardCmd(loader), "", "unsuspend", strconv.Itoa(details.Deck.ID)+"/"+key, "--json")
+	if err != nil {
+		t.Fatalf("card unsuspend failed: %v", err)
+	}
+	var card deck.CardSummary
+	if err := json.Unmarshal([]byte(out), &card); err != nil || card.Key != key || card.Suspended {
+		t.Errorf("expected %s to be unsuspended, got %s (%v)", key, out, err)
+	}
+	if _, err := run(NewCardCmd(loader), "", "suspend", "linux-file-ops/no-such-card"); err == nil {
+		t.Error("expected suspending a missing card to fail")
+	}
+
+	if _, err := run(NewRemoveCmd(loader), "n\n", "linux-file-ops"); err == nil {
+		t.Error("expected declining the confirmation to cancel removal")
+	}
+	if _, err := run(NewRemoveCmd(loader), "y\n", strconv.Itoa(details.Deck.ID)); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	if _, err := run(NewShowCmd(loader), "", "linux-file-ops"); err == nil {
+		t.Error("expected removed deck to be gone")
+	}
+}
@@ -3,8 +3,12 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +19,12 @@ import (
 	"github.com/justinlyon12/ancli/internal/sandbox"
 )
 
+// Review modes for the --mode flag
+const (
+	modeRecall = "recall" // The learner types the command from the card's description
+	modeShow   = "show"   // The command is shown and run for the learner
+)
+
 // NewReviewCmd creates a new review command with dependency injection
 func NewReviewCmd(loader ConfigLoader) *cobra.Command {
 	cmd := &cobra.Command{
@@ -23,7 +33,57 @@ func NewReviewCmd(loader ConfigLoader) *cobra.Command {
 		Long: `Start an interactive flashcard review session. Cards will be presented one at a time,
 executed in a secure container, and you'll rate your performance for spaced repetition scheduling.
 
-The session continues until all due cards are reviewed or you quit with 'q'.`,
+In recall mode (the default) each card shows only its title and description and you type the
+command. It runs in the container and is checked against the card's solutions and verify
+command. Revealing the card's hint or solution is recorded as needing help. Entering '!'
+instead of a command opens an interactive shell inside the card's container; the attempt
+ends when you exit the shell or run 'verify', and its transcript is saved with the review.
+After an attempt you can retry the card, and after rating you can read its explanation.
+Decks can hide solutions and explanations with their show_solutions and show_explanations
+settings. Use --mode show to see the command and run it instead.
+
+On a terminal the review runs full-screen. A ribbon at the top shows the card's network
+access and capabilities, output scrolls with the arrow and page keys, and the rating row
+shows the interval each rating would give. Ctrl-T shows the hint, Ctrl-S the solution,
+Ctrl-R resets the container, and '?' (on an empty command line) or Ctrl-O toggles the card's
+metadata; after an attempt, 'r' retries and 1-4 or Enter rates.
+
+With --no-tui, or when input or output isn't a terminal, plain prompts are used instead,
+for SSH and CI: at the command prompt 'h' shows the hint and 's' the solution, after an
+attempt 'r' retries, and after rating 'x' shows the explanation.
+
+A card you can't or don't want to answer can be set aside before it is rated: skipped to
+the end of the session (Ctrl-N, or 'skip' at a prompt), buried until tomorrow (Ctrl-B or
+'bury'), or suspended until 'ancli card unsuspend' (Ctrl-X or 'suspend'). Its schedule is
+left unchanged.
+
+A rating given by mistake can be undone: press 'u' right after rating or Ctrl-Z later, or
+type 'undo' at a card's first prompt. The card gets back exactly the schedule it had, the
+review is deleted, and the card is shown again next. Undo repeatedly to take back earlier
+ratings in the session.
+
+The session continues until all due cards are reviewed or you quit with 'q' (Esc in the
+full-screen interface). A card you rate Again, or that is still in its learning steps,
+comes back later in the same session when its next step is due, so the session isn't
+finished until those cards are learned for the day. Quitting parks the session: the card
+you were on and the rest of the queue are saved, and 'ancli review --resume' continues
+exactly where you stopped. Sessions are saved as you go, so --resume also recovers a
+session that was interrupted.
+
+A finished session ends with a summary of the cards reviewed by state, the ratings given,
+time spent thinking and running commands per card, the command success rate, and the
+cards that lapsed. Use --json to print the summary as JSON; a parked session is reported
+as JSON too, and the prompts and progress messages go to stderr.
+
+With --script, the session runs headless from a JSON-lines file ('-' for stdin), one
+answer per card: {"rating":3}, {"command":"ls -la","rating":0} to run a typed command and
+accept the suggested rating, or {"action":"skip"} (skip, bury, suspend, undo, quit). In
+show mode the card's own command runs. An optional "card_key" is checked against the card
+shown. Instead of prompts, one JSON event per line is written to stdout: session_started,
+card_shown, execution_result, rating_applied (with next_due), card_set_aside,
+rating_undone, and session_ended with the session statistics, or session_paused when the
+script ends before the queue does. A script that fails part way writes an error event with
+the script line and message, then pauses the session.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Initialize app only when running the command
 			app, err := initializeApp(loader)
@@ -36,22 +96,28 @@ The session continues until all due cards are reviewed or you quit with 'q'.`,
 				}
 			}()
 
-			return runReview(cmd, args, app)
+			return runReview(cmd, args, app.ReviewService)
 		},
 	}
 
 	// Add review-specific flags
+	cmd.Flags().String("mode", modeRecall, "review mode: recall (type the command) or show (display and run it)")
 	cmd.Flags().Int("deck-id", 0, "review cards from specific deck ID (0 = all decks)")
 	cmd.Flags().Int("max-cards", 20, "maximum cards per session (0 = unlimited)")
 	cmd.Flags().Bool("new-only", false, "only review new cards")
 	cmd.Flags().Bool("due-only", false, "only review cards that are due")
 	cmd.Flags().Bool("shuffle", true, "randomize card order")
 	cmd.Flags().Bool("no-network", true, "disable network access (safer)")
+	cmd.Flags().Bool("explain-queue", false, "show the session queue and why cards were held back, then exit")
+	cmd.Flags().Bool("resume", false, "continue the last unfinished session where it stopped")
+	cmd.Flags().Bool("no-tui", false, "use line-oriented prompts instead of the full-screen interface (for SSH, CI, and scripts)")
+	cmd.Flags().Bool("json", false, "print the end-of-session summary as JSON")
+	cmd.Flags().String("script", "", "run headless from a JSON-lines answers file ('-' for stdin), writing JSON events to stdout")
 
 	return cmd
 }
 
-func runReview(cmd *cobra.Command, args []string, app *App) error {
+func runReview(cmd *cobra.Command, args []string, service review.ReviewService) error {
 	ctx := context.Background()
 
 	// Get flag values from the command
@@ -61,6 +127,25 @@ func runReview(cmd *cobra.Command, args []string, app *App) error {
 	reviewDueOnly, _ := cmd.Flags().GetBool("due-only")
 	reviewShuffle, _ := cmd.Flags().GetBool("shuffle")
 	reviewNoNetwork, _ := cmd.Flags().GetBool("no-network")
+	explainQueue, _ := cmd.Flags().GetBool("explain-queue")
+	resume, _ := cmd.Flags().GetBool("resume")
+	noTUI, _ := cmd.Flags().GetBool("no-tui")
+	mode, _ := cmd.Flags().GetString("mode")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	script, _ := cmd.Flags().GetString("script")
+
+	if mode != modeRecall && mode != modeShow {
+		return fmt.Errorf("invalid mode %q: use %s or %s", mode, modeRecall, modeShow)
+	}
+
+	if resume {
+		// A resumed session keeps the queue it was started with
+		for _, name := range []string{"deck-id", "max-cards", "new-only", "due-only", "shuffle", "no-network", "explain-queue"} {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--%s cannot be combined with --resume: the session keeps its original options", name)
+			}
+		}
+	}
 
 	// Set up session options
 	var deckID *int
@@ -75,161 +160,717 @@ func runReview(cmd *cobra.Command, args []string, app *App) error {
 		ReviewCardsOnly: reviewDueOnly,
 		ShuffleCards:    reviewShuffle,
 		NetworkEnabled:  !reviewNoNetwork, // Invert the flag
+		Mode:            mode,
 	}
 
-	// Start session
-	fmt.Println("🚀 Starting review session...")
-	session, err := app.ReviewService.StartSession(ctx, opts)
-	if err != nil {
-		return fmt.Errorf("failed to start review session: %w", err)
+	if explainQueue {
+		explanation, err := service.ExplainQueue(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("failed to build review queue: %w", err)
+		}
+		printQueueExplanation(cmd.OutOrStdout(), explanation)
+		return nil
+	}
+
+	var answers io.ReadCloser
+	if script != "" {
+		var err error
+		if answers, err = openScript(script); err != nil {
+			return err
+		}
+		defer answers.Close()
+	}
+
+	// Scripted sessions write only JSON events to stdout, so progress messages are
+	// dropped; with --json they go to stderr, leaving stdout to the summary
+	out := cmd.OutOrStdout()
+	status := out
+	if answers != nil {
+		status = io.Discard
+	} else if jsonOutput {
+		status = cmd.ErrOrStderr()
+	}
+
+	var session *review.Session
+	var err error
+	if resume {
+		fmt.Fprintln(status, "⏯️  Resuming review session...")
+		session, err = service.ResumeSession(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to resume review session: %w", err)
+		}
+		opts = session.Options
+		// The session continues in its own mode unless another is asked for
+		if !cmd.Flags().Changed("mode") && (opts.Mode == modeRecall || opts.Mode == modeShow) {
+			mode = opts.Mode
+		}
+		fmt.Fprintf(status, "📚 Session resumed with %d cards left (%d reviewed)\n", session.CardsRemaining, session.CardsReviewed)
+	} else {
+		fmt.Fprintln(status, "🚀 Starting review session...")
+		session, err = service.StartSession(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("failed to start review session: %w", err)
+		}
+		fmt.Fprintf(status, "📚 Session started with %d cards\n", session.CardsRemaining)
+		if session.Allowance != nil {
+			fmt.Fprintf(status, "📅 Left today: %s\n", formatAllowance(session.Allowance))
+		}
 	}
 
-	fmt.Printf("📚 Session started with %d cards\n", session.CardsRemaining)
 	if !opts.NetworkEnabled {
-		fmt.Println("🔒 Network access disabled for security")
+		fmt.Fprintln(status, "🔒 Network access disabled for security")
 	} else {
-		fmt.Println("🌐 Network access enabled")
+		fmt.Fprintln(status, "🌐 Network access enabled")
+	}
+
+	if answers != nil {
+		return runScriptedReview(ctx, service, session, mode, answers, out)
 	}
 
 	// Review loop
-	scanner := bufio.NewScanner(os.Stdin)
-	cardsReviewed := 0
+	var quit bool
+	if noTUI || !terminalSupportsTUI() {
+		quit, err = runLineReview(ctx, service, session, mode, cmd.InOrStdin(), status)
+	} else {
+		quit, err = runReviewTUI(ctx, service, session, mode)
+	}
+	if err != nil {
+		return err
+	}
+	if quit {
+		// Park the session rather than ending it so it can be resumed
+		progress, err := service.SessionStatus(ctx, session.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get session progress: %w", err)
+		}
+		if err := service.PauseSession(ctx, session.ID); err != nil {
+			return fmt.Errorf("failed to pause review session: %w", err)
+		}
+		if jsonOutput {
+			return writeJSON(out, pausedSession{Status: "paused", Session: progress})
+		}
+		fmt.Fprintf(out, "\n⏸️  Session paused with %d cards left (%d reviewed)\n", progress.CardsRemaining, progress.CardsReviewed)
+		fmt.Fprintln(out, "   Resume with: ancli review --resume")
+		return nil
+	}
+
+	// End session and show stats
+	stats, err := service.EndSession(ctx, session.ID)
+	if jsonOutput {
+		if err != nil {
+			return fmt.Errorf("failed to end review session: %w", err)
+		}
+		return writeJSON(out, stats)
+	}
+
+	fmt.Fprintln(out, "\n📊 Finalizing session...")
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: Failed to get session stats: %v\n", err)
+	} else {
+		printSessionStats(out, stats)
+	}
+
+	fmt.Fprintln(out, "👋 Thanks for studying!")
+	return nil
+}
+
+// pausedSession is the --json output when the learner parks the session
+type pausedSession struct {
+	Status string `json:"status"`
+	*review.Session
+}
+
+// runScriptedReview reviews the session from script answers, then pauses or ends it
+// A script that fails part way, such as on an answer for the wrong card, also pauses it.
+func runScriptedReview(ctx context.Context, service review.ReviewService, session *review.Session, mode string, answers io.Reader, out io.Writer) error {
+	script := newScriptReview(ctx, service, session, mode, answers, out)
+	quit, err := script.run()
+	if err != nil {
+		if abortErr := script.abort(err); abortErr != nil {
+			return errors.Join(err, abortErr)
+		}
+		return err
+	}
+	if quit {
+		if err := service.PauseSession(ctx, session.ID); err != nil {
+			return fmt.Errorf("failed to pause review session: %w", err)
+		}
+		return script.emitSession(eventSessionPaused, nil)
+	}
+
+	stats, err := service.EndSession(ctx, session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to end review session: %w", err)
+	}
+	return script.emitSession(eventSessionEnded, stats)
+}
+
+// printSessionStats shows the end-of-session summary
+func printSessionStats(w io.Writer, stats *review.SessionStats) {
+	fmt.Fprintf(w, "🎯 Session completed in %v\n", stats.Duration.Round(time.Second))
+	fmt.Fprintf(w, "📈 Cards reviewed: %d", stats.CardsReviewed)
+	if stats.CardsReviewed == 0 {
+		fmt.Fprintln(w)
+		return
+	}
+	fmt.Fprintf(w, " (%d new, %d learning, %d review, %d relearning)\n",
+		stats.NewCards, stats.LearningCards, stats.ReviewCards, stats.RelearningCards)
+	fmt.Fprintf(w, "⭐ Ratings: Again %d · Hard %d · Good %d · Easy %d (average %.2f)\n",
+		stats.AgainCount, stats.HardCount, stats.GoodCount, stats.EasyCount, stats.AverageRating)
+	fmt.Fprintf(w, "⏱️  Per card: %v thinking, %v running\n",
+		stats.AverageThinkingTime.Round(100*time.Millisecond), stats.AverageExecutionTime.Round(10*time.Millisecond))
+	if stats.CommandsRun > 0 {
+		fmt.Fprintf(w, "🏃 Commands: %d of %d succeeded (%.0f%%)\n",
+			stats.CommandsSucceeded, stats.CommandsRun, stats.CommandSuccessRate*100)
+	}
+	if len(stats.LapsedCards) > 0 {
+		fmt.Fprintf(w, "🔁 Lapsed (%d):\n", len(stats.LapsedCards))
+		for _, card := range stats.LapsedCards {
+			fmt.Fprintf(w, "  • %s: %s\n", card.CardKey, card.Title)
+		}
+	}
+}
+
+// runLineReview reviews the session's cards with line-oriented prompts
+// quit is set when the learner quits before the queue is done.
+func runLineReview(ctx context.Context, service review.ReviewService, session *review.Session, mode string, in io.Reader, w io.Writer) (quit bool, err error) {
+	scanner := bufio.NewScanner(in)
+
+	for {
+		// The service owns the session's progress, so read it afresh for each card
+		progress, err := service.SessionStatus(ctx, session.ID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get session progress: %w", err)
+		}
+		if progress.CardsRemaining <= 0 {
+			break
+		}
 
-	for session.CardsRemaining > 0 {
 		// Get next card
-		card, err := app.ReviewService.GetNextCard(ctx, session.ID)
+		card, err := service.GetNextCard(ctx, session.ID)
 		if err != nil {
-			if strings.Contains(err.Error(), "no more cards") {
-				fmt.Println("✅ No more cards to review!")
+			if errors.Is(err, review.ErrQueueEmpty) {
+				fmt.Fprintln(w, "✅ No more cards to review!")
 				break
 			}
-			return fmt.Errorf("failed to get next card: %w", err)
+			return false, fmt.Errorf("failed to get next card: %w", err)
+		}
+
+		quit, err := reviewCard(ctx, service, session.ID, card, scanner, mode, w)
+		if err != nil || quit {
+			return quit, err
+		}
+	}
+	return false, nil
+}
+
+// reviewCard shows one card, runs the learner's attempts, and submits their rating
+// quit is set when the learner quits, or input ends, before the card is rated.
+func reviewCard(ctx context.Context, service review.ReviewService, sessionID string, card *review.ReviewCard, scanner *bufio.Scanner, mode string, w io.Writer) (quit bool, err error) {
+	printCard(w, card, mode)
+
+	// Record thinking start time
+	thinkingStart := time.Now()
+	var thinkingTime time.Duration
+
+	help := &cardHelp{card: card, out: w}
+	attempts := 0
+	var attempt *review.Attempt
+	var suggested, rating domain.Rating
+	for rating == 0 {
+		// Get the command to run
+		input := recallInput{command: card.Command}
+		if mode == modeShow {
+			fmt.Fprint(w, "Press Enter when ready to execute the command (skip/bury/suspend, undo, or 'q' to quit): ")
+			if !scanner.Scan() {
+				return true, nil
+			}
+			text := strings.TrimSpace(scanner.Text())
+			if text == "q" || text == "quit" {
+				return true, nil
+			}
+			if isSetAside(text) {
+				return false, setAsideCard(ctx, service, sessionID, card, text, w)
+			}
+			if text == undoWord {
+				return false, undoRating(ctx, service, sessionID, w)
+			}
+		} else {
+			var ok bool
+			if input, ok = readRecallAttempt(scanner, help); !ok {
+				return true, nil
+			}
+			if input.setAside != "" {
+				return false, setAsideCard(ctx, service, sessionID, card, input.setAside, w)
+			}
+			if input.undo {
+				return false, undoRating(ctx, service, sessionID, w)
+			}
 		}
 
-		// Show card info
-		fmt.Print("\n" + strings.Repeat("=", 60) + "\n")
-		fmt.Printf("📋 Card: %s\n", card.Title)
-		if card.Description != "" {
-			fmt.Printf("📖 Description: %s\n", card.Description)
+		// Thinking time runs until the first attempt
+		if attempts == 0 {
+			thinkingTime = time.Since(thinkingStart)
 		}
-		fmt.Printf("🐳 Image: %s | ⏱️  Timeout: %v\n", card.Image, card.Timeout)
-		if card.NetworkEnabled {
-			fmt.Printf("🌐 Network: ENABLED\n")
+		attempts++
+
+		attempt = runAttempt(ctx, service, sessionID, card, input, w)
+		suggested = 0
+		if mode == modeRecall && attempt != nil {
+			printJudgement(attempt, help)
+			if attempt.Judged() {
+				suggested = review.SuggestRating(review.Outcome{
+					Passed:       attempt.Correct,
+					Attempts:     attempts,
+					ThinkingTime: thinkingTime,
+					HelpAccessed: help.accessed,
+				})
+			}
 		}
-		fmt.Printf("📁 Working Dir: %s\n", card.WorkingDir)
-		fmt.Printf("🔧 Command: %s\n", card.Command)
-		fmt.Print(strings.Repeat("=", 60) + "\n")
 
-		// Wait for user to be ready
-		fmt.Print("Press Enter when ready to execute the command (or 'q' to quit): ")
-		if !scanner.Scan() {
-			break
+		var retry bool
+		var setAside string
+		// The preview is a convenience, so rate without it if it can't be computed
+		preview, _ := service.PreviewSchedule(ctx, card.ID)
+		rating, retry, setAside, quit = readRating(scanner, help, suggested, preview)
+		if quit {
+			return true, nil
 		}
-		input := strings.TrimSpace(scanner.Text())
-		if input == "q" || input == "quit" {
-			fmt.Println("👋 Quitting review session...")
-			break
+		if setAside != "" {
+			return false, setAsideCard(ctx, service, sessionID, card, setAside, w)
 		}
+		if retry {
+			fmt.Fprintf(w, "\n🔁 Attempt %d\n", attempts+1)
+		}
+	}
+
+	// Submit review
+	executionResult := newExecutionResult(card, attempt, attempts, thinkingTime, help.accessed, suggested)
+	if err := service.SubmitReview(ctx, sessionID, card.ID, rating, executionResult); err != nil {
+		return false, fmt.Errorf("failed to submit review: %w", err)
+	}
+	fmt.Fprintf(w, "✅ Review submitted! Rating: %s\n", rating.String())
 
-		// Record thinking start time
-		thinkingStart := time.Now()
+	offerExplanation(w, scanner, card)
+	return false, nil
+}
 
-		// Execute command
-		fmt.Println("\n🏃 Executing command...")
-		sandboxConfig := sandbox.ExecutionConfig{
-			Command:        strings.Fields(card.Command), // Convert string to []string
-			WorkingDir:     card.WorkingDir,
-			Image:          card.Image,
-			Timeout:        card.Timeout,
+// newExecutionResult records the learner's work on a card for SubmitReview
+// suggested is the rating offered from a judged recall attempt, zero when none was.
+func newExecutionResult(card *review.ReviewCard, attempt *review.Attempt, attempts int, thinkingTime time.Duration, helpAccessed bool, suggested domain.Rating) *domain.ExecutionResult {
+	if attempt == nil {
+		// The command never ran, but the attempts and any help still count
+		return &domain.ExecutionResult{
+			ExitCode:       -1,
+			ThinkingTime:   thinkingTime,
 			NetworkEnabled: card.NetworkEnabled,
-			Capabilities:   card.Capabilities,
-			Environment:    card.EnvironmentVars,
+			Attempts:       attempts,
+			HelpAccessed:   helpAccessed,
 		}
+	}
 
-		result, err := app.Sandbox.Run(ctx, sandboxConfig)
-		if err != nil {
-			fmt.Printf("❌ Execution failed: %v\n", err)
-			// Still allow rating for learning purposes
+	result := attempt.Result
+	executionResult := &domain.ExecutionResult{
+		Success:        result.Success,
+		ExitCode:       result.ExitCode,
+		Stdout:         result.Stdout,
+		Stderr:         result.Stderr,
+		Duration:       result.Duration,
+		ThinkingTime:   thinkingTime,
+		ContainerID:    result.ContainerID,
+		ImageUsed:      result.ImageUsed,
+		NetworkEnabled: card.NetworkEnabled,
+		Attempts:       attempts,
+		HelpAccessed:   helpAccessed,
+	}
+	if suggested != 0 {
+		executionResult.AttemptPassed = &attempt.Correct
+		executionResult.SuggestedRating = suggested
+	}
+	return executionResult
+}
+
+// printCard shows a card's details; the command is only shown in show mode
+func printCard(w io.Writer, card *review.ReviewCard, mode string) {
+	fmt.Fprint(w, "\n"+strings.Repeat("=", 60)+"\n")
+	fmt.Fprintf(w, "📋 Card: %s\n", card.Title)
+	if card.Description != "" {
+		fmt.Fprintf(w, "📖 Description: %s\n", card.Description)
+	}
+	fmt.Fprintf(w, "🐳 Image: %s | ⏱️  Timeout: %v\n", card.Image, card.Timeout)
+	if card.NetworkEnabled {
+		fmt.Fprintf(w, "🌐 Network: ENABLED\n")
+	}
+	fmt.Fprintf(w, "📁 Working Dir: %s\n", card.WorkingDir)
+	if card.AssetsDir != "" {
+		fmt.Fprintf(w, "📎 Assets: %s (read-only)\n", sandbox.AssetsPath)
+	}
+	if mode == modeShow {
+		fmt.Fprintf(w, "🔧 Command: %s\n", card.Command)
+	}
+	fmt.Fprint(w, strings.Repeat("=", 60)+"\n")
+}
+
+// runAttempt runs one attempt at card and shows its output
+// It returns nil when the attempt could not run; the learner can still rate the card.
+func runAttempt(ctx context.Context, service review.ReviewService, sessionID string, card *review.ReviewCard, input recallInput, w io.Writer) *review.Attempt {
+	var attempt *review.Attempt
+	var err error
+	if input.shell {
+		fmt.Fprintln(w, "\n🐚 Opening a shell in the card's container...")
+		if card.Verify != "" {
+			fmt.Fprintln(w, "   Type 'verify' to check your work and finish, or 'exit' to give up.")
 		} else {
-			fmt.Printf("✅ Command completed (exit code: %d)\n", result.ExitCode)
+			fmt.Fprintln(w, "   Type 'exit' when you're done.")
 		}
+		attempt, err = service.ShellAttempt(ctx, sessionID, card, sandbox.Terminal{In: os.Stdin, Out: os.Stdout})
+	} else {
+		fmt.Fprintln(w, "\n🏃 Executing command...")
+		attempt, err = service.AttemptCard(ctx, sessionID, card, input.command)
+	}
+	if err != nil {
+		fmt.Fprintf(w, "❌ Execution failed: %v\n", err)
+		return nil
+	}
 
-		// Show output
-		if result != nil {
-			if result.Stdout != "" {
-				fmt.Println("\n📤 STDOUT:")
-				fmt.Println(result.Stdout)
-			}
-			if result.Stderr != "" {
-				fmt.Println("\n📤 STDERR:")
-				fmt.Println(result.Stderr)
-			}
+	result := attempt.Result
+	fmt.Fprintf(w, "✅ Command completed (exit code: %d)\n", result.ExitCode)
+
+	// Show output; a shell's output was already on the terminal
+	if !input.shell {
+		if result.Stdout != "" {
+			fmt.Fprintln(w, "\n📤 STDOUT:")
+			fmt.Fprintln(w, result.Stdout)
 		}
+		if result.Stderr != "" {
+			fmt.Fprintln(w, "\n📤 STDERR:")
+			fmt.Fprintln(w, result.Stderr)
+		}
+	}
+	return attempt
+}
 
-		// Calculate thinking time
-		thinkingTime := time.Since(thinkingStart)
+// cardHelp shows a card's hint and solution and tracks whether the learner used them
+type cardHelp struct {
+	card     *review.ReviewCard
+	out      io.Writer
+	accessed bool
+}
 
-		// Get user rating
-		var rating domain.Rating
-		for {
-			fmt.Print("\n⭐ Rate your performance (1=Again, 2=Hard, 3=Good, 4=Easy): ")
-			if !scanner.Scan() {
-				return fmt.Errorf("failed to read rating")
-			}
+// hint shows the card's hint
+func (h *cardHelp) hint() {
+	if h.card.Hint == "" {
+		fmt.Fprintln(h.out, "🤷 This card has no hint")
+		return
+	}
+	h.accessed = true
+	fmt.Fprintf(h.out, "💡 Hint: %s\n", h.card.Hint)
+}
 
-			ratingInput := strings.TrimSpace(scanner.Text())
-			if ratingInput == "q" || ratingInput == "quit" {
-				fmt.Println("👋 Quitting review session...")
-				goto cleanup
-			}
+// solution shows the card's accepted solutions, if the deck allows it
+func (h *cardHelp) solution() {
+	if !h.card.ShowSolutions {
+		fmt.Fprintln(h.out, "🔒 This deck doesn't show solutions")
+		return
+	}
+	h.accessed = true
+	printAnswer(h.out, h.card)
+}
 
-			parsedRating, err := domain.ParseRating(ratingInput)
-			if err != nil {
-				fmt.Printf("❌ Invalid rating: %v\n", err)
+// recallInput is what the learner chose at the recall prompt
+type recallInput struct {
+	command  string // Typed command, empty when shell, setAside, or undo is set
+	shell    bool   // Open an interactive shell instead
+	setAside string // Skip, bury, or suspend the card instead
+	undo     bool   // Take back the previous rating instead
+}
+
+// Words that set the current card aside instead of attempting or rating it
+const (
+	setAsideSkip    = "skip"    // Move the card to the end of the session
+	setAsideBury    = "bury"    // Hide the card until tomorrow
+	setAsideSuspend = "suspend" // Hide the card until it is unsuspended
+)
+
+// isSetAside reports whether text asks to skip, bury, or suspend the card
+func isSetAside(text string) bool {
+	return text == setAsideSkip || text == setAsideBury || text == setAsideSuspend
+}
+
+// setAsideCard skips, buries, or suspends card and says what happened to it
+func setAsideCard(ctx context.Context, service review.ReviewService, sessionID string, card *review.ReviewCard, how string, w io.Writer) error {
+	var err error
+	switch how {
+	case setAsideSkip:
+		err = service.SkipCard(ctx, sessionID, card.ID)
+	case setAsideBury:
+		err = service.BuryCard(ctx, sessionID, card.ID)
+	default:
+		err = service.SuspendCard(ctx, sessionID, card.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to %s card: %w", how, err)
+	}
+	fmt.Fprintln(w, "↪️  "+setAsideMessage(how, card))
+	return nil
+}
+
+// undoWord takes back the session's last rating at a card's first prompt
+const undoWord = "undo"
+
+// undoRating takes back the session's last rating, so that card is shown next
+// The card being shown stays in the queue behind it.
+func undoRating(ctx context.Context, service review.ReviewService, sessionID string, w io.Writer) error {
+	undone, err := service.UndoReview(ctx, sessionID)
+	if errors.Is(err, review.ErrNothingToUndo) {
+		fmt.Fprintln(w, "🤷 Nothing to undo yet in this session")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to undo rating: %w", err)
+	}
+	fmt.Fprintln(w, "↩️  "+undoMessage(undone))
+	return nil
+}
+
+// undoMessage tells the learner which rating was taken back
+func undoMessage(undone *review.UndoneReview) string {
+	return fmt.Sprintf("Undid %s on %s: rate it again", undone.Rating, undone.Title)
+}
+
+// setAsideMessage tells the learner what happened to a card they set aside
+func setAsideMessage(how string, card *review.ReviewCard) string {
+	switch how {
+	case setAsideSkip:
+		return "Skipped: the card is back at the end of the session"
+	case setAsideBury:
+		return "Buried: the card is hidden until tomorrow"
+	default:
+		return fmt.Sprintf("Suspended: bring the card back with 'ancli card unsuspend %d/%s'", card.DeckID, card.CardKey)
+	}
+}
+
+// readRecallAttempt prompts until the learner types a command or asks for a shell
+// 'h' shows the hint and 's' (or '?') the solution, both counted as help, '!'
+// opens an interactive shell when stdin is a terminal, skip, bury, or suspend sets
+// the card aside, and undo takes back the previous rating. ok is false when the
+// learner quits or input ends.
+func readRecallAttempt(scanner *bufio.Scanner, help *cardHelp) (input recallInput, ok bool) {
+	for {
+		fmt.Fprint(help.out, "⌨️  Type the command (h=hint, s=solution, !=shell, skip/bury/suspend, undo, q=quit): ")
+		if !scanner.Scan() {
+			return input, false
+		}
+
+		text := strings.TrimSpace(scanner.Text())
+		switch text {
+		case "":
+			continue
+		case "q", "quit":
+			return input, false
+		case "h":
+			help.hint()
+		case "s", "?":
+			help.solution()
+		case "!":
+			if !stdinIsTerminal() {
+				fmt.Fprintln(help.out, "❌ An interactive shell needs a terminal")
 				continue
 			}
-			rating = parsedRating
-			break
+			input.shell = true
+			return input, true
+		case setAsideSkip, setAsideBury, setAsideSuspend:
+			input.setAside = text
+			return input, true
+		case undoWord:
+			input.undo = true
+			return input, true
+		default:
+			input.command = text
+			return input, true
 		}
+	}
+}
+
+// printRatingPrompt lists the ratings, with the interval each would give the card
+// and its current retrievability when a preview is available
+func printRatingPrompt(w io.Writer, preview *review.SchedulePreview) {
+	if preview == nil {
+		fmt.Fprint(w, "\n⭐ Rate your performance (1=Again, 2=Hard, 3=Good, 4=Easy)")
+		return
+	}
 
-		// Create execution result
-		var executionResult *domain.ExecutionResult
-		if result != nil {
-			executionResult = &domain.ExecutionResult{
-				Success:        result.Success,
-				ExitCode:       result.ExitCode,
-				Stdout:         result.Stdout,
-				Stderr:         result.Stderr,
-				Duration:       result.Duration,
-				ThinkingTime:   thinkingTime,
-				ContainerID:    result.ContainerID,
-				ImageUsed:      result.ImageUsed,
-				NetworkEnabled: card.NetworkEnabled,
+	if preview.Retrievability > 0 {
+		fmt.Fprintf(w, "\n⭐ Rate your performance (recall chance %.0f%%)\n", preview.Retrievability*100)
+	} else {
+		fmt.Fprint(w, "\n⭐ Rate your performance (new card)\n")
+	}
+	options := make([]string, 0, len(preview.Options))
+	for _, option := range preview.Options {
+		options = append(options, fmt.Sprintf("%d=%s %s", option.Rating, option.Rating, formatInterval(option.Interval)))
+	}
+	fmt.Fprint(w, "   "+strings.Join(options, "  "))
+}
+
+// formatInterval renders a scheduling interval, rounded, in the largest unit that fits
+func formatInterval(d time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case d < 30*time.Second:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(math.Round(d.Minutes())))
+	case d < day:
+		return fmt.Sprintf("%dh", int(math.Round(d.Hours())))
+	case d < 30*day:
+		return fmt.Sprintf("%dd", int(math.Round(d.Hours()/24)))
+	case d < 365*day:
+		return fmt.Sprintf("%.1fmo", d.Hours()/24/30)
+	default:
+		return fmt.Sprintf("%.1fy", d.Hours()/24/365)
+	}
+}
+
+// readRating prompts until the learner rates the card, asks to retry it, sets it
+// aside, or quits
+// Enter accepts the suggested rating when there is one, and 's' shows the solution.
+// 'h' is not offered here because it already means Hard.
+func readRating(scanner *bufio.Scanner, help *cardHelp, suggested domain.Rating, preview *review.SchedulePreview) (rating domain.Rating, retry bool, setAside string, quit bool) {
+	for {
+		printRatingPrompt(help.out, preview)
+		if suggested != 0 {
+			fmt.Fprintf(help.out, " [Enter = %d %s]", suggested, suggested)
+		}
+		fmt.Fprint(help.out, "\n   r=retry  s=solution  skip/bury/suspend  q=quit: ")
+		if !scanner.Scan() {
+			return 0, false, "", true
+		}
+
+		input := strings.TrimSpace(scanner.Text())
+		switch input {
+		case "q", "quit":
+			return 0, false, "", true
+		case "r":
+			return 0, true, "", false
+		case setAsideSkip, setAsideBury, setAsideSuspend:
+			return 0, false, input, false
+		case "s":
+			help.solution()
+			continue
+		case "":
+			if suggested != 0 {
+				return suggested, false, "", false
 			}
 		}
 
-		// Submit review
-		err = app.ReviewService.SubmitReview(ctx, session.ID, card.ID, rating, executionResult)
+		parsedRating, err := domain.ParseRating(input)
 		if err != nil {
-			return fmt.Errorf("failed to submit review: %w", err)
+			fmt.Fprintf(help.out, "❌ Invalid rating: %v\n", err)
+			continue
 		}
+		return parsedRating, false, "", false
+	}
+}
 
-		fmt.Printf("✅ Review submitted! Rating: %s\n", rating.String())
-		cardsReviewed++
-		session.CardsRemaining--
+// offerExplanation lets the learner read the card's explanation after rating it
+func offerExplanation(w io.Writer, scanner *bufio.Scanner, card *review.ReviewCard) {
+	if card.Explanation == "" || !card.ShowExplanations {
+		return
 	}
 
-cleanup:
-	// End session and show stats
-	fmt.Println("\n📊 Finalizing session...")
-	stats, err := app.ReviewService.EndSession(ctx, session.ID)
-	if err != nil {
-		fmt.Printf("Warning: Failed to get session stats: %v\n", err)
-	} else {
-		fmt.Printf("🎯 Session completed in %v\n", stats.Duration.Round(time.Second))
-		fmt.Printf("📈 Cards reviewed: %d\n", stats.CardsReviewed)
+	fmt.Fprint(w, "📘 x=explanation, Enter to continue: ")
+	if scanner.Scan() && strings.TrimSpace(scanner.Text()) == "x" {
+		fmt.Fprintf(w, "📘 %s\n", card.Explanation)
 	}
+}
 
-	fmt.Println("👋 Thanks for studying!")
-	return nil
+// stdinIsTerminal reports whether stdin is a terminal rather than a pipe or file
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printAnswer shows the card's accepted solutions
+func printAnswer(w io.Writer, card *review.ReviewCard) {
+	solutions := card.SolutionAlternatives()
+	if len(solutions) == 1 {
+		fmt.Fprintf(w, "💡 Answer: %s\n", solutions[0])
+		return
+	}
+	fmt.Fprintln(w, "💡 Accepted answers:")
+	for _, solution := range solutions {
+		fmt.Fprintf(w, "  • %s\n", solution)
+	}
+}
+
+// printJudgement reports whether a recall attempt was correct
+// A card's verify is the objective check; cards without one are judged by their
+// solutions. The answer is shown after a miss when the deck allows it, which counts
+// as help for any retry.
+func printJudgement(attempt *review.Attempt, help *cardHelp) {
+	card := help.card
+	icon := "❌"
+	if !attempt.Judged() {
+		icon = "ℹ️ "
+	} else if attempt.Correct {
+		icon = "🎯"
+	}
+	fmt.Fprintf(help.out, "\n%s %s\n", icon, judgement(attempt, card))
+	if !attempt.Correct && card.ShowSolutions {
+		help.solution()
+	}
+}
+
+// judgement describes how a recall attempt was judged
+func judgement(attempt *review.Attempt, card *review.ReviewCard) string {
+	switch {
+	case !attempt.Judged():
+		return "This card has no verify, so the shell session can't be checked"
+	case attempt.Correct && attempt.Verified != nil:
+		return "Correct: verify passed"
+	case attempt.Correct:
+		return "Correct: matches the solution"
+	case attempt.Verified != nil:
+		return fmt.Sprintf("Not quite: verify failed (%s)", card.Verify)
+	default:
+		return "Not quite: that isn't one of the card's solutions"
+	}
+}
+
+// printQueueExplanation shows the order cards would be reviewed in and which new
+// cards are waiting on prerequisites
+func printQueueExplanation(w io.Writer, explanation *review.QueueExplanation) {
+	fmt.Fprintf(w, "📋 Review queue (%d cards)\n", len(explanation.Queue))
+	for i, card := range explanation.Queue {
+		fmt.Fprintf(w, "  %2d. %s", i+1, card.CardKey)
+		if len(card.Prerequisites) > 0 {
+			fmt.Fprintf(w, "  (after %s)", strings.Join(card.Prerequisites, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+
+	if explanation.Allowance != nil {
+		fmt.Fprintf(w, "\n📅 Left today: %s\n", formatAllowance(explanation.Allowance))
+	}
+
+	if len(explanation.HeldBack) == 0 {
+		fmt.Fprintln(w, "\n✅ No cards held back by prerequisites")
+		return
+	}
+
+	fmt.Fprintf(w, "\n⏸️  Held back (%d cards)\n", len(explanation.HeldBack))
+	for _, card := range explanation.HeldBack {
+		fmt.Fprintf(w, "  • %s: waiting for %s to reach Review\n", card.CardKey, strings.Join(card.WaitingOn, ", "))
+	}
+}
+
+// formatAllowance describes what is left of the daily limits, e.g. "5 new, unlimited reviews"
+func formatAllowance(allowance *review.DailyAllowance) string {
+	count := func(n *int) string {
+		if n == nil {
+			return "unlimited"
+		}
+		return strconv.Itoa(*n)
+	}
+	return fmt.Sprintf("%s new, %s reviews", count(allowance.NewCards), count(allowance.Reviews))
 }
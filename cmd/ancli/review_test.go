@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justinlyon12/ancli/internal/domain"
+	"github.com/justinlyon12/ancli/internal/review"
+)
+
+func TestFormatInterval(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		want     string
+	}{
+		{10 * time.Second, "<1m"},
+		{time.Minute + 20*time.Millisecond, "1m"},
+		{10 * time.Minute, "10m"},
+		{5*time.Hour + 10*time.Minute, "5h"},
+		{2 * 24 * time.Hour, "2d"},
+		{45 * 24 * time.Hour, "1.5mo"},
+		{400 * 24 * time.Hour, "1.1y"},
+	}
+
+	for _, tt := range tests {
+		if got := formatInterval(tt.interval); got != tt.want {
+			t.Errorf("formatInterval(%v) = %q, want %q", tt.interval, got, tt.want)
+		}
+	}
+}
+
+func TestPrintSessionStats(t *testing.T) {
+	stats := &review.SessionStats{
+		Duration:             4*time.Minute + 12*time.Second,
+		CardsReviewed:        4,
+		NewCards:             2,
+		ReviewCards:          1,
+		RelearningCards:      1,
+		AgainCount:           1,
+		GoodCount:            3,
+		AverageRating:        2.5,
+		AverageThinkingTime:  3200 * time.Millisecond,
+		AverageExecutionTime: 150 * time.Millisecond,
+		CommandsRun:          4,
+		CommandsSucceeded:    3,
+		CommandSuccessRate:   0.75,
+		LapsedCards:          []review.LapsedCard{{CardID: 7, CardKey: "list-files", Title: "List files"}},
+	}
+
+	var buf bytes.Buffer
+	printSessionStats(&buf, stats)
+	out := buf.String()
+
+	for _, want := range []string{
+		"Session completed in 4m12s",
+		"Cards reviewed: 4 (2 new, 0 learning, 1 review, 1 relearning)",
+		"Again 1 · Hard 0 · Good 3 · Easy 0 (average 2.50)",
+		"Per card: 3.2s thinking, 150ms running",
+		"Commands: 3 of 4 succeeded (75%)",
+		"list-files: List files",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected summary to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintJudgement_RevealedAnswerCountsAsHelp(t *testing.T) {
+	card := &review.ReviewCard{Command: "mkdir my_project", ShowSolutions: true}
+	missed := &review.Attempt{Command: "ls"}
+
+	help := &cardHelp{card: card, out: io.Discard}
+	printJudgement(missed, help)
+	if !help.accessed {
+		t.Error("expected the answer shown after a miss to count as help")
+	}
+	retried := review.SuggestRating(review.Outcome{Passed: true, Attempts: 1, HelpAccessed: help.accessed})
+	if retried != domain.Hard {
+		t.Errorf("expected a pass after the answer was shown to suggest Hard, got %s", retried)
+	}
+
+	// A deck that hides solutions shows nothing, so no help was had
+	card.ShowSolutions = false
+	help = &cardHelp{card: card, out: io.Discard}
+	printJudgement(missed, help)
+	if help.accessed {
+		t.Error("expected no help recorded when the deck hides solutions")
+	}
+}
+
+func TestRunReview_JSONOutput(t *testing.T) {
+	run := func(t *testing.T, input string, flags map[string]string) (stdout, stderr string) {
+		t.Helper()
+		cmd := NewReviewCmd(nil)
+		for name, value := range flags {
+			if err := cmd.Flags().Set(name, value); err != nil {
+				t.Fatalf("failed to set --%s: %v", name, err)
+			}
+		}
+		var out, errOut bytes.Buffer
+		cmd.SetIn(strings.NewReader(input))
+		cmd.SetOut(&out)
+		cmd.SetErr(&errOut)
+
+		if err := runReview(cmd, nil, newFakeReviewService(tuiCard(1))); err != nil {
+			t.Fatalf("runReview returned error: %v", err)
+		}
+		return out.String(), errOut.String()
+	}
+
+	// A finished session prints only the summary on stdout; the prompts go to stderr
+	stdout, stderr := run(t, "\n3\n\n", map[string]string{"json": "true", "no-tui": "true", "mode": modeShow})
+	var stats review.SessionStats
+	if err := json.Unmarshal([]byte(stdout), &stats); err != nil {
+		t.Fatalf("expected stdout to be the JSON summary, got %v:\n%s", err, stdout)
+	}
+	if stats.SessionID != "session" {
+		t.Errorf("expected the session's summary, got %+v", stats)
+	}
+	for _, want := range []string{"Session started with 1 cards", "Card: Create project directory", "Review submitted"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("expected stderr to contain %q, got:\n%s", want, stderr)
+		}
+	}
+
+	// A parked session is reported as a JSON object too
+	stdout, _ = run(t, "q\n", map[string]string{"json": "true", "no-tui": "true"})
+	var paused struct {
+		Status         string `json:"status"`
+		ID             string `json:"id"`
+		CardsRemaining int    `json:"cards_remaining"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &paused); err != nil {
+		t.Fatalf("expected stdout to be JSON when the session is parked, got %v:\n%s", err, stdout)
+	}
+	if paused.Status != "paused" || paused.ID != "session" || paused.CardsRemaining != 1 {
+		t.Errorf("expected the parked session with 1 card left, got %+v", paused)
+	}
+}
+
+func TestFormatAllowance(t *testing.T) {
+	five := 5
+	if got := formatAllowance(&review.DailyAllowance{NewCards: &five}); got != "5 new, unlimited reviews" {
+		t.Errorf("unexpected allowance %q", got)
+	}
+}
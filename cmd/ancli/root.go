@@ -1,9 +1,13 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
+
+	"github.com/justinlyon12/ancli/internal/storage"
 )
 
 // NewRootCmd creates a new root command with lazy initialization
@@ -24,7 +28,9 @@ the FSRS 4-parameter algorithm.`,
 
 	// Add subcommands
 	cmd.AddCommand(NewReviewCmd(loader))
-	cmd.AddCommand(NewDeckCmd())
+	cmd.AddCommand(NewDeckCmd(loader))
+	cmd.AddCommand(NewCardCmd(loader))
+	cmd.AddCommand(NewDBCmd(loader))
 
 	return cmd
 }
@@ -55,3 +61,41 @@ func initializeApp(loader ConfigLoader) (*App, error) {
 
 	return app, nil
 }
+
+// openStorage loads configuration and opens only the database
+// Deck management commands use this so they work on hosts without a sandbox driver
+func openStorage(loader ConfigLoader) (*storage.DB, error) {
+	dbPath, err := databasePath(loader)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := storage.NewDB(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return db, nil
+}
+
+// databasePath loads configuration and resolves the database file path
+func databasePath(loader ConfigLoader) (string, error) {
+	cfg, err := loader.Load()
+	if err != nil {
+		return "", fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	dbPath, err := cfg.GetDatabasePath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get database path: %w", err)
+	}
+
+	return dbPath, nil
+}
+
+// writeJSON writes v to w as indented JSON for --json output
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/justinlyon12/ancli/internal/domain"
+	"github.com/justinlyon12/ancli/internal/review"
+)
+
+// scriptAnswer is one line of a review script: what to do with the next card
+type scriptAnswer struct {
+	CardKey string `json:"card_key,omitempty"` // Expected card, checked when set
+	Command string `json:"command,omitempty"`  // Typed command in recall mode; show mode runs the card's command
+	Rating  int    `json:"rating,omitempty"`   // 1-4, or 0 to accept the suggested rating
+	Action  string `json:"action,omitempty"`   // skip, bury, suspend, undo, or quit instead of rating
+}
+
+// Script events, one JSON object per line on stdout
+const (
+	eventSessionStarted = "session_started"
+	eventCardShown      = "card_shown"
+	eventExecution      = "execution_result"
+	eventRatingApplied  = "rating_applied"
+	eventCardSetAside   = "card_set_aside"
+	eventRatingUndone   = "rating_undone"
+	eventSessionPaused  = "session_paused"
+	eventSessionEnded   = "session_ended"
+	eventError          = "error"
+)
+
+// scriptSessionEvent reports a session starting, pausing, or ending
+type scriptSessionEvent struct {
+	Event          string               `json:"event"`
+	SessionID      string               `json:"session_id"`
+	CardsReviewed  int                  `json:"cards_reviewed"`
+	CardsRemaining int                  `json:"cards_remaining"`
+	Resumed        bool                 `json:"resumed,omitempty"`
+	Stats          *review.SessionStats `json:"stats,omitempty"` // Set when the session ended
+}
+
+// scriptErrorEvent reports why a script stopped early; the session is paused after it
+type scriptErrorEvent struct {
+	Event   string `json:"event"`
+	Line    int    `json:"line,omitempty"` // Script line last read, when the script was read
+	Message string `json:"message"`
+}
+
+// scriptCardEvent reports the card being shown; the command only in show mode
+type scriptCardEvent struct {
+	Event       string `json:"event"`
+	CardID      int    `json:"card_id"`
+	CardKey     string `json:"card_key"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Command     string `json:"command,omitempty"`
+}
+
+// scriptExecutionEvent reports a command run against the card
+type scriptExecutionEvent struct {
+	Event           string `json:"event"`
+	CardID          int    `json:"card_id"`
+	Command         string `json:"command"`
+	ExitCode        int    `json:"exit_code"`
+	Success         bool   `json:"success"`
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr"`
+	DurationMs      int64  `json:"duration_ms"`
+	Correct         *bool  `json:"correct,omitempty"`          // Recall attempts only
+	SuggestedRating int    `json:"suggested_rating,omitempty"` // Recall attempts only
+	Error           string `json:"error,omitempty"`            // The command could not run
+}
+
+// scriptRatingEvent reports the rating applied to a card and when it is due next
+type scriptRatingEvent struct {
+	Event          string     `json:"event"`
+	CardID         int        `json:"card_id"`
+	Rating         int        `json:"rating"`
+	RatingName     string     `json:"rating_name"`
+	NextDue        *time.Time `json:"next_due,omitempty"`
+	CardsRemaining int        `json:"cards_remaining"`
+}
+
+// scriptSetAsideEvent reports a card skipped, buried, or suspended
+type scriptSetAsideEvent struct {
+	Event          string `json:"event"`
+	CardID         int    `json:"card_id"`
+	Action         string `json:"action"`
+	CardsRemaining int    `json:"cards_remaining"`
+}
+
+// scriptUndoEvent reports the previous rating taken back; that card is shown next
+type scriptUndoEvent struct {
+	Event          string `json:"event"`
+	CardID         int    `json:"card_id"`
+	CardKey        string `json:"card_key"`
+	Rating         int    `json:"rating"`
+	CardsRemaining int    `json:"cards_remaining"`
+}
+
+// openScript opens the answers file for --script, "-" meaning stdin
+func openScript(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open review script: %w", err)
+	}
+	return file, nil
+}
+
+// scriptReview drives a session from JSON-lines answers and writes JSON-lines events
+type scriptReview struct {
+	ctx     context.Context
+	service review.ReviewService
+	session *review.Session
+	mode    string
+	answers *bufio.Scanner
+	events  *json.Encoder
+	line    int // Line of the script last read
+}
+
+// newScriptReview prepares a scripted review of session reading answers from r
+func newScriptReview(ctx context.Context, service review.ReviewService, session *review.Session, mode string, r io.Reader, w io.Writer) *scriptReview {
+	return &scriptReview{
+		ctx:     ctx,
+		service: service,
+		session: session,
+		mode:    mode,
+		answers: bufio.NewScanner(r),
+		events:  json.NewEncoder(w),
+	}
+}
+
+// run reviews cards until the queue is done or the script ends or quits
+// quit is set when the script stops before the queue is done.
+func (s *scriptReview) run() (quit bool, err error) {
+	if err := s.emitSession(eventSessionStarted, nil); err != nil {
+		return false, err
+	}
+
+	for {
+		if err := s.refresh(); err != nil {
+			return false, err
+		}
+		if s.session.CardsRemaining <= 0 {
+			break
+		}
+
+		card, err := s.service.GetNextCard(s.ctx, s.session.ID)
+		if err != nil {
+			if errors.Is(err, review.ErrQueueEmpty) {
+				break
+			}
+			return false, fmt.Errorf("failed to get next card: %w", err)
+		}
+
+		quit, err := s.reviewCard(card)
+		if err != nil || quit {
+			return quit, err
+		}
+	}
+	return false, nil
+}
+
+// reviewCard shows one card, runs its command, and applies the script's rating
+func (s *scriptReview) reviewCard(card *review.ReviewCard) (quit bool, err error) {
+	shown := scriptCardEvent{Event: eventCardShown, CardID: card.ID, CardKey: card.CardKey, Title: card.Title, Description: card.Description}
+	if s.mode == modeShow {
+		shown.Command = card.Command
+	}
+	if err := s.events.Encode(shown); err != nil {
+		return false, err
+	}
+
+	answer, ok, err := s.nextAnswer()
+	if err != nil || !ok {
+		return !ok, err
+	}
+	// An undo answers the card being shown with the rating it takes back, so its
+	// card_key names the card rated before
+	if answer.Action == undoWord {
+		return false, s.undo(answer.CardKey)
+	}
+	if answer.CardKey != "" && answer.CardKey != card.CardKey {
+		return false, fmt.Errorf("script line %d: expected card %s, got %s", s.line, answer.CardKey, card.CardKey)
+	}
+
+	switch answer.Action {
+	case "":
+	case "quit":
+		return true, nil
+	case setAsideSkip, setAsideBury, setAsideSuspend:
+		return false, s.setAside(card, answer.Action)
+	default:
+		return false, fmt.Errorf("script line %d: unknown action %q (expected skip, bury, suspend, undo, or quit)", s.line, answer.Action)
+	}
+
+	command := answer.Command
+	if s.mode == modeShow {
+		command = card.Command
+	}
+
+	var attempt *review.Attempt
+	var suggested domain.Rating
+	attempts := 0
+	if command != "" {
+		attempts = 1
+		if attempt, err = s.attempt(card, command); err != nil {
+			return false, err
+		}
+		if s.mode == modeRecall && attempt != nil && attempt.Judged() {
+			suggested = review.SuggestRating(review.Outcome{Passed: attempt.Correct, Attempts: 1})
+		}
+	}
+
+	rating := domain.Rating(answer.Rating)
+	if rating == 0 {
+		rating = suggested
+	}
+	if rating < domain.Again || rating > domain.Easy {
+		if rating == 0 {
+			return false, fmt.Errorf("script line %d: no rating for card %s and no suggested rating", s.line, card.CardKey)
+		}
+		return false, fmt.Errorf("script line %d: invalid rating %d (expected 1-4)", s.line, rating)
+	}
+
+	// The preview is only for reporting, so rate without it if it can't be computed
+	var nextDue *time.Time
+	if preview, err := s.service.PreviewSchedule(s.ctx, card.ID); err == nil {
+		for _, option := range preview.Options {
+			if option.Rating == rating {
+				nextDue = &option.Due
+			}
+		}
+	}
+
+	executionResult := newExecutionResult(card, attempt, attempts, 0, false, suggested)
+	if err := s.service.SubmitReview(s.ctx, s.session.ID, card.ID, rating, executionResult); err != nil {
+		return false, fmt.Errorf("failed to submit review: %w", err)
+	}
+	if err := s.refresh(); err != nil {
+		return false, err
+	}
+
+	return false, s.events.Encode(scriptRatingEvent{
+		Event:          eventRatingApplied,
+		CardID:         card.ID,
+		Rating:         int(rating),
+		RatingName:     rating.String(),
+		NextDue:        nextDue,
+		CardsRemaining: s.session.CardsRemaining,
+	})
+}
+
+// attempt runs command against card and reports the result
+// It returns nil when the command could not run; the card can still be rated.
+func (s *scriptReview) attempt(card *review.ReviewCard, command string) (*review.Attempt, error) {
+	attempt, err := s.service.AttemptCard(s.ctx, s.session.ID, card, command)
+	if err != nil {
+		return nil, s.events.Encode(scriptExecutionEvent{
+			Event: eventExecution, CardID: card.ID, Command: command, ExitCode: -1, Error: err.Error(),
+		})
+	}
+
+	result := attempt.Result
+	event := scriptExecutionEvent{
+		Event:      eventExecution,
+		CardID:     card.ID,
+		Command:    command,
+		ExitCode:   result.ExitCode,
+		Success:    result.Success,
+		Stdout:     result.Stdout,
+		Stderr:     result.Stderr,
+		DurationMs: result.Duration.Milliseconds(),
+	}
+	if s.mode == modeRecall && attempt.Judged() {
+		event.Correct = &attempt.Correct
+		event.SuggestedRating = int(review.SuggestRating(review.Outcome{Passed: attempt.Correct, Attempts: 1}))
+	}
+	return attempt, s.events.Encode(event)
+}
+
+// setAside skips, buries, or suspends card and reports it
+func (s *scriptReview) setAside(card *review.ReviewCard, how string) error {
+	var err error
+	switch how {
+	case setAsideSkip:
+		err = s.service.SkipCard(s.ctx, s.session.ID, card.ID)
+	case setAsideBury:
+		err = s.service.BuryCard(s.ctx, s.session.ID, card.ID)
+	default:
+		err = s.service.SuspendCard(s.ctx, s.session.ID, card.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to %s card: %w", how, err)
+	}
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	return s.events.Encode(scriptSetAsideEvent{
+		Event: eventCardSetAside, CardID: card.ID, Action: how, CardsRemaining: s.session.CardsRemaining,
+	})
+}
+
+// undo takes back the previous rating and reports it
+// cardKey, when set, must be the card whose rating is undone.
+func (s *scriptReview) undo(cardKey string) error {
+	undone, err := s.service.UndoReview(s.ctx, s.session.ID)
+	if errors.Is(err, review.ErrNothingToUndo) {
+		return fmt.Errorf("script line %d: %w", s.line, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to undo rating: %w", err)
+	}
+	if cardKey != "" && cardKey != undone.CardKey {
+		return fmt.Errorf("script line %d: expected to undo card %s, undid %s", s.line, cardKey, undone.CardKey)
+	}
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	return s.events.Encode(scriptUndoEvent{
+		Event:          eventRatingUndone,
+		CardID:         undone.CardID,
+		CardKey:        undone.CardKey,
+		Rating:         int(undone.Rating),
+		CardsRemaining: s.session.CardsRemaining,
+	})
+}
+
+// refresh reads the session's current progress from the service
+func (s *scriptReview) refresh() error {
+	session, err := s.service.SessionStatus(s.ctx, s.session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get session progress: %w", err)
+	}
+	s.session = session
+	return nil
+}
+
+// nextAnswer reads the script's next answer, skipping blank lines
+// ok is false when the script has ended.
+func (s *scriptReview) nextAnswer() (answer scriptAnswer, ok bool, err error) {
+	for s.answers.Scan() {
+		s.line++
+		text := strings.TrimSpace(s.answers.Text())
+		if text == "" {
+			continue
+		}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&answer); err != nil {
+			return answer, false, fmt.Errorf("script line %d: %w", s.line, err)
+		}
+		return answer, true, nil
+	}
+	if err := s.answers.Err(); err != nil {
+		return answer, false, fmt.Errorf("failed to read review script: %w", err)
+	}
+	return answer, false, nil
+}
+
+// abort parks the session after cause stopped the script, so it is left to --resume
+// rather than active, and reports the error and the pause
+func (s *scriptReview) abort(cause error) error {
+	if err := s.service.PauseSession(s.ctx, s.session.ID); err != nil {
+		return fmt.Errorf("failed to pause review session: %w", err)
+	}
+	if err := s.events.Encode(scriptErrorEvent{Event: eventError, Line: s.line, Message: cause.Error()}); err != nil {
+		return err
+	}
+	return s.emitSession(eventSessionPaused, nil)
+}
+
+// emitSession reports the session's progress; stats are set when it ended
+func (s *scriptReview) emitSession(event string, stats *review.SessionStats) error {
+	return s.events.Encode(scriptSessionEvent{
+		Event:          event,
+		SessionID:      s.session.ID,
+		CardsReviewed:  s.session.CardsReviewed,
+		CardsRemaining: s.session.CardsRemaining,
+		Resumed:        s.session.Resumed,
+		Stats:          stats,
+	})
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/justinlyon12/ancli/internal/domain"
+)
+
+// scriptEvents decodes the JSON-lines events a scripted review wrote
+func scriptEvents(t *testing.T, out string) []map[string]any {
+	t.Helper()
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var event map[string]any
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("event %q is not JSON: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestRunScriptedReview(t *testing.T) {
+	first, second, third := tuiCard(1), tuiCard(2), tuiCard(3)
+	first.CardKey, second.CardKey, third.CardKey = "create-dir", "list-files", "remove-dir"
+	service := newFakeReviewService(first, second, third)
+
+	answers := strings.Join([]string{
+		`{"card_key":"create-dir","command":"mkdir my_project"}`,
+		``,
+		`{"action":"skip"}`,
+		`{"rating":1}`,
+		`{"command":"ls","rating":3}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := runScriptedReview(context.Background(), service, service.session, modeRecall, strings.NewReader(answers), &out); err != nil {
+		t.Fatalf("runScriptedReview failed: %v", err)
+	}
+
+	var kinds []string
+	events := scriptEvents(t, out.String())
+	for _, event := range events {
+		kinds = append(kinds, event["event"].(string))
+	}
+	want := "session_started card_shown execution_result rating_applied card_shown card_set_aside " +
+		"card_shown rating_applied card_shown execution_result rating_applied session_ended"
+	if strings.Join(kinds, " ") != want {
+		t.Fatalf("unexpected events:\n got %s\nwant %s", strings.Join(kinds, " "), want)
+	}
+
+	// A correct recall attempt with no rating accepts the suggested rating
+	if events[2]["correct"] != true || events[3]["rating"] != float64(domain.Easy) {
+		t.Errorf("expected the correct attempt to be rated Easy, got %v then %v", events[2], events[3])
+	}
+	if _, ok := events[3]["next_due"]; !ok {
+		t.Errorf("expected the next due time to be reported, got %v", events[3])
+	}
+	// Recall cards don't reveal their command
+	if _, ok := events[1]["command"]; ok {
+		t.Errorf("expected the command hidden in recall mode, got %v", events[1])
+	}
+
+	if len(service.submitted) != 3 || service.submitted[1] != domain.Again || service.submitted[2] != domain.Good {
+		t.Errorf("expected ratings Easy, Again, Good, got %v", service.submitted)
+	}
+	if len(service.attempts) != 2 || service.attempts[1] != "ls" {
+		t.Errorf("expected the typed commands to run, got %v", service.attempts)
+	}
+	if events[len(events)-1]["stats"] == nil {
+		t.Error("expected the session statistics in session_ended")
+	}
+}
+
+func TestRunScriptedReview_PausesWhenScriptEnds(t *testing.T) {
+	service := newFakeReviewService(tuiCard(1), tuiCard(2))
+
+	var out bytes.Buffer
+	if err := runScriptedReview(context.Background(), service, service.session, modeShow, strings.NewReader(`{"rating":3}`), &out); err != nil {
+		t.Fatalf("runScriptedReview failed: %v", err)
+	}
+
+	events := scriptEvents(t, out.String())
+	if last := events[len(events)-1]; last["event"] != eventSessionPaused || last["cards_remaining"] != float64(1) {
+		t.Errorf("expected the session paused with 1 card left, got %v", last)
+	}
+	// Show mode runs the card's own command
+	if len(service.attempts) != 1 || service.attempts[0] != "mkdir my_project" {
+		t.Errorf("expected the card's command to run, got %v", service.attempts)
+	}
+}
+
+func TestRunScriptedReview_RejectsBadAnswers(t *testing.T) {
+	tests := map[string]string{
+		`{"card_key":"other"}`:   "expected card other",
+		`{"action":"explode"}`:   "unknown action",
+		`{"rating":7}`:           "invalid rating 7",
+		`{}`:                     "no rating",
+		`{"rating":3,"extra":1}`: "unknown field",
+	}
+
+	for answer, want := range tests {
+		card := tuiCard(1)
+		card.CardKey = "create-dir"
+		service := newFakeReviewService(card)
+		err := runScriptedReview(context.Background(), service, service.session, modeRecall, strings.NewReader(answer), &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("answer %s: expected error containing %q, got %v", answer, want, err)
+		}
+	}
+}
+
+func TestRunScriptedReview_PausesOnMismatch(t *testing.T) {
+	first, second := tuiCard(1), tuiCard(2)
+	first.CardKey, second.CardKey = "create-dir", "list-files"
+	service := newFakeReviewService(first, second)
+
+	answers := "{\"rating\":3}\n{\"card_key\":\"remove-dir\",\"rating\":3}\n"
+	var out bytes.Buffer
+	err := runScriptedReview(context.Background(), service, service.session, modeShow, strings.NewReader(answers), &out)
+	if err == nil || !strings.Contains(err.Error(), "expected card remove-dir, got list-files") {
+		t.Fatalf("expected a card mismatch error, got %v", err)
+	}
+	if service.paused != 1 {
+		t.Errorf("expected the session paused once, got %d", service.paused)
+	}
+
+	// The stream ends with the error and the pause rather than stopping at card_shown
+	events := scriptEvents(t, out.String())
+	if len(events) < 2 {
+		t.Fatalf("expected closing events, got %v", events)
+	}
+	failure, last := events[len(events)-2], events[len(events)-1]
+	if failure["event"] != eventError || failure["line"] != float64(2) ||
+		!strings.Contains(failure["message"].(string), "expected card remove-dir") {
+		t.Errorf("expected an error event for script line 2, got %v", failure)
+	}
+	if last["event"] != eventSessionPaused || last["cards_remaining"] != float64(1) {
+		t.Errorf("expected the session paused with 1 card left, got %v", last)
+	}
+}
+
+func TestRunScriptedReview_Undo(t *testing.T) {
+	first, second := tuiCard(1), tuiCard(2)
+	first.CardKey, second.CardKey = "create-dir", "list-files"
+	service := newFakeReviewService(first, second)
+
+	answers := strings.Join([]string{
+		`{"rating":1}`,
+		`{"card_key":"create-dir","action":"undo"}`,
+		`{"card_key":"create-dir","rating":3}`,
+		`{"rating":3}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := runScriptedReview(context.Background(), service, service.session, modeShow, strings.NewReader(answers), &out); err != nil {
+		t.Fatalf("runScriptedReview failed: %v", err)
+	}
+
+	events := scriptEvents(t, out.String())
+	var undone map[string]any
+	for _, event := range events {
+		if event["event"] == eventRatingUndone {
+			undone = event
+		}
+	}
+	if undone == nil || undone["card_key"] != "create-dir" || undone["rating"] != float64(domain.Again) {
+		t.Errorf("expected create-dir's Again to be undone, got %v", undone)
+	}
+	if len(service.rated) != 2 || service.ratings[0] != domain.Good {
+		t.Errorf("expected create-dir re-rated Good, got %v", service.ratings)
+	}
+
+	// Undo with no ratings yet fails the script
+	service = newFakeReviewService(tuiCard(1))
+	err := runScriptedReview(context.Background(), service, service.session, modeShow, strings.NewReader(`{"action":"undo"}`), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "no ratings to undo") {
+		t.Errorf("expected an error undoing nothing, got %v", err)
+	}
+}
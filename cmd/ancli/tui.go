@@ -0,0 +1,879 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/justinlyon12/ancli/internal/domain"
+	"github.com/justinlyon12/ancli/internal/review"
+	"github.com/justinlyon12/ancli/internal/sandbox"
+)
+
+// Styles used by the TUI
+var (
+	styleBold       = lipgloss.NewStyle().Bold(true)
+	styleDim        = lipgloss.NewStyle().Faint(true)
+	styleError      = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	styleSuccess    = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	styleHelp       = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	styleCommand    = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	styleNetworkOn  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15")).Background(lipgloss.Color("1"))
+	styleNetworkOff = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("0")).Background(lipgloss.Color("2"))
+)
+
+// terminalSupportsTUI reports whether stdin and stdout are both an interactive terminal
+func terminalSupportsTUI() bool {
+	if os.Getenv("TERM") == "dumb" || !stdinIsTerminal() {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// tuiPhase is the step of a card the TUI is on
+type tuiPhase int
+
+const (
+	phaseAttempt tuiPhase = iota // Typing a command
+	phaseRating                  // Reviewing the attempt and choosing a rating
+	phaseRated                   // Rated; the explanation can be read before moving on
+)
+
+// attemptMsg reports an attempt finishing, with the schedule preview for rating it
+type attemptMsg struct {
+	attempt *review.Attempt
+	preview *review.SchedulePreview
+	err     error
+}
+
+// resetMsg reports the sandbox environment reset finishing
+type resetMsg struct {
+	err error
+}
+
+// reviewTUI is the full-screen review front-end, a Bubble Tea model
+// Keys update the model and View renders the whole screen from it. Commands, shells,
+// and resets run as tea.Cmds. All review logic stays in the ReviewService.
+type reviewTUI struct {
+	ctx     context.Context
+	service review.ReviewService
+	session *review.Session
+	mode    string
+
+	width, height int
+
+	card    *review.ReviewCard
+	phase   tuiPhase
+	running bool  // A command or reset is in flight
+	done    bool  // The queue is finished
+	quit    bool  // The learner parked the session
+	err     error // Failure that ended the TUI
+
+	input  string
+	output []string // Lines of the output pane
+	scroll int      // Lines scrolled back from the end of the output pane
+	status string   // Message shown under the output pane
+
+	showMeta         bool
+	hintShown        bool
+	solutionShown    bool
+	explanationShown bool
+	helpAccessed     bool
+
+	thinkingStart time.Time
+	thinkingTime  time.Duration
+	attempts      int
+	attempt       *review.Attempt
+	suggested     domain.Rating
+	preview       *review.SchedulePreview
+	rated         domain.Rating
+}
+
+// newReviewTUI creates the TUI model for session
+func newReviewTUI(ctx context.Context, service review.ReviewService, session *review.Session, mode string) *reviewTUI {
+	return &reviewTUI{ctx: ctx, service: service, session: session, mode: mode, width: 80, height: 24}
+}
+
+// runReviewTUI reviews the session's cards in the full-screen interface
+// quit is set when the learner parks the session before the queue is done.
+func runReviewTUI(ctx context.Context, service review.ReviewService, session *review.Session, mode string) (quit bool, err error) {
+	m := newReviewTUI(ctx, service, session, mode)
+	if err := m.nextCard(); err != nil {
+		return false, err
+	}
+	if m.done {
+		return false, nil
+	}
+
+	// Logs would scribble over the screen, so hold them until the TUI exits
+	var logs bytes.Buffer
+	logOutput := log.Writer()
+	log.SetOutput(&logs)
+	defer func() {
+		log.SetOutput(logOutput)
+		_, _ = logOutput.Write(logs.Bytes())
+	}()
+
+	program := tea.NewProgram(m, tea.WithContext(ctx), tea.WithAltScreen())
+	if _, err := program.Run(); err != nil {
+		if errors.Is(err, tea.ErrInterrupted) {
+			return true, nil
+		}
+		return false, fmt.Errorf("review interface failed: %w", err)
+	}
+	if m.err != nil {
+		return false, m.err
+	}
+	return m.quit, nil
+}
+
+// Init implements tea.Model; the first card is loaded before the program starts
+func (m *reviewTUI) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m *reviewTUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var err error
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case attemptMsg:
+		m.running = false
+		m.finishAttempt(msg)
+	case resetMsg:
+		m.running = false
+		m.finishReset(msg.err)
+	case tea.KeyMsg:
+		if msg.Type != tea.KeyRunes || msg.Alt {
+			cmd, err = m.update(msg.String())
+			break
+		}
+		// Typed and pasted text arrives as runes, applied one at a time
+		for _, r := range msg.Runes {
+			if !unicode.IsPrint(r) {
+				continue
+			}
+			if cmd, err = m.update(string(r)); err != nil || cmd != nil || m.done || m.quit {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		m.err = err
+		return m, tea.Quit
+	}
+	if m.done || m.quit {
+		return m, tea.Quit
+	}
+	return m, cmd
+}
+
+// nextCard moves to the next card in the session's queue
+func (m *reviewTUI) nextCard() error {
+	if err := m.refresh(); err != nil {
+		return err
+	}
+	if m.session.CardsRemaining <= 0 {
+		m.done = true
+		return nil
+	}
+
+	card, err := m.service.GetNextCard(m.ctx, m.session.ID)
+	if err != nil {
+		if errors.Is(err, review.ErrQueueEmpty) {
+			m.done = true
+			return nil
+		}
+		return fmt.Errorf("failed to get next card: %w", err)
+	}
+
+	*m = reviewTUI{
+		ctx: m.ctx, service: m.service, session: m.session, mode: m.mode,
+		width: m.width, height: m.height, showMeta: m.showMeta,
+		card:          card,
+		thinkingStart: time.Now(),
+	}
+	m.startAttempt()
+	return nil
+}
+
+// refresh reads the session's current progress from the service
+func (m *reviewTUI) refresh() error {
+	session, err := m.service.SessionStatus(m.ctx, m.session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get session progress: %w", err)
+	}
+	m.session = session
+	return nil
+}
+
+// startAttempt readies the card for a new attempt
+func (m *reviewTUI) startAttempt() {
+	m.phase = phaseAttempt
+	m.input = ""
+	if m.mode == modeShow {
+		m.input = m.card.Command
+	}
+	m.scroll = 0
+}
+
+// update applies one key press to the model
+// Keys are named as by tea.KeyMsg: printable characters are themselves, others are
+// names like "enter", "esc", "pgdown", and "ctrl+t".
+func (m *reviewTUI) update(key string) (tea.Cmd, error) {
+	// Only parking is possible while a command runs
+	if m.running {
+		if key == "ctrl+c" {
+			m.quit = true
+		}
+		return nil, nil
+	}
+
+	// Keys available throughout a card
+	switch key {
+	case "ctrl+c", "ctrl+d", "esc":
+		m.quit = true
+		return nil, nil
+	case "ctrl+t":
+		m.revealHint()
+		return nil, nil
+	case "ctrl+s":
+		m.revealSolution()
+		return nil, nil
+	case "ctrl+o":
+		m.showMeta = !m.showMeta
+		return nil, nil
+	case "ctrl+r":
+		if m.phase != phaseRated {
+			return m.reset(), nil
+		}
+		return nil, nil
+	case "ctrl+n", "ctrl+b", "ctrl+x":
+		if m.phase != phaseRated {
+			return nil, m.setAside(setAsideKeys[key])
+		}
+		return nil, nil
+	case "ctrl+z":
+		return nil, m.undo()
+	case "up":
+		m.scrollBy(1)
+		return nil, nil
+	case "down":
+		m.scrollBy(-1)
+		return nil, nil
+	case "pgup":
+		m.scrollBy(m.outputHeight())
+		return nil, nil
+	case "pgdown":
+		m.scrollBy(-m.outputHeight())
+		return nil, nil
+	}
+
+	switch m.phase {
+	case phaseAttempt:
+		return m.updateAttempt(key), nil
+	case phaseRating:
+		return nil, m.updateRating(key)
+	default:
+		return nil, m.updateRated(key)
+	}
+}
+
+// updateAttempt edits the command line; the command is fixed in show mode
+func (m *reviewTUI) updateAttempt(key string) tea.Cmd {
+	switch key {
+	case "enter":
+		switch strings.TrimSpace(m.input) {
+		case "":
+		case "!":
+			if m.mode == modeRecall {
+				return m.shell()
+			}
+		default:
+			return m.run()
+		}
+	case "backspace":
+		if m.mode == modeRecall && m.input != "" {
+			_, size := utf8.DecodeLastRuneInString(m.input)
+			m.input = m.input[:len(m.input)-size]
+		}
+	case "?":
+		// Commands don't start with '?', so on an empty line it toggles the metadata
+		if m.input == "" || m.mode == modeShow {
+			m.showMeta = !m.showMeta
+		} else if m.mode == modeRecall {
+			m.input += key
+		}
+	case "q":
+		if m.mode == modeShow {
+			m.quit = true
+		} else {
+			m.input += key
+		}
+	case "tab":
+		if m.mode == modeRecall {
+			m.input += " "
+		}
+	default:
+		if m.mode == modeRecall && utf8.RuneCountInString(key) == 1 {
+			m.input += key
+		}
+	}
+	return nil
+}
+
+// updateRating handles the rating row
+func (m *reviewTUI) updateRating(key string) error {
+	switch key {
+	case "q":
+		m.quit = true
+	case "r":
+		m.status = fmt.Sprintf("Attempt %d", m.attempts+1)
+		m.startAttempt()
+	case "s":
+		m.revealSolution()
+	case "?":
+		m.showMeta = !m.showMeta
+	case "enter":
+		if m.suggested != 0 {
+			return m.rate(m.suggested)
+		}
+	default:
+		if rating, err := domain.ParseRating(key); err == nil {
+			return m.rate(rating)
+		}
+	}
+	return nil
+}
+
+// updateRated shows the explanation or moves on to the next card
+func (m *reviewTUI) updateRated(key string) error {
+	switch key {
+	case "q":
+		m.quit = true
+		return nil
+	case "u":
+		return m.undo()
+	case "x":
+		if m.card.Explanation != "" && m.card.ShowExplanations && !m.explanationShown {
+			m.explanationShown = true
+			return nil
+		}
+	}
+	return m.nextCard()
+}
+
+// undo takes back the session's last rating and shows that card again
+// A card part-way through stays in the queue behind it.
+func (m *reviewTUI) undo() error {
+	undone, err := m.service.UndoReview(m.ctx, m.session.ID)
+	if errors.Is(err, review.ErrNothingToUndo) {
+		m.status = "Nothing to undo yet in this session"
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to undo rating: %w", err)
+	}
+
+	if err := m.nextCard(); err != nil {
+		return err
+	}
+	m.status = undoMessage(undone)
+	return nil
+}
+
+// setAsideKeys maps the keys that set a card aside to what they do
+var setAsideKeys = map[string]string{
+	"ctrl+n": setAsideSkip,
+	"ctrl+b": setAsideBury,
+	"ctrl+x": setAsideSuspend,
+}
+
+// setAside skips, buries, or suspends the card and moves on to the next one
+func (m *reviewTUI) setAside(how string) error {
+	card := m.card
+	var err error
+	switch how {
+	case setAsideSkip:
+		err = m.service.SkipCard(m.ctx, m.session.ID, card.ID)
+	case setAsideBury:
+		err = m.service.BuryCard(m.ctx, m.session.ID, card.ID)
+	default:
+		err = m.service.SuspendCard(m.ctx, m.session.ID, card.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to %s card: %w", how, err)
+	}
+
+	if err := m.nextCard(); err != nil {
+		return err
+	}
+	m.status = setAsideMessage(how, card)
+	return nil
+}
+
+// revealHint shows the card's hint, which counts as help
+func (m *reviewTUI) revealHint() {
+	if m.card.Hint == "" {
+		m.status = "This card has no hint"
+		return
+	}
+	m.hintShown = true
+	m.helpAccessed = true
+}
+
+// revealSolution shows the card's solutions if the deck allows it, which counts as help
+func (m *reviewTUI) revealSolution() {
+	if !m.card.ShowSolutions {
+		m.status = "This deck doesn't show solutions"
+		return
+	}
+	m.solutionShown = true
+	m.helpAccessed = true
+}
+
+// scrollBy scrolls the output pane back by lines, or forward when negative
+func (m *reviewTUI) scrollBy(lines int) {
+	m.scroll = max(0, min(m.scroll+lines, len(m.output)-m.outputHeight()))
+}
+
+// run starts the typed command as an attempt at the card
+func (m *reviewTUI) run() tea.Cmd {
+	m.beginAttempt()
+	m.status = "Running..."
+	ctx, service, sessionID, card, command := m.ctx, m.service, m.session.ID, m.card, strings.TrimSpace(m.input)
+	return func() tea.Msg {
+		attempt, err := service.AttemptCard(ctx, sessionID, card, command)
+		return newAttemptMsg(ctx, service, card, attempt, err)
+	}
+}
+
+// shell hands the terminal to an interactive shell attempt at the card
+func (m *reviewTUI) shell() tea.Cmd {
+	m.beginAttempt()
+	shell := &shellAttempt{ctx: m.ctx, service: m.service, sessionID: m.session.ID, card: m.card}
+	return tea.Exec(shell, func(err error) tea.Msg {
+		return newAttemptMsg(shell.ctx, shell.service, shell.card, shell.attempt, err)
+	})
+}
+
+// reset discards the sandbox environment so the next attempt starts fresh
+func (m *reviewTUI) reset() tea.Cmd {
+	m.running = true
+	m.status = "Resetting the environment..."
+	ctx, service, sessionID := m.ctx, m.service, m.session.ID
+	return func() tea.Msg {
+		return resetMsg{err: service.ResetEnvironment(ctx, sessionID)}
+	}
+}
+
+// finishReset shows the outcome of a reset and readies a fresh attempt
+// Failures are shown in the TUI rather than ending the session.
+func (m *reviewTUI) finishReset(err error) {
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.output = nil
+	m.status = "Environment reset: the next attempt starts in a fresh container"
+	m.startAttempt()
+}
+
+// shellAttempt runs an interactive shell attempt as a tea.ExecCommand
+// Bubble Tea releases the terminal to it and restores the screen when it returns.
+type shellAttempt struct {
+	ctx       context.Context
+	service   review.ReviewService
+	sessionID string
+	card      *review.ReviewCard
+	in        io.Reader
+	out       io.Writer
+	attempt   *review.Attempt
+}
+
+func (s *shellAttempt) SetStdin(r io.Reader)  { s.in = r }
+func (s *shellAttempt) SetStdout(w io.Writer) { s.out = w }
+func (s *shellAttempt) SetStderr(io.Writer)   {}
+
+// Run attaches the terminal to the card's shell until the learner leaves it
+func (s *shellAttempt) Run() error {
+	fmt.Fprintln(s.out, "🐚 Opening a shell in the card's container...")
+	if s.card.Verify != "" {
+		fmt.Fprintln(s.out, "   Type 'verify' to check your work and finish, or 'exit' to give up.")
+	} else {
+		fmt.Fprintln(s.out, "   Type 'exit' when you're done.")
+	}
+	var err error
+	s.attempt, err = s.service.ShellAttempt(s.ctx, s.sessionID, s.card, sandbox.Terminal{In: s.in, Out: s.out})
+	return err
+}
+
+// newAttemptMsg reports an attempt with the card's schedule preview
+// The preview is a convenience, so the card is rated without it if it can't be computed.
+func newAttemptMsg(ctx context.Context, service review.ReviewService, card *review.ReviewCard, attempt *review.Attempt, err error) attemptMsg {
+	msg := attemptMsg{attempt: attempt, err: err}
+	msg.preview, _ = service.PreviewSchedule(ctx, card.ID)
+	return msg
+}
+
+// beginAttempt counts an attempt, ending the thinking time at the first
+func (m *reviewTUI) beginAttempt() {
+	if m.attempts == 0 {
+		m.thinkingTime = time.Since(m.thinkingStart)
+	}
+	m.attempts++
+	m.running = true
+	m.status = ""
+}
+
+// finishAttempt shows an attempt's output and judgement and moves on to rating
+// Failures are shown in the TUI rather than ending the session.
+func (m *reviewTUI) finishAttempt(msg attemptMsg) {
+	m.attempt = msg.attempt
+	m.preview = msg.preview
+	m.suggested = 0
+	m.phase = phaseRating
+	m.scroll = 0
+	m.status = ""
+
+	if msg.err != nil {
+		m.attempt = nil
+		m.output = []string{styleError.Render("Execution failed: " + msg.err.Error())}
+		return
+	}
+
+	m.output = attemptOutput(msg.attempt)
+	if m.mode == modeRecall && msg.attempt.Judged() {
+		m.suggested = review.SuggestRating(review.Outcome{
+			Passed:       msg.attempt.Correct,
+			Attempts:     m.attempts,
+			ThinkingTime: m.thinkingTime,
+			HelpAccessed: m.helpAccessed,
+		})
+	}
+
+	// A missed recall reveals the answer, so any retry has had help
+	if m.mode == modeRecall && !msg.attempt.Correct && m.card.ShowSolutions {
+		m.solutionShown = true
+		m.helpAccessed = true
+	}
+}
+
+// attemptOutput renders an attempt's command, output, and exit code as pane lines
+func attemptOutput(attempt *review.Attempt) []string {
+	var lines []string
+	if attempt.Interactive {
+		lines = append(lines, styleDim.Render("── shell transcript ──"))
+	} else {
+		lines = append(lines, styleCommand.Render("$ "+attempt.Command))
+	}
+
+	result := attempt.Result
+	lines = append(lines, textLines(result.Stdout)...)
+	for _, line := range textLines(result.Stderr) {
+		lines = append(lines, styleError.Render(line))
+	}
+	lines = append(lines, styleDim.Render(fmt.Sprintf("exit code %d", result.ExitCode)))
+	return lines
+}
+
+// textLines splits command output into lines safe to draw in a pane
+// Shell transcripts carry the terminal's control sequences, which are dropped.
+func textLines(text string) []string {
+	text = strings.TrimRight(ansi.Strip(text), "\r\n")
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		lines[i] = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, line)
+	}
+	return lines
+}
+
+// rate submits the learner's rating for the card
+func (m *reviewTUI) rate(rating domain.Rating) error {
+	executionResult := newExecutionResult(m.card, m.attempt, m.attempts, m.thinkingTime, m.helpAccessed, m.suggested)
+	if err := m.service.SubmitReview(m.ctx, m.session.ID, m.card.ID, rating, executionResult); err != nil {
+		return fmt.Errorf("failed to submit review: %w", err)
+	}
+	if err := m.refresh(); err != nil {
+		return err
+	}
+
+	m.rated = rating
+	m.phase = phaseRated
+	m.status = "Rated " + rating.String()
+	if option, ok := previewOption(m.preview, rating); ok {
+		m.status += ", next review in " + formatInterval(option.Interval)
+	}
+	return nil
+}
+
+// previewOption finds rating's option in preview
+func previewOption(preview *review.SchedulePreview, rating domain.Rating) (review.RatingOption, bool) {
+	if preview != nil {
+		for _, option := range preview.Options {
+			if option.Rating == rating {
+				return option, true
+			}
+		}
+	}
+	return review.RatingOption{}, false
+}
+
+// View implements tea.Model, rendering the whole screen
+func (m *reviewTUI) View() string {
+	if m.card == nil {
+		return ""
+	}
+
+	top := append([]string{m.ribbon()}, m.cardPane()...)
+	bottom := m.controls()
+
+	height := max(m.height-len(top)-len(bottom), 3)
+	lines := append(top, m.outputPane(height)...)
+	lines = append(lines, bottom...)
+	return strings.Join(lines, "\n")
+}
+
+// ribbon renders the safety ribbon: network state, capabilities, and session progress
+func (m *reviewTUI) ribbon() string {
+	network, style := " NETWORK OFF ", styleNetworkOff
+	if m.card.NetworkEnabled {
+		network, style = " NETWORK ON ", styleNetworkOn
+	}
+
+	caps := "none"
+	if len(m.card.Capabilities) > 0 {
+		caps = strings.Join(m.card.Capabilities, ",")
+	}
+	total := m.session.CardsReviewed + m.session.CardsRemaining
+	position := min(m.session.CardsReviewed+1, total)
+	if m.phase == phaseRated {
+		position = m.session.CardsReviewed
+	}
+
+	details := fmt.Sprintf(" caps: %s │ %s │ card %d of %d", caps, m.card.Image, position, total)
+	return style.Render(network) + truncate(details, m.width-len(network))
+}
+
+// cardPane renders the card and whatever the learner has revealed
+func (m *reviewTUI) cardPane() []string {
+	lines := []string{"", styleBold.Render(truncate(m.card.Title, m.width))}
+	lines = append(lines, wrap(m.card.Description, m.width)...)
+	if m.mode == modeShow {
+		lines = append(lines, styleCommand.Render(truncate("Command: "+m.card.Command, m.width)))
+	}
+	if m.card.AssetsDir != "" {
+		lines = append(lines, styleDim.Render("Assets: "+sandbox.AssetsPath+" (read-only)"))
+	}
+
+	if m.hintShown {
+		lines = append(lines, wrapStyled("Hint: "+m.card.Hint, m.width, styleHelp)...)
+	}
+	if m.solutionShown {
+		lines = append(lines, wrapStyled("Answer: "+strings.Join(m.card.SolutionAlternatives(), "  |  "), m.width, styleHelp)...)
+	}
+	if m.explanationShown {
+		lines = append(lines, wrapStyled("Explanation: "+m.card.Explanation, m.width, styleCommand)...)
+	}
+	if m.showMeta {
+		lines = append(lines, m.metadata()...)
+	}
+	return lines
+}
+
+// metadata renders the card's configuration for authors and the curious
+func (m *reviewTUI) metadata() []string {
+	c := m.card
+	env := make([]string, 0, len(c.EnvironmentVars))
+	for k, v := range c.EnvironmentVars {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	var steps []string
+	for _, step := range []struct{ name, script string }{{"setup", c.Setup}, {"verify", c.Verify}, {"cleanup", c.Cleanup}} {
+		if step.script != "" {
+			steps = append(steps, step.name)
+		}
+	}
+
+	fields := []string{
+		fmt.Sprintf("key: %s  deck: %d  difficulty: %d  tags: %s", c.CardKey, c.DeckID, c.DifficultyLevel, strings.Join(c.Tags, ", ")),
+		fmt.Sprintf("image: %s  timeout: %v  workdir: %s", c.Image, c.Timeout, c.WorkingDir),
+		fmt.Sprintf("capabilities: %s  env: %s  steps: %s", orNone(c.Capabilities), orNone(env), orNone(steps)),
+		fmt.Sprintf("state: %s  reps: %d  lapses: %d  stability: %.1f  difficulty: %.1f", c.State, c.Reps, c.Lapses, c.Stability, c.Difficulty),
+	}
+	lines := make([]string, 0, len(fields))
+	for _, field := range fields {
+		lines = append(lines, styleDim.Render(truncate(field, m.width)))
+	}
+	return lines
+}
+
+// orNone joins values, or returns "none" when there are none
+func orNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ",")
+}
+
+// outputPane renders height lines of the output pane, scrolled back by m.scroll
+func (m *reviewTUI) outputPane(height int) []string {
+	title := "── output "
+	if m.scroll > 0 {
+		title = fmt.Sprintf("── output (scrolled back %d) ", m.scroll)
+	}
+	lines := []string{styleDim.Render(title + strings.Repeat("─", max(m.width-ansi.StringWidth(title), 0)))}
+
+	height--
+	end := max(len(m.output)-m.scroll, 0)
+	start := max(end-height, 0)
+	for _, line := range m.output[start:end] {
+		lines = append(lines, truncate(line, m.width))
+	}
+	for len(lines) <= height {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// controls renders the command line or rating row, the status line, and the key help
+func (m *reviewTUI) controls() []string {
+	var lines []string
+	switch m.phase {
+	case phaseAttempt:
+		lines = append(lines, truncate("$ "+m.input, m.width-1)+"█")
+	case phaseRating:
+		lines = append(lines, m.ratingRow())
+	default:
+		lines = append(lines, styleSuccess.Render(truncate(m.status, m.width)))
+	}
+
+	status := ""
+	if m.phase != phaseRated {
+		status = m.status
+		if m.attempt != nil && m.phase == phaseRating && m.mode == modeRecall {
+			style := styleError
+			if !m.attempt.Judged() {
+				style = styleDim
+			} else if m.attempt.Correct {
+				style = styleSuccess
+			}
+			status = style.Render(judgement(m.attempt, m.card))
+		}
+	}
+	lines = append(lines, truncate(status, m.width))
+
+	var keys string
+	switch {
+	case m.running:
+		keys = "running...  ^C park"
+	case m.phase == phaseAttempt:
+		keys = "enter run  ! shell  ^T hint  ^S solution  ^R reset  ^N skip  ^B bury  ^X suspend  ^Z undo  ? info  esc park"
+		if m.mode == modeShow {
+			keys = "enter run  ^T hint  ^R reset  ^N skip  ^B bury  ^X suspend  ^Z undo  ? info  q park"
+		}
+	case m.phase == phaseRating:
+		keys = "1-4 rate  r retry  s solution  ^T hint  ^R reset  ^N skip  ^B bury  ^X suspend  ^Z undo  ? info  q park"
+	default:
+		keys = "any key next card  u undo  q park"
+		if m.card.Explanation != "" && m.card.ShowExplanations && !m.explanationShown {
+			keys = "x explanation  " + keys
+		}
+	}
+	lines = append(lines, styleDim.Render(truncate(keys, m.width)))
+	return lines
+}
+
+// ratingRow renders the ratings with the interval each would give and the suggestion
+func (m *reviewTUI) ratingRow() string {
+	parts := []string{}
+	if m.preview != nil {
+		if m.preview.Retrievability > 0 {
+			parts = append(parts, fmt.Sprintf("recall %.0f%%", m.preview.Retrievability*100))
+		} else {
+			parts = append(parts, "new card")
+		}
+		for _, option := range m.preview.Options {
+			parts = append(parts, fmt.Sprintf("%d %s %s", option.Rating, option.Rating, formatInterval(option.Interval)))
+		}
+	} else {
+		parts = append(parts, "1 Again", "2 Hard", "3 Good", "4 Easy")
+	}
+	if m.suggested != 0 {
+		parts = append(parts, "enter = "+m.suggested.String())
+	}
+	return styleBold.Render(truncate(strings.Join(parts, " │ "), m.width))
+}
+
+// truncate cuts s, which may hold styling, to width cells
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return ansi.Truncate(s, width, "…")
+}
+
+// wrap breaks text into lines of at most width cells at spaces
+func wrap(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case ansi.StringWidth(line)+1+ansi.StringWidth(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, truncate(line, width))
+				line = word
+			}
+		}
+		if line != "" {
+			lines = append(lines, truncate(line, width))
+		}
+	}
+	return lines
+}
+
+// wrapStyled wraps text and applies style to each line
+func wrapStyled(text string, width int, style lipgloss.Style) []string {
+	lines := wrap(text, width)
+	for i, line := range lines {
+		lines[i] = style.Render(line)
+	}
+	return lines
+}
+
+// outputHeight returns how many output lines fit on screen
+func (m *reviewTUI) outputHeight() int {
+	return max(m.height-len(m.cardPane())-len(m.controls())-2, 2)
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/justinlyon12/ancli/internal/domain"
+	"github.com/justinlyon12/ancli/internal/review"
+	"github.com/justinlyon12/ancli/internal/sandbox"
+)
+
+// fakeReviewService serves a fixed queue of cards and records what the TUI submits
+type fakeReviewService struct {
+	session   *review.Session
+	cards     []*review.ReviewCard
+	attempts  []string
+	submitted []domain.Rating
+	results   []*domain.ExecutionResult
+	resets    int
+	paused    int
+	setAside  []string
+	rated     []*review.ReviewCard // Cards rated and not undone, for UndoReview
+	ratings   []domain.Rating      // Their ratings
+}
+
+func newFakeReviewService(cards ...*review.ReviewCard) *fakeReviewService {
+	return &fakeReviewService{
+		session: &review.Session{ID: "session", CardsRemaining: len(cards)},
+		cards:   cards,
+	}
+}
+
+func (f *fakeReviewService) StartSession(ctx context.Context, opts review.SessionOptions) (*review.Session, error) {
+	return f.session, nil
+}
+
+func (f *fakeReviewService) SessionStatus(ctx context.Context, sessionID string) (*review.Session, error) {
+	session := *f.session
+	return &session, nil
+}
+
+func (f *fakeReviewService) GetNextCard(ctx context.Context, sessionID string) (*review.ReviewCard, error) {
+	if len(f.cards) == 0 {
+		return nil, review.ErrQueueEmpty
+	}
+	return f.cards[0], nil
+}
+
+func (f *fakeReviewService) AttemptCard(ctx context.Context, sessionID string, card *review.ReviewCard, command string) (*review.Attempt, error) {
+	f.attempts = append(f.attempts, command)
+	matched := card.MatchSolution(command)
+	result := &sandbox.ExecutionResult{Success: true, Stdout: "ran " + command + "\n"}
+	return &review.Attempt{Command: command, Result: result, Matched: matched, Correct: matched != ""}, nil
+}
+
+func (f *fakeReviewService) ShellAttempt(ctx context.Context, sessionID string, card *review.ReviewCard, term sandbox.Terminal) (*review.Attempt, error) {
+	return nil, fmt.Errorf("no shell in tests")
+}
+
+func (f *fakeReviewService) ResetEnvironment(ctx context.Context, sessionID string) error {
+	f.resets++
+	return nil
+}
+
+func (f *fakeReviewService) PreviewSchedule(ctx context.Context, cardID int) (*review.SchedulePreview, error) {
+	return &review.SchedulePreview{CardID: cardID, Options: []review.RatingOption{
+		{Rating: domain.Again, Interval: time.Minute},
+		{Rating: domain.Hard, Interval: 5 * time.Minute},
+		{Rating: domain.Good, Interval: 10 * time.Minute},
+		{Rating: domain.Easy, Interval: 4 * 24 * time.Hour},
+	}}, nil
+}
+
+func (f *fakeReviewService) SubmitReview(ctx context.Context, sessionID string, cardID int, rating domain.Rating, executionResult *domain.ExecutionResult) error {
+	f.submitted = append(f.submitted, rating)
+	f.results = append(f.results, executionResult)
+	f.rated = append(f.rated, f.cards[0])
+	f.ratings = append(f.ratings, rating)
+	f.cards = f.cards[1:]
+	f.session.CardsReviewed++
+	f.session.CardsRemaining--
+	return nil
+}
+
+func (f *fakeReviewService) UndoReview(ctx context.Context, sessionID string) (*review.UndoneReview, error) {
+	if len(f.rated) == 0 {
+		return nil, review.ErrNothingToUndo
+	}
+	last := len(f.rated) - 1
+	card, rating := f.rated[last], f.ratings[last]
+	f.rated, f.ratings = f.rated[:last], f.ratings[:last]
+	f.cards = append([]*review.ReviewCard{card}, f.cards...)
+	f.session.CardsReviewed--
+	f.session.CardsRemaining++
+	return &review.UndoneReview{CardID: card.ID, CardKey: card.CardKey, Title: card.Title, Rating: rating}, nil
+}
+
+func (f *fakeReviewService) SkipCard(ctx context.Context, sessionID string, cardID int) error {
+	f.setAside = append(f.setAside, "skip")
+	f.cards = append(f.cards[1:], f.cards[0])
+	return nil
+}
+
+func (f *fakeReviewService) BuryCard(ctx context.Context, sessionID string, cardID int) error {
+	f.setAside = append(f.setAside, "bury")
+	f.cards = f.cards[1:]
+	f.session.CardsRemaining--
+	return nil
+}
+
+func (f *fakeReviewService) SuspendCard(ctx context.Context, sessionID string, cardID int) error {
+	f.setAside = append(f.setAside, "suspend")
+	f.cards = f.cards[1:]
+	f.session.CardsRemaining--
+	return nil
+}
+
+func (f *fakeReviewService) EndSession(ctx context.Context, sessionID string) (*review.SessionStats, error) {
+	return &review.SessionStats{SessionID: sessionID}, nil
+}
+
+func (f *fakeReviewService) PauseSession(ctx context.Context, sessionID string) error {
+	f.paused++
+	return nil
+}
+
+func (f *fakeReviewService) ResumeSession(ctx context.Context) (*review.Session, error) {
+	return f.session, nil
+}
+
+func (f *fakeReviewService) ExplainQueue(ctx context.Context, opts review.SessionOptions) (*review.QueueExplanation, error) {
+	return &review.QueueExplanation{}, nil
+}
+
+// keyMsg builds the key press Bubble Tea reports for a key name
+func keyMsg(key string) tea.KeyMsg {
+	names := map[string]tea.KeyType{
+		"enter": tea.KeyEnter, "backspace": tea.KeyBackspace, "esc": tea.KeyEsc, "tab": tea.KeyTab,
+		"up": tea.KeyUp, "down": tea.KeyDown, "pgup": tea.KeyPgUp, "pgdown": tea.KeyPgDown,
+	}
+	if keyType, ok := names[key]; ok {
+		return tea.KeyMsg{Type: keyType}
+	}
+	if letter, ok := strings.CutPrefix(key, "ctrl+"); ok {
+		return tea.KeyMsg{Type: tea.KeyCtrlA + tea.KeyType(letter[0]-'a')}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+// typeKeys sends each key to the model, running the commands it starts to completion
+func typeKeys(t *testing.T, m *reviewTUI, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		send(t, m, keyMsg(key))
+	}
+}
+
+// send delivers msg to the model and feeds back what its commands report
+func send(t *testing.T, m *reviewTUI, msg tea.Msg) {
+	t.Helper()
+	_, cmd := m.Update(msg)
+	if m.err != nil {
+		t.Fatalf("Update(%v) failed: %v", msg, m.err)
+	}
+	if cmd == nil {
+		return
+	}
+	if reply := cmd(); reply != nil {
+		if _, quit := reply.(tea.QuitMsg); !quit {
+			send(t, m, reply)
+		}
+	}
+}
+
+func startTUI(t *testing.T, service *fakeReviewService, mode string) *reviewTUI {
+	t.Helper()
+	m := newReviewTUI(context.Background(), service, service.session, mode)
+	if err := m.nextCard(); err != nil {
+		t.Fatalf("nextCard failed: %v", err)
+	}
+	return m
+}
+
+func tuiCard(id int) *review.ReviewCard {
+	return &review.ReviewCard{
+		ID: id, Title: "Create project directory", Description: "Create a directory called my_project",
+		Command: "mkdir my_project", Hint: "Use mkdir", Explanation: "mkdir creates directories",
+		Image: "alpine:3.18", ShowSolutions: true, ShowExplanations: true,
+	}
+}
+
+func TestReviewTUI_TypedAndPastedRunes(t *testing.T) {
+	m := startTUI(t, newFakeReviewService(tuiCard(1)), modeRecall)
+
+	send(t, m, tea.WindowSizeMsg{Width: 100, Height: 30})
+	send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("mkdir"), Paste: true})
+	send(t, m, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	typeKeys(t, m, "x", "backspace", "é")
+	if m.input != "mkdir é" || m.width != 100 || m.height != 30 {
+		t.Errorf("expected input %q at 100x30, got %q at %dx%d", "mkdir é", m.input, m.width, m.height)
+	}
+	if view := m.View(); strings.Count(view, "\n") != 29 || !strings.Contains(view, "$ mkdir é") {
+		t.Errorf("expected a 30-line screen with the command line, got:\n%s", view)
+	}
+
+	// Parking the session quits the program
+	if _, cmd := m.Update(keyMsg("esc")); cmd == nil || !m.quit {
+		t.Fatal("expected esc to park the session and quit")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("expected esc to quit the program")
+	}
+}
+
+func TestReviewTUI_RecallAttemptAndRating(t *testing.T) {
+	service := newFakeReviewService(tuiCard(1), tuiCard(2))
+	m := startTUI(t, service, modeRecall)
+
+	// The hint is help; the wrong command gets the answer shown
+	typeKeys(t, m, "ctrl+t", "m", "k", "d", "i", "r", "enter")
+	if !m.helpAccessed || !strings.Contains(m.View(), "Hint: Use mkdir") {
+		t.Error("expected the hint to be shown and recorded as help")
+	}
+	if m.phase != phaseRating || m.suggested != domain.Again {
+		t.Fatalf("expected a failed attempt to suggest Again, got phase %d suggestion %s", m.phase, m.suggested)
+	}
+	view := m.View()
+	for _, want := range []string{"$ mkdir", "ran mkdir", "Not quite", "Answer: mkdir my_project", "3 Good 10m"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected view to contain %q", want)
+		}
+	}
+
+	// Retry, then accept the suggested rating
+	typeKeys(t, m, "r")
+	for _, key := range "mkdir my_project" {
+		typeKeys(t, m, string(key))
+	}
+	typeKeys(t, m, "enter", "enter")
+	if len(service.submitted) != 1 || service.submitted[0] != domain.Hard {
+		t.Fatalf("expected a retried, hinted card to be rated Hard, got %v", service.submitted)
+	}
+	result := service.results[0]
+	if result.Attempts != 2 || !result.HelpAccessed || result.AttemptPassed == nil || !*result.AttemptPassed {
+		t.Errorf("expected 2 attempts, help, and a pass recorded, got %+v", result)
+	}
+	if !strings.Contains(m.View(), "next review in 5m") {
+		t.Error("expected the rated card's next interval to be shown")
+	}
+
+	// The explanation is offered before moving on
+	typeKeys(t, m, "x")
+	if !strings.Contains(m.View(), "Explanation: mkdir creates directories") {
+		t.Error("expected the explanation to be shown")
+	}
+	typeKeys(t, m, "enter")
+	if m.card.ID != 2 || m.phase != phaseAttempt || m.helpAccessed {
+		t.Errorf("expected a fresh attempt at card 2, got card %d phase %d", m.card.ID, m.phase)
+	}
+
+	typeKeys(t, m, "esc")
+	if !m.quit {
+		t.Error("expected esc to park the session")
+	}
+}
+
+func TestReviewTUI_RetryAfterRevealedAnswer(t *testing.T) {
+	service := newFakeReviewService(tuiCard(1))
+	m := startTUI(t, service, modeRecall)
+
+	// Missing the card reveals the answer, which counts as help for the retry
+	typeKeys(t, m, "l", "s", "enter")
+	if !m.solutionShown || !m.helpAccessed {
+		t.Fatal("expected the missed card's answer to be shown and recorded as help")
+	}
+	typeKeys(t, m, "r")
+	if !strings.Contains(m.View(), "Answer: mkdir my_project") {
+		t.Error("expected the answer to stay shown for the retry")
+	}
+	for _, key := range "mkdir my_project" {
+		typeKeys(t, m, string(key))
+	}
+	typeKeys(t, m, "enter", "enter")
+	if len(service.results) != 1 || !service.results[0].HelpAccessed || service.submitted[0] != domain.Hard {
+		t.Errorf("expected the retry to be rated Hard with help recorded, got %v %+v", service.submitted, service.results)
+	}
+}
+
+func TestReviewTUI_SafetyRibbon(t *testing.T) {
+	card := tuiCard(1)
+	m := startTUI(t, newFakeReviewService(card), modeRecall)
+	if ribbon := m.ribbon(); !strings.Contains(ribbon, "NETWORK OFF") || !strings.Contains(ribbon, "caps: none") {
+		t.Errorf("expected ribbon to show network off and no capabilities, got %q", ribbon)
+	}
+
+	card.NetworkEnabled = true
+	card.Capabilities = []string{"NET_ADMIN"}
+	if ribbon := m.ribbon(); !strings.Contains(ribbon, "NETWORK ON") || !strings.Contains(ribbon, "caps: NET_ADMIN") {
+		t.Errorf("expected ribbon to show network on and NET_ADMIN, got %q", ribbon)
+	}
+}
+
+func TestReviewTUI_ShowModeHotkeys(t *testing.T) {
+	service := newFakeReviewService(tuiCard(1))
+	m := startTUI(t, service, modeShow)
+
+	// The command is fixed in show mode, and '?' toggles the card metadata
+	typeKeys(t, m, "x", "backspace", "?")
+	if m.input != "mkdir my_project" {
+		t.Errorf("expected show mode to keep the card's command, got %q", m.input)
+	}
+	if !m.showMeta || !strings.Contains(m.View(), "image: alpine:3.18") {
+		t.Error("expected '?' to show the card metadata")
+	}
+
+	typeKeys(t, m, "ctrl+r")
+	if service.resets != 1 {
+		t.Errorf("expected ctrl+r to reset the environment, got %d resets", service.resets)
+	}
+
+	typeKeys(t, m, "enter")
+	if len(service.attempts) != 1 || service.attempts[0] != "mkdir my_project" || m.suggested != 0 {
+		t.Errorf("expected the card's command to run without a suggestion, got %v", service.attempts)
+	}
+}
+
+func TestReviewTUI_SetCardsAside(t *testing.T) {
+	service := newFakeReviewService(tuiCard(1), tuiCard(2), tuiCard(3))
+	m := startTUI(t, service, modeRecall)
+
+	// Skipping keeps the card in the session, at the back
+	typeKeys(t, m, "ctrl+n")
+	if m.card.ID != 2 || m.session.CardsRemaining != 3 || !strings.Contains(m.View(), "Skipped") {
+		t.Errorf("expected card 2 next with 3 left, got card %d with %d left", m.card.ID, m.session.CardsRemaining)
+	}
+
+	// Cards can be buried after an attempt, and suspended before one
+	typeKeys(t, m, "l", "s", "enter", "ctrl+b")
+	if m.card.ID != 3 || m.phase != phaseAttempt || m.session.CardsRemaining != 2 {
+		t.Errorf("expected a fresh attempt at card 3 with 2 left, got card %d phase %d", m.card.ID, m.phase)
+	}
+	typeKeys(t, m, "ctrl+x")
+	if m.card.ID != 1 || !strings.Contains(m.View(), "ancli card unsuspend 0/") {
+		t.Errorf("expected the skipped card back and an unsuspend hint, got card %d", m.card.ID)
+	}
+
+	if want := []string{"skip", "bury", "suspend"}; !reflect.DeepEqual(service.setAside, want) || len(service.submitted) != 0 {
+		t.Errorf("expected %v and no ratings, got %v and %v", want, service.setAside, service.submitted)
+	}
+}
+
+func TestReviewTUI_UndoRating(t *testing.T) {
+	service := newFakeReviewService(tuiCard(1), tuiCard(2), tuiCard(3))
+	m := startTUI(t, service, modeShow)
+
+	typeKeys(t, m, "ctrl+z")
+	if m.card.ID != 1 || !strings.Contains(m.View(), "Nothing to undo") {
+		t.Errorf("expected nothing to undo on the first card, got card %d", m.card.ID)
+	}
+
+	// 'u' right after rating takes the rating back
+	typeKeys(t, m, "enter", "1", "u")
+	if m.card.ID != 1 || m.phase != phaseAttempt || !strings.Contains(m.View(), "Undid Again") {
+		t.Errorf("expected card 1 back for a fresh attempt, got card %d phase %d", m.card.ID, m.phase)
+	}
+
+	// ^Z part-way through the next card brings the previous one back first
+	typeKeys(t, m, "enter", "3", "enter", "enter", "ctrl+z")
+	if m.card.ID != 1 || m.session.CardsRemaining != 3 || m.session.CardsReviewed != 0 {
+		t.Errorf("expected card 1 back with 3 left and none reviewed, got card %d with %d left", m.card.ID, m.session.CardsRemaining)
+	}
+	typeKeys(t, m, "enter", "4", "enter")
+	if m.card.ID != 2 {
+		t.Errorf("expected card 2 after re-rating card 1, got card %d", m.card.ID)
+	}
+}
+
+func TestReviewTUI_RunsAsProgram(t *testing.T) {
+	service := newFakeReviewService(tuiCard(1))
+	m := startTUI(t, service, modeShow)
+
+	// Show the card's metadata, then park the session
+	var screen bytes.Buffer
+	program := tea.NewProgram(m, tea.WithInput(strings.NewReader("?q")), tea.WithOutput(&screen))
+	if _, err := program.Run(); err != nil {
+		t.Fatalf("program failed: %v", err)
+	}
+	if !m.quit || !m.showMeta {
+		t.Errorf("expected the metadata shown and the session parked, got showMeta %v quit %v", m.showMeta, m.quit)
+	}
+	if !strings.Contains(screen.String(), "Create project directory") {
+		t.Error("expected the card to be drawn")
+	}
+}
@@ -97,7 +97,7 @@ func main() {
 
 	// Cleanup
 	fmt.Println("\n🧹 Cleaning up...")
-	if err := driver.Cleanup(ctx); err != nil {
+	if err := driver.CleanupAll(ctx); err != nil {
 		log.Printf("⚠️  Cleanup warning: %v", err)
 	} else {
 		fmt.Println("✅ Cleanup completed")
@@ -44,6 +44,10 @@ type ReviewConfig struct {
 	MaxCardsPerSession int           `mapstructure:"max_cards_per_session"`
 	SessionTimeout     time.Duration `mapstructure:"session_timeout"`
 	AutoAdvance        bool          `mapstructure:"auto_advance"`
+
+	// Daily limits per deck, overridable in deck.yaml settings (0 = unlimited)
+	NewCardsPerDay int `mapstructure:"new_cards_per_day"` // New cards introduced
+	ReviewsPerDay  int `mapstructure:"reviews_per_day"`   // Due reviews shown
 }
 
 // Load reads configuration from files, environment variables, and flags
@@ -117,6 +121,8 @@ func setDefaults() error {
 	viper.SetDefault("review.max_cards_per_session", 20)
 	viper.SetDefault("review.session_timeout", "30m")
 	viper.SetDefault("review.auto_advance", false)
+	viper.SetDefault("review.new_cards_per_day", 20)
+	viper.SetDefault("review.reviews_per_day", 200)
 
 	// Logging defaults
 	viper.SetDefault("log_level", "info")
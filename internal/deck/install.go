@@ -0,0 +1,257 @@
+package deck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/justinlyon12/ancli/internal/storage"
+)
+
+// InstallResult summarizes a completed deck installation
+type InstallResult struct {
+	Deck       *storage.Deck
+	CardCount  int
+	AssetCount int
+	Validation *ValidationResult
+}
+
+// InvalidDeckError is returned when a deck fails validation and cannot be installed
+type InvalidDeckError struct {
+	Result *ValidationResult
+}
+
+func (e *InvalidDeckError) Error() string {
+	return fmt.Sprintf("deck failed validation with %d error(s)", len(e.Result.Errors))
+}
+
+// Install validates the deck at deckPath and imports it into db
+// deckPath may be a deck directory or a packed .ancli archive. The deck row, its
+// cards, and every file under assets/ are written in a single transaction, so a
+// failed install leaves the store untouched.
+func Install(db *storage.DB, deckPath string) (*InstallResult, error) {
+	source, err := Open(deckPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open deck: %w", err)
+	}
+	defer source.Close()
+
+	if !source.Validation.Valid {
+		return nil, &InvalidDeckError{Result: source.Validation}
+	}
+	spec, cards, validation := source.Spec, source.Cards, source.Validation
+
+	assets, err := readAssets(source.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &InstallResult{Validation: validation}
+	err = db.WithTx(func(tx *storage.DB) error {
+		if _, err := tx.GetDeckByName(spec.Name); err == nil {
+			return fmt.Errorf("deck %q is already installed", spec.Name)
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+
+		deck, err := spec.toStorageDeck()
+		if err != nil {
+			return err
+		}
+		if err := tx.CreateDeck(deck); err != nil {
+			return err
+		}
+
+		for _, cardSpec := range cards {
+			card, err := cardSpec.toStorageCard(spec, deck.ID)
+			if err != nil {
+				return err
+			}
+			if err := tx.CreateCard(card); err != nil {
+				return fmt.Errorf("card %q: %w", cardSpec.Key, err)
+			}
+		}
+
+		for _, asset := range assets {
+			asset.DeckID = deck.ID
+			if err := tx.StoreAsset(asset); err != nil {
+				return fmt.Errorf("asset %q: %w", asset.Filename, err)
+			}
+		}
+
+		result.Deck = deck
+		result.CardCount = len(cards)
+		result.AssetCount = len(assets)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to install deck: %w", err)
+	}
+
+	return result, nil
+}
+
+// toStorageDeck maps deck.yaml metadata and container defaults onto a deck row
+func (s *DeckSpec) toStorageDeck() (*storage.Deck, error) {
+	deck := &storage.Deck{
+		Name:                  s.Name,
+		Description:           s.Description,
+		Version:               s.Version,
+		Author:                s.Author,
+		DefaultImage:          s.image(),
+		DefaultTimeout:        s.Container.Timeout,
+		DefaultNetworkEnabled: s.Container.Network,
+		DefaultCapabilities:   "[]",
+		DefaultShell:          s.Container.Shell,
+	}
+
+	if deck.DefaultTimeout <= 0 {
+		deck.DefaultTimeout = storage.DefaultDeckTimeout
+	}
+
+	// Only persist the FSRS parameters the author actually set
+	params := make(map[string]any)
+	if s.FSRS.RequestRetention > 0 {
+		params["request_retention"] = s.FSRS.RequestRetention
+	}
+	if s.FSRS.MaximumInterval > 0 {
+		params["maximum_interval"] = s.FSRS.MaximumInterval
+	}
+	if s.FSRS.InitialDifficulty > 0 {
+		params["initial_difficulty"] = s.FSRS.InitialDifficulty
+	}
+	if len(params) > 0 {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode FSRS parameters: %w", err)
+		}
+		deck.FSRSParameters = string(data)
+	}
+
+	settings, err := json.Marshal(storage.DeckSettings{
+		ShowSolutions:    s.Settings.ShowSolutions,
+		ShowExplanations: s.Settings.ShowExplanations,
+		NewCardsPerDay:   s.Settings.NewCardsPerDay,
+		ReviewsPerDay:    s.Settings.ReviewsPerDay,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode deck settings: %w", err)
+	}
+	deck.Settings = string(settings)
+
+	return deck, nil
+}
+
+// toStorageCard maps a cards.csv row onto a card row, applying deck-wide container settings
+func (c CardSpec) toStorageCard(spec *DeckSpec, deckID int) (*storage.Card, error) {
+	environment := spec.Container.Environment
+	if environment == nil {
+		environment = map[string]string{}
+	}
+	envJSON, err := json.Marshal(environment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode environment for card %q: %w", c.Key, err)
+	}
+
+	tagsJSON, err := json.Marshal(splitList(c.Tags))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tags for card %q: %w", c.Key, err)
+	}
+
+	prereqJSON, err := json.Marshal(splitList(c.Prerequisites))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode prerequisites for card %q: %w", c.Key, err)
+	}
+
+	prerequisiteMode := spec.Settings.PrerequisiteMode
+	if prerequisiteMode == "" {
+		prerequisiteMode = "link"
+	}
+
+	return &storage.Card{
+		DeckID:           deckID,
+		CardKey:          c.Key,
+		Title:            c.Title,
+		Description:      c.Description,
+		Command:          c.Command,
+		WorkingDir:       spec.workingDir(),
+		EnvironmentVars:  string(envJSON),
+		DifficultyLevel:  c.Difficulty,
+		Tags:             string(tagsJSON),
+		Prerequisites:    string(prereqJSON),
+		PrerequisiteMode: prerequisiteMode,
+		Setup:            c.Setup,
+		Cleanup:          c.Cleanup,
+		Verify:           c.Verify,
+		Hint:             c.Hint,
+		Solution:         c.Solution,
+		Explanation:      c.Explanation,
+	}, nil
+}
+
+// readAssets loads every regular file under the deck's assets/ directory
+// Filenames are stored relative to assets/ with forward slashes so cards can
+// reference them the same way on every platform
+func readAssets(deckPath string) ([]*storage.DeckAsset, error) {
+	assetsDir := filepath.Join(deckPath, "assets")
+	if info, err := os.Stat(assetsDir); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+
+	var assets []*storage.DeckAsset
+	err := filepath.WalkDir(assetsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(assetsDir, path)
+		if err != nil {
+			return err
+		}
+
+		assets = append(assets, &storage.DeckAsset{
+			Filename:    filepath.ToSlash(rel),
+			Content:     content,
+			ContentType: detectContentType(rel, content),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deck assets: %w", err)
+	}
+
+	return assets, nil
+}
+
+// detectContentType prefers the extension's registered type and falls back to content sniffing
+func detectContentType(filename string, content []byte) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(filename)); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(content)
+}
+
+// splitList splits a comma-separated CSV field into trimmed, non-empty values
+func splitList(field string) []string {
+	values := []string{}
+	for _, value := range strings.Split(field, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,149 @@
+package deck
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/justinlyon12/ancli/internal/storage"
+)
+
+func setupInstallDB(t *testing.T) *storage.DB {
+	t.Helper()
+
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func createInstallableDeck(t *testing.T, dir string) {
+	t.Helper()
+
+	createFile(t, filepath.Join(dir, "deck.yaml"), `
+name: install-test
+version: 1.2.0
+author: Test Author
+description: A deck used by install tests
+container:
+  image: alpine:3.18
+  timeout: 15
+  network: true
+  environment:
+    USER: student
+  working_dir: /workspace
+fsrs:
+  request_retention: 0.9
+settings:
+  prerequisite_mode: enforce
+  show_explanations: false
+`)
+	createFile(t, filepath.Join(dir, "cards.csv"), `key,title,command,description,setup,cleanup,prerequisites,verify,hint,solution,explanation,difficulty,tags
+basic,"Basic","echo hello","Print hello",,,,,"Use echo","echo hello","Prints hello",1,"basics, output"
+second,"Second","echo world","Print world",,,basic,,"Use echo","echo world","Prints world",2,"basics"
+`)
+	createFile(t, filepath.Join(dir, "assets", "data", "config.json"), `{"key": "value"}`)
+}
+
+func TestInstall_MapsDeckCardsAndAssets(t *testing.T) {
+	db := setupInstallDB(t)
+	deckDir := t.TempDir()
+	createInstallableDeck(t, deckDir)
+
+	result, err := Install(db, deckDir)
+	if err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+
+	if result.CardCount != 2 || result.AssetCount != 1 {
+		t.Errorf("expected 2 cards and 1 asset, got %d cards and %d assets", result.CardCount, result.AssetCount)
+	}
+
+	installed, err := db.GetDeck(result.Deck.ID)
+	if err != nil {
+		t.Fatalf("failed to get installed deck: %v", err)
+	}
+	if installed.DefaultImage != "alpine:3.18" || installed.DefaultTimeout != 15 || !installed.DefaultNetworkEnabled {
+		t.Errorf("container defaults not mapped: %+v", installed)
+	}
+	if installed.FSRSParameters != `{"request_retention":0.9}` {
+		t.Errorf("unexpected FSRS parameters: %s", installed.FSRSParameters)
+	}
+	if installed.Settings != `{"show_explanations":false}` {
+		t.Errorf("unexpected settings: %s", installed.Settings)
+	}
+
+	cards, err := db.GetCardsByDeck(installed.ID)
+	if err != nil {
+		t.Fatalf("failed to get cards: %v", err)
+	}
+	if len(cards) != 2 {
+		t.Fatalf("expected 2 cards, got %d", len(cards))
+	}
+
+	basic := cards[0]
+	if basic.Tags != `["basics","output"]` {
+		t.Errorf("unexpected tags: %s", basic.Tags)
+	}
+	if basic.EnvironmentVars != `{"USER":"student"}` {
+		t.Errorf("unexpected environment: %s", basic.EnvironmentVars)
+	}
+	if basic.WorkingDir != "/workspace" || basic.PrerequisiteMode != "enforce" {
+		t.Errorf("unexpected working dir or prerequisite mode: %s, %s", basic.WorkingDir, basic.PrerequisiteMode)
+	}
+	if basic.Hint != "Use echo" || basic.Solution != "echo hello" || basic.Explanation != "Prints hello" {
+		t.Errorf("unexpected pedagogy fields: hint=%q solution=%q explanation=%q", basic.Hint, basic.Solution, basic.Explanation)
+	}
+
+	second := cards[1]
+	if second.Prerequisites != `["basic"]` || second.DifficultyLevel != 2 {
+		t.Errorf("unexpected prerequisites or difficulty: %s, %d", second.Prerequisites, second.DifficultyLevel)
+	}
+
+	asset, err := db.GetAsset(installed.ID, "data/config.json")
+	if err != nil {
+		t.Fatalf("failed to get asset: %v", err)
+	}
+	if asset.ContentType != "application/json" {
+		t.Errorf("expected application/json content type, got %s", asset.ContentType)
+	}
+}
+
+func TestInstall_RejectsInvalidDeck(t *testing.T) {
+	db := setupInstallDB(t)
+	deckDir := t.TempDir()
+	createFile(t, filepath.Join(deckDir, "deck.yaml"), "name: broken\n")
+	createFile(t, filepath.Join(deckDir, "cards.csv"), "key,title\n")
+
+	_, err := Install(db, deckDir)
+
+	var invalid *InvalidDeckError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidDeckError, got %v", err)
+	}
+
+	decks, err := db.ListDecks()
+	if err != nil {
+		t.Fatalf("failed to list decks: %v", err)
+	}
+	if len(decks) != 0 {
+		t.Errorf("expected no decks after failed install, got %d", len(decks))
+	}
+}
+
+func TestInstall_RejectsDuplicateDeck(t *testing.T) {
+	db := setupInstallDB(t)
+	deckDir := t.TempDir()
+	createInstallableDeck(t, deckDir)
+
+	if _, err := Install(db, deckDir); err != nil {
+		t.Fatalf("first install failed: %v", err)
+	}
+
+	if _, err := Install(db, deckDir); err == nil {
+		t.Error("expected error when installing the same deck twice")
+	}
+}
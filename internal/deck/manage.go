@@ -0,0 +1,271 @@
+package deck
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justinlyon12/ancli/internal/storage"
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+)
+
+// CardCounts breaks a deck's active cards down by FSRS state
+// Due counts cards whose due date has passed, whatever their state, unless they are
+// suspended or buried. Learning includes relearning cards. Archived cards are counted
+// separately.
+type CardCounts struct {
+	Total     int `json:"total"`
+	Due       int `json:"due"`
+	New       int `json:"new"`
+	Learning  int `json:"learning"`
+	Review    int `json:"review"`
+	Suspended int `json:"suspended"`
+	Archived  int `json:"archived"`
+}
+
+// DeckSummary is an installed deck with its card counts
+type DeckSummary struct {
+	Deck   *storage.Deck `json:"deck"`
+	Counts CardCounts    `json:"counts"`
+}
+
+// CardSummary is the FSRS state of a single card
+type CardSummary struct {
+	Key        string     `json:"key"`
+	Title      string     `json:"title"`
+	State      string     `json:"state"`
+	Due        time.Time  `json:"due"`
+	Reps       int        `json:"reps"`
+	Lapses     int        `json:"lapses"`
+	Stability  float64    `json:"stability"`
+	Difficulty float64    `json:"difficulty"`
+	LastReview *time.Time `json:"last_review,omitempty"`
+
+	Suspended   bool       `json:"suspended,omitempty"`
+	BuriedUntil *time.Time `json:"buried_until,omitempty"` // Set while the card is buried
+}
+
+// DeckDetails is everything 'deck show' reports about an installed deck
+type DeckDetails struct {
+	DeckSummary
+	Assets   int           `json:"assets"`
+	Versions int           `json:"versions"`
+	Cards    []CardSummary `json:"cards"`
+}
+
+// Find looks up an installed deck by name, or by numeric ID when no deck has that name
+func Find(db *storage.DB, ref string) (*storage.Deck, error) {
+	deck, err := db.GetDeckByName(ref)
+	if err == nil || !errors.Is(err, storage.ErrNotFound) {
+		return deck, err
+	}
+
+	id, convErr := strconv.Atoi(ref)
+	if convErr != nil {
+		return nil, fmt.Errorf("deck %q is not installed: %w", ref, err)
+	}
+
+	deck, err = db.GetDeck(id)
+	if err != nil {
+		return nil, fmt.Errorf("deck %q is not installed: %w", ref, err)
+	}
+	return deck, nil
+}
+
+// List summarizes every installed deck, ordered by name
+func List(db *storage.DB, now time.Time) ([]DeckSummary, error) {
+	decks, err := db.ListDecks()
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]DeckSummary, 0, len(decks))
+	for _, deck := range decks {
+		cards, err := db.GetCardsByDeckWithArchived(deck.ID)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, DeckSummary{Deck: deck, Counts: countCards(cards, now)})
+	}
+
+	return summaries, nil
+}
+
+// Show reports an installed deck's metadata and the FSRS state of each active card
+func Show(db *storage.DB, ref string, now time.Time) (*DeckDetails, error) {
+	deck, err := Find(db, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, err := db.GetCardsByDeckWithArchived(deck.ID)
+	if err != nil {
+		return nil, err
+	}
+	assets, err := db.ListDeckAssets(deck.ID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := db.ListDeckVersions(deck.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &DeckDetails{
+		DeckSummary: DeckSummary{Deck: deck, Counts: countCards(cards, now)},
+		Assets:      len(assets),
+		Versions:    len(versions),
+		Cards:       make([]CardSummary, 0, len(cards)),
+	}
+	for _, card := range cards {
+		if card.Archived {
+			continue
+		}
+		details.Cards = append(details.Cards, summarizeCard(card, now))
+	}
+
+	return details, nil
+}
+
+// summarizeCard reports a card's FSRS state and whether it is set aside
+func summarizeCard(card *storage.Card, now time.Time) CardSummary {
+	summary := CardSummary{
+		Key:        card.CardKey,
+		Title:      card.Title,
+		State:      stateName(card.FSRSState),
+		Due:        card.FSRSDue,
+		Reps:       card.FSRSReps,
+		Lapses:     card.FSRSLapses,
+		Stability:  card.FSRSStability,
+		Difficulty: card.FSRSDifficulty,
+		LastReview: card.FSRSLastReview,
+		Suspended:  card.Suspended,
+	}
+	if card.BuriedUntil != nil && card.BuriedUntil.After(now) {
+		summary.BuriedUntil = card.BuriedUntil
+	}
+	return summary
+}
+
+// FindCard looks up an installed card by "<deck>/<key>", with the deck given as for Find
+func FindCard(db *storage.DB, ref string) (*storage.Deck, *storage.Card, error) {
+	i := strings.LastIndex(ref, "/")
+	if i <= 0 || i == len(ref)-1 {
+		return nil, nil, fmt.Errorf("invalid card %q: use <deck>/<card-key>", ref)
+	}
+	deckRef, key := ref[:i], ref[i+1:]
+
+	deck, err := Find(db, deckRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	card, err := db.GetCardByKey(deck.ID, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, fmt.Errorf("deck %q has no card %q: %w", deck.Name, key, err)
+		}
+		return nil, nil, err
+	}
+	return deck, card, nil
+}
+
+// SetSuspended suspends a card, keeping it out of review sessions, or unsuspends it
+// Unsuspending also ends any burial, so the card is back in the next session when due.
+func SetSuspended(db *storage.DB, ref string, suspended bool, now time.Time) (*CardSummary, error) {
+	_, card, err := FindCard(db, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	card.Suspended = suspended
+	if !suspended {
+		card.BuriedUntil = nil
+	}
+	if err := db.UpdateCard(card); err != nil {
+		return nil, err
+	}
+
+	summary := summarizeCard(card, now)
+	return &summary, nil
+}
+
+// RemovalPlan describes what removing a deck will delete
+type RemovalPlan struct {
+	Deck    *storage.Deck `json:"deck"`
+	Cards   int           `json:"cards"`
+	Reviews int           `json:"reviews"`
+}
+
+// PlanRemoval looks up a deck and counts what removing it would delete
+func PlanRemoval(db *storage.DB, ref string) (*RemovalPlan, error) {
+	deck, err := Find(db, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, err := db.GetCardsByDeckWithArchived(deck.ID)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := db.CountReviewsByDeck(deck.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemovalPlan{Deck: deck, Cards: len(cards), Reviews: reviews}, nil
+}
+
+// Remove deletes a deck; its cards, review history, assets, and versions go with it
+func Remove(db *storage.DB, deck *storage.Deck) error {
+	if err := db.DeleteDeck(deck.ID); err != nil {
+		return fmt.Errorf("failed to remove deck %q: %w", deck.Name, err)
+	}
+	return nil
+}
+
+// countCards tallies cards by FSRS state
+func countCards(cards []*storage.Card, now time.Time) CardCounts {
+	var counts CardCounts
+	for _, card := range cards {
+		if card.Archived {
+			counts.Archived++
+			continue
+		}
+
+		counts.Total++
+		buried := card.BuriedUntil != nil && card.BuriedUntil.After(now)
+		if card.Suspended {
+			counts.Suspended++
+		} else if !card.FSRSDue.After(now) && !buried {
+			counts.Due++
+		}
+		switch fsrs.State(card.FSRSState) {
+		case fsrs.New:
+			counts.New++
+		case fsrs.Learning, fsrs.Relearning:
+			counts.Learning++
+		case fsrs.Review:
+			counts.Review++
+		}
+	}
+	return counts
+}
+
+// stateName returns the lowercase name of an FSRS state
+func stateName(state int) string {
+	switch fsrs.State(state) {
+	case fsrs.New:
+		return "new"
+	case fsrs.Learning:
+		return "learning"
+	case fsrs.Review:
+		return "review"
+	case fsrs.Relearning:
+		return "relearning"
+	default:
+		return "unknown"
+	}
+}
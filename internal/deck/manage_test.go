@@ -0,0 +1,152 @@
+package deck
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/justinlyon12/ancli/internal/storage"
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+)
+
+func TestListAndShow_CountCardsByState(t *testing.T) {
+	db := setupInstallDB(t)
+	installed := installWithProgress(t, db)
+
+	now := time.Now()
+	cards := cardsByKey(t, db, installed.ID)
+	second := cards["second"]
+	second.FSRSState = int(fsrs.Review)
+	second.FSRSDue = now.Add(48 * time.Hour)
+	if err := db.UpdateCard(second); err != nil {
+		t.Fatalf("UpdateCard returned error: %v", err)
+	}
+
+	summaries, err := List(db, now)
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(summaries) != 1 || summaries[0].Deck.Name != "install-test" {
+		t.Fatalf("expected one installed deck, got %+v", summaries)
+	}
+	expected := CardCounts{Total: 2, Due: 1, New: 1, Review: 1}
+	if summaries[0].Counts != expected {
+		t.Errorf("expected counts %+v, got %+v", expected, summaries[0].Counts)
+	}
+
+	details, err := Show(db, strconv.Itoa(installed.ID), now)
+	if err != nil {
+		t.Fatalf("Show returned error: %v", err)
+	}
+	if details.Deck.Name != "install-test" || details.Assets != 1 || len(details.Cards) != 2 {
+		t.Errorf("unexpected details: deck %s, %d assets, %d cards",
+			details.Deck.Name, details.Assets, len(details.Cards))
+	}
+	states := map[string]string{}
+	for _, card := range details.Cards {
+		states[card.Key] = card.State
+	}
+	if states["basic"] != "new" || states["second"] != "review" {
+		t.Errorf("unexpected card states: %v", states)
+	}
+}
+
+func TestFind_ByNameOrID(t *testing.T) {
+	db := setupInstallDB(t)
+	installed := installWithProgress(t, db)
+
+	for _, ref := range []string{"install-test", strconv.Itoa(installed.ID)} {
+		deck, err := Find(db, ref)
+		if err != nil {
+			t.Errorf("Find(%q) returned error: %v", ref, err)
+			continue
+		}
+		if deck.ID != installed.ID {
+			t.Errorf("Find(%q) returned deck %d, expected %d", ref, deck.ID, installed.ID)
+		}
+	}
+
+	for _, ref := range []string{"missing", "999"} {
+		if _, err := Find(db, ref); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Find(%q): expected ErrNotFound, got %v", ref, err)
+		}
+	}
+}
+
+func TestSetSuspended(t *testing.T) {
+	db := setupInstallDB(t)
+	installed := installWithProgress(t, db)
+	now := time.Now()
+
+	summary, err := SetSuspended(db, "install-test/basic", true, now)
+	if err != nil {
+		t.Fatalf("SetSuspended returned error: %v", err)
+	}
+	if summary.Key != "basic" || !summary.Suspended {
+		t.Errorf("expected basic to be suspended, got %+v", summary)
+	}
+
+	summaries, err := List(db, now)
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if counts := summaries[0].Counts; counts.Suspended != 1 || counts.Due != 1 {
+		t.Errorf("expected 1 suspended and 1 due card, got %+v", counts)
+	}
+
+	// Unsuspending also ends a burial
+	basic := cardsByKey(t, db, installed.ID)["basic"]
+	tomorrow := now.Add(24 * time.Hour)
+	basic.BuriedUntil = &tomorrow
+	if err := db.UpdateCard(basic); err != nil {
+		t.Fatalf("UpdateCard returned error: %v", err)
+	}
+	summary, err = SetSuspended(db, strconv.Itoa(installed.ID)+"/basic", false, now)
+	if err != nil {
+		t.Fatalf("SetSuspended returned error: %v", err)
+	}
+	if summary.Suspended || summary.BuriedUntil != nil {
+		t.Errorf("expected basic to be back in review, got %+v", summary)
+	}
+
+	for _, ref := range []string{"install-test/missing", "missing/basic"} {
+		if _, err := SetSuspended(db, ref, true, now); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("SetSuspended(%q): expected ErrNotFound, got %v", ref, err)
+		}
+	}
+	if _, err := SetSuspended(db, "basic", true, now); err == nil {
+		t.Error("expected a card without a deck to be rejected")
+	}
+}
+
+func TestRemove_DeletesDeckAndHistory(t *testing.T) {
+	db := setupInstallDB(t)
+	installed := installWithProgress(t, db)
+
+	basic := cardsByKey(t, db, installed.ID)["basic"]
+	if err := db.CreateReview(&storage.Review{
+		CardID: basic.ID, Rating: 3, ExecutionSuccess: true, Attempts: 1,
+		FSRSDueBefore: basic.FSRSDue, FSRSDueAfter: basic.FSRSDue,
+	}); err != nil {
+		t.Fatalf("CreateReview returned error: %v", err)
+	}
+
+	plan, err := PlanRemoval(db, "install-test")
+	if err != nil {
+		t.Fatalf("PlanRemoval returned error: %v", err)
+	}
+	if plan.Cards != 2 || plan.Reviews != 1 {
+		t.Errorf("expected plan to delete 2 cards and 1 review, got %+v", plan)
+	}
+
+	if err := Remove(db, plan.Deck); err != nil {
+		t.Fatalf("Remove returned error: %v", err)
+	}
+	if _, err := Find(db, "install-test"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected deck to be gone, got %v", err)
+	}
+	if assets, _ := db.ListDeckAssets(installed.ID); len(assets) != 0 {
+		t.Errorf("expected assets to be removed, got %d", len(assets))
+	}
+}
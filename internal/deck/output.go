@@ -0,0 +1,186 @@
+package deck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// codeDescriptions documents each validation code for SARIF rule metadata
+var codeDescriptions = map[string]string{
+	STRUCT001: "Missing required file",
+	STRUCT002: "Invalid file format",
+	STRUCT003: "File encoding error",
+	STRUCT004: "Archive manifest mismatch",
+	DECK001:   "Missing required field",
+	DECK002:   "Invalid version format",
+	DECK003:   "Invalid container image",
+	DECK004:   "Invalid timeout value",
+	DECK005:   "Invalid FSRS parameters",
+	CARD001:   "Duplicate card key",
+	CARD002:   "Missing required field",
+	CARD003:   "Invalid prerequisite reference",
+	CARD004:   "Circular dependency detected",
+	CARD005:   "Command syntax error",
+	CARD006:   "Setup without cleanup",
+	CARD007:   "Bash-only syntax with a non-bash shell",
+	SEC001:    "Network enabled globally",
+	SEC002:    "Dangerous capability requested",
+	SEC003:    "Privileged container detected",
+	SEC004:    "Write access to host filesystem",
+	PERF001:   "Timeout too short",
+	PERF002:   "Timeout too long",
+	PERF003:   "Excessive memory limit",
+	PERF004:   "Too many prerequisites",
+	UX001:     "Missing description",
+	UX002:     "No hint provided",
+	UX003:     "Difficulty progression issue",
+	UX004:     "Learning path too long",
+}
+
+// sarifLog is the subset of the SARIF 2.1.0 format produced by lint
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// WriteJSON writes a validation result as indented JSON
+func WriteJSON(w io.Writer, result *ValidationResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return fmt.Errorf("failed to encode validation result: %w", err)
+	}
+	return nil
+}
+
+// WriteSARIF writes a validation result as a SARIF 2.1.0 log
+// File locations are reported relative to deckPath so code scanning can annotate
+// them; problems inside a packed archive are reported against the archive itself.
+func WriteSARIF(w io.Writer, result *ValidationResult, deckPath string) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "ancli",
+			InformationURI: "https://github.com/justinlyon12/ancli",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	used := make(map[string]bool)
+	add := func(code, level, file string, line, column int, message, details string) {
+		used[code] = true
+		text := message
+		if details != "" {
+			text += ": " + details
+		}
+		res := sarifResult{RuleID: code, Level: level, Message: sarifMessage{Text: text}}
+		if file != "" {
+			location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: artifactURI(deckPath, file)},
+			}}
+			if line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: line, StartColumn: column}
+			}
+			res.Locations = []sarifLocation{location}
+		}
+		run.Results = append(run.Results, res)
+	}
+
+	for _, e := range result.Errors {
+		add(e.Code, "error", e.File, e.Line, e.Column, e.Message, e.Details)
+	}
+	for _, warn := range result.Warnings {
+		add(warn.Code, "warning", warn.File, warn.Line, warn.Column, warn.Message, warn.Details)
+	}
+
+	codes := make([]string, 0, len(used))
+	for code := range used {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		description := codeDescriptions[code]
+		if description == "" {
+			description = code
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               code,
+			ShortDescription: sarifMessage{Text: description},
+		})
+	}
+
+	log := sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(log); err != nil {
+		return fmt.Errorf("failed to encode SARIF log: %w", err)
+	}
+	return nil
+}
+
+// artifactURI maps a file named in a validation message to a slash-separated path
+func artifactURI(deckPath, file string) string {
+	if info, err := os.Stat(deckPath); err == nil && !info.IsDir() {
+		return filepath.ToSlash(filepath.Clean(deckPath))
+	}
+	return filepath.ToSlash(filepath.Join(deckPath, file))
+}
@@ -0,0 +1,60 @@
+package deck
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	deckDir := t.TempDir()
+	result := newValidationResult()
+	result.Valid = false
+	result.Errors = append(result.Errors, ValidationError{
+		Level: "error", File: "cards.csv", Line: 3, Column: 2,
+		Code: CARD001, Message: "Duplicate card key 'basic'", Details: "Card keys must be unique",
+	})
+	result.Warnings = append(result.Warnings, ValidationWarning{
+		Level: "warning", File: "deck.yaml", Code: SEC001, Message: "Network access is enabled globally",
+	})
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, result, deckDir); err != nil {
+		t.Fatalf("WriteSARIF returned error: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("SARIF output is not valid JSON: %v", err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF envelope: version=%s runs=%d", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+
+	if len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[0].ID != CARD001 {
+		t.Errorf("expected sorted rules for the codes used, got %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(run.Results))
+	}
+
+	errResult := run.Results[0]
+	if errResult.Level != "error" || errResult.Message.Text != "Duplicate card key 'basic': Card keys must be unique" {
+		t.Errorf("unexpected error result: %+v", errResult)
+	}
+	location := errResult.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != filepath.ToSlash(filepath.Join(deckDir, "cards.csv")) {
+		t.Errorf("unexpected artifact URI: %s", location.ArtifactLocation.URI)
+	}
+	if location.Region == nil || location.Region.StartLine != 3 || location.Region.StartColumn != 2 {
+		t.Errorf("unexpected region: %+v", location.Region)
+	}
+
+	warnResult := run.Results[1]
+	if warnResult.Level != "warning" || warnResult.Locations[0].PhysicalLocation.Region != nil {
+		t.Errorf("expected a warning without a region, got %+v", warnResult)
+	}
+}
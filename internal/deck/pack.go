@@ -0,0 +1,317 @@
+package deck
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the name of the generated manifest inside a .ancli archive
+const ManifestFile = "MANIFEST.json"
+
+// maxArchiveFileSize bounds each extracted file so a hostile archive cannot fill the disk
+const maxArchiveFileSize = 64 << 20
+
+// archiveModTime is stamped on every entry so identical decks produce identical archives
+var archiveModTime = time.Unix(0, 0).UTC()
+
+// Manifest describes the contents of a .ancli archive
+type Manifest struct {
+	Name    string          `json:"name"`
+	Version string          `json:"version"`
+	Files   []ManifestEntry `json:"files"`
+}
+
+// ManifestEntry records the size and SHA-256 digest of one packed file
+type ManifestEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// PackResult summarizes a packed deck
+type PackResult struct {
+	Manifest   *Manifest
+	Validation *ValidationResult
+	SHA256     string // Digest of the archive itself
+}
+
+// Pack validates the deck directory and writes it to w as a reproducible .ancli archive
+// The archive is a gzip-compressed tar holding deck.yaml, cards.csv, README.md (if
+// present), assets/, and a generated MANIFEST.json. Entries are sorted and carry fixed
+// ownership and timestamps, so packing the same deck twice yields identical bytes.
+func Pack(deckPath string, w io.Writer) (*PackResult, error) {
+	info, err := os.Stat(deckPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deck directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a deck directory", deckPath)
+	}
+
+	source, err := Open(deckPath)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	if !source.Validation.Valid {
+		return nil, &InvalidDeckError{Result: source.Validation}
+	}
+
+	files, err := packableFiles(deckPath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		Name:    source.Spec.Name,
+		Version: source.Spec.Version,
+	}
+
+	contents := make(map[string][]byte, len(files)+1)
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(deckPath, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, ManifestEntry{
+			Path:   name,
+			Size:   int64(len(data)),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+		contents[name] = data
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	contents[ManifestFile] = append(manifestData, '\n')
+
+	names := append([]string{ManifestFile}, files...)
+	sort.Strings(names)
+
+	digest := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(w, digest))
+	tw := tar.NewWriter(gz)
+
+	for _, name := range names {
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(contents[name])),
+			ModTime:  archiveModTime,
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to write archive header for %s: %w", name, err)
+		}
+		if _, err := tw.Write(contents[name]); err != nil {
+			return nil, fmt.Errorf("failed to write %s to archive: %w", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize compression: %w", err)
+	}
+
+	return &PackResult{
+		Manifest:   manifest,
+		Validation: source.Validation,
+		SHA256:     hex.EncodeToString(digest.Sum(nil)),
+	}, nil
+}
+
+// ArchiveName returns the default file name for a packed deck
+func ArchiveName(manifest *Manifest) string {
+	return fmt.Sprintf("%s-%s.ancli", manifest.Name, manifest.Version)
+}
+
+// packableFiles lists the deck files that belong in an archive as sorted slash paths
+func packableFiles(deckPath string) ([]string, error) {
+	files := []string{"deck.yaml", "cards.csv"}
+
+	if info, err := os.Stat(filepath.Join(deckPath, "README.md")); err == nil && info.Mode().IsRegular() {
+		files = append(files, "README.md")
+	}
+
+	assetsDir := filepath.Join(deckPath, "assets")
+	if info, err := os.Stat(assetsDir); err == nil && info.IsDir() {
+		err := filepath.WalkDir(assetsDir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(deckPath, p)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deck assets: %w", err)
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// unpackArchive extracts a .ancli archive into destDir and checks it against its manifest
+// Integrity problems (missing manifest, unexpected files, hash mismatches, a name or
+// version that differs from deck.yaml) are recorded on result as STRUCT004 errors;
+// only I/O failures are returned as errors.
+func unpackArchive(archivePath, destDir string, result *ValidationResult) (*Manifest, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		addManifestError(result, archivePath, "File is not a gzip-compressed .ancli archive", err.Error())
+		return nil, nil
+	}
+	defer gz.Close()
+
+	digests := make(map[string]string)
+	var manifestData, deckData []byte
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			addManifestError(result, archivePath, "Archive is corrupt", err.Error())
+			return nil, nil
+		}
+
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		name, ok := cleanArchivePath(header.Name)
+		if !ok || header.Typeflag != tar.TypeReg {
+			addManifestError(result, header.Name, "Archive contains an unsafe entry",
+				"Only regular files with relative paths are allowed in .ancli archives")
+			continue
+		}
+		if header.Size > maxArchiveFileSize {
+			addManifestError(result, name, "Archive entry is too large",
+				fmt.Sprintf("%d bytes exceeds the %d byte limit", header.Size, maxArchiveFileSize))
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, maxArchiveFileSize))
+		if err != nil {
+			addManifestError(result, name, "Archive entry could not be read", err.Error())
+			return nil, nil
+		}
+
+		if name == ManifestFile {
+			manifestData = data
+			continue
+		}
+		if name == "deck.yaml" {
+			deckData = data
+		}
+
+		target := filepath.Join(destDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+
+		sum := sha256.Sum256(data)
+		digests[name] = hex.EncodeToString(sum[:])
+	}
+
+	if manifestData == nil {
+		addManifestError(result, ManifestFile, "Archive has no manifest",
+			"Rebuild the archive with 'ancli deck pack'")
+		return nil, nil
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		addManifestError(result, ManifestFile, "Manifest is not valid JSON", err.Error())
+		return nil, nil
+	}
+
+	listed := make(map[string]bool, len(manifest.Files))
+	for _, entry := range manifest.Files {
+		listed[entry.Path] = true
+		actual, exists := digests[entry.Path]
+		switch {
+		case !exists:
+			addManifestError(result, entry.Path, "File listed in manifest is missing from archive", "")
+		case actual != entry.SHA256:
+			addManifestError(result, entry.Path, "File does not match its manifest hash",
+				fmt.Sprintf("expected sha256 %s, got %s", entry.SHA256, actual))
+		}
+	}
+
+	for name := range digests {
+		if !listed[name] {
+			addManifestError(result, name, "File is not listed in the manifest", "")
+		}
+	}
+
+	// The manifest names the deck the archive installs, so it must agree with deck.yaml;
+	// a deck.yaml that can't be parsed is reported by validation instead
+	var spec DeckSpec
+	if deckData != nil && yaml.Unmarshal(deckData, &spec) == nil &&
+		(spec.Name != manifest.Name || spec.Version != manifest.Version) {
+		addManifestError(result, ManifestFile, "Manifest does not match deck.yaml",
+			fmt.Sprintf("manifest is %s %s, deck.yaml is %s %s", manifest.Name, manifest.Version, spec.Name, spec.Version))
+	}
+
+	return &manifest, nil
+}
+
+// cleanArchivePath normalizes an entry name and rejects absolute or escaping paths
+func cleanArchivePath(name string) (string, bool) {
+	cleaned := path.Clean(strings.TrimPrefix(name, "./"))
+	if cleaned == "." || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", false
+	}
+	return cleaned, true
+}
+
+// addManifestError records an archive integrity problem
+func addManifestError(result *ValidationResult, file, message, details string) {
+	result.Errors = append(result.Errors, ValidationError{
+		Level:   "error",
+		File:    file,
+		Code:    STRUCT004,
+		Message: message,
+		Details: details,
+	})
+}
@@ -0,0 +1,220 @@
+package deck
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPack_IsReproducible(t *testing.T) {
+	deckDir := t.TempDir()
+	createInstallableDeck(t, deckDir)
+
+	var first, second bytes.Buffer
+	result, err := Pack(deckDir, &first)
+	if err != nil {
+		t.Fatalf("Pack returned error: %v", err)
+	}
+	if _, err := Pack(deckDir, &second); err != nil {
+		t.Fatalf("second Pack returned error: %v", err)
+	}
+
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("packing the same deck twice produced different archives")
+	}
+
+	if result.Manifest.Name != "install-test" || result.Manifest.Version != "1.2.0" {
+		t.Errorf("unexpected manifest identity: %s %s", result.Manifest.Name, result.Manifest.Version)
+	}
+
+	expected := []string{"assets/data/config.json", "cards.csv", "deck.yaml"}
+	if len(result.Manifest.Files) != len(expected) {
+		t.Fatalf("expected %d manifest entries, got %d", len(expected), len(result.Manifest.Files))
+	}
+	for i, path := range expected {
+		if result.Manifest.Files[i].Path != path {
+			t.Errorf("manifest entry %d: expected %s, got %s", i, path, result.Manifest.Files[i].Path)
+		}
+	}
+}
+
+func TestPack_RefusesInvalidDeck(t *testing.T) {
+	deckDir := t.TempDir()
+	createFile(t, filepath.Join(deckDir, "deck.yaml"), "name: broken\n")
+	createFile(t, filepath.Join(deckDir, "cards.csv"), "key,title\n")
+
+	var buf bytes.Buffer
+	if _, err := Pack(deckDir, &buf); err == nil {
+		t.Error("expected error when packing an invalid deck")
+	}
+}
+
+func TestOpen_Archive(t *testing.T) {
+	deckDir := t.TempDir()
+	createInstallableDeck(t, deckDir)
+	archive := writeArchive(t, deckDir)
+
+	source, err := Open(archive)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer source.Close()
+
+	if !source.Validation.Valid {
+		t.Fatalf("expected packed deck to validate, got errors: %+v", source.Validation.Errors)
+	}
+	if source.Manifest == nil || source.Manifest.Name != "install-test" {
+		t.Errorf("expected manifest to be loaded, got %+v", source.Manifest)
+	}
+	if len(source.Cards) != 2 {
+		t.Errorf("expected 2 cards, got %d", len(source.Cards))
+	}
+
+	extracted := source.Dir
+	source.Close()
+	if _, err := os.Stat(extracted); !os.IsNotExist(err) {
+		t.Error("expected extracted files to be removed on Close")
+	}
+}
+
+func TestOpen_TamperedArchive(t *testing.T) {
+	deckDir := t.TempDir()
+	createInstallableDeck(t, deckDir)
+	archive := writeArchive(t, deckDir)
+
+	// Rewrite the archive with modified card content but the original manifest
+	tampered := rewriteArchive(t, archive, func(name string, data []byte) []byte {
+		if name == "cards.csv" {
+			return append(data, []byte("evil,\"Evil\",\"rm -rf /\",\"Oops\",,,,,,,,1,\n")...)
+		}
+		return data
+	})
+
+	source, err := Open(tampered)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer source.Close()
+
+	if source.Validation.Valid {
+		t.Fatal("expected tampered archive to fail validation")
+	}
+	found := false
+	for _, e := range source.Validation.Errors {
+		if e.Code == STRUCT004 && e.File == "cards.csv" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected STRUCT004 error for cards.csv, got %+v", source.Validation.Errors)
+	}
+}
+
+func TestOpen_ArchiveManifestMustMatchDeck(t *testing.T) {
+	deckDir := t.TempDir()
+	createInstallableDeck(t, deckDir)
+	archive := writeArchive(t, deckDir)
+
+	// The files are intact, but the manifest claims to be a different release
+	relabelled := rewriteArchive(t, archive, func(name string, data []byte) []byte {
+		if name == ManifestFile {
+			return bytes.Replace(data, []byte(`"version": "1.2.0"`), []byte(`"version": "2.0.0"`), 1)
+		}
+		return data
+	})
+
+	source, err := Open(relabelled)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer source.Close()
+
+	if source.Validation.Valid {
+		t.Fatal("expected an archive whose manifest disagrees with deck.yaml to fail validation")
+	}
+	if len(source.Validation.Errors) != 1 || source.Validation.Errors[0].File != ManifestFile ||
+		!strings.Contains(source.Validation.Errors[0].Details, "manifest is install-test 2.0.0, deck.yaml is install-test 1.2.0") {
+		t.Errorf("expected one STRUCT004 error for the manifest, got %+v", source.Validation.Errors)
+	}
+}
+
+func TestInstall_FromArchive(t *testing.T) {
+	db := setupInstallDB(t)
+	deckDir := t.TempDir()
+	createInstallableDeck(t, deckDir)
+	archive := writeArchive(t, deckDir)
+
+	result, err := Install(db, archive)
+	if err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+	if result.CardCount != 2 || result.AssetCount != 1 {
+		t.Errorf("expected 2 cards and 1 asset, got %d and %d", result.CardCount, result.AssetCount)
+	}
+}
+
+func writeArchive(t *testing.T, deckDir string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if _, err := Pack(deckDir, &buf); err != nil {
+		t.Fatalf("Pack returned error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "deck.ancli")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	return path
+}
+
+func rewriteArchive(t *testing.T, archive string, modify func(name string, data []byte) []byte) string {
+	t.Helper()
+
+	file, err := os.Open(archive)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("failed to read gzip: %v", err)
+	}
+
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read tar: %v", err)
+		}
+		data, _ := io.ReadAll(tr)
+		data = modify(header.Name, data)
+		header.Size = int64(len(data))
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("failed to write header: %v", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatalf("failed to write data: %v", err)
+		}
+	}
+	tw.Close()
+	gw.Close()
+
+	path := filepath.Join(t.TempDir(), "tampered.ancli")
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write tampered archive: %v", err)
+	}
+	return path
+}
@@ -0,0 +1,70 @@
+package deck
+
+import (
+	"fmt"
+	"os"
+)
+
+// Source is a deck opened from a directory or a packed .ancli archive
+type Source struct {
+	Dir        string     // Directory holding deck.yaml, cards.csv, and assets/
+	Spec       *DeckSpec  // Parsed deck.yaml (nil when validation failed)
+	Cards      []CardSpec // Parsed cards.csv (nil when validation failed)
+	Validation *ValidationResult
+	Manifest   *Manifest // Set when the deck was opened from an archive
+
+	cleanup func() error
+}
+
+// Open validates the deck at path, which may be a directory or a .ancli archive
+// Archives are extracted to a temporary directory and their manifest hashes are
+// checked before validation; mismatches are reported as STRUCT004 errors.
+// The returned Source must be closed to remove any extracted files.
+func Open(path string) (*Source, error) {
+	source := &Source{
+		Dir:        path,
+		Validation: newValidationResult(),
+		cleanup:    func() error { return nil },
+	}
+
+	info, err := os.Stat(path)
+	if err == nil && !info.IsDir() {
+		tmpDir, err := os.MkdirTemp("", "ancli-deck-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create extraction directory: %w", err)
+		}
+		source.Dir = tmpDir
+		source.cleanup = func() error { return os.RemoveAll(tmpDir) }
+
+		manifest, err := unpackArchive(path, tmpDir, source.Validation)
+		if err != nil {
+			source.Close()
+			return nil, err
+		}
+		source.Manifest = manifest
+
+		// A tampered archive is never validated further
+		if len(source.Validation.Errors) > 0 {
+			source.Validation.Valid = false
+			return source, nil
+		}
+	}
+
+	spec, cards, err := validateDeck(source.Dir, source.Validation)
+	if err != nil {
+		source.Close()
+		return nil, err
+	}
+
+	if source.Validation.Valid {
+		source.Spec = spec
+		source.Cards = cards
+	}
+
+	return source, nil
+}
+
+// Close removes any files extracted from an archive
+func (s *Source) Close() error {
+	return s.cleanup()
+}
@@ -0,0 +1,515 @@
+package deck
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/justinlyon12/ancli/internal/sandbox"
+	"github.com/justinlyon12/ancli/internal/storage"
+)
+
+// Card test steps, in the order they run
+const (
+	StepSetup     = "setup"
+	StepPreVerify = "pre-verify" // verify run before the solution; it should fail
+	StepSolution  = "solution"
+	StepVerify    = "verify"
+	StepCleanup   = "cleanup"
+)
+
+// TestOptions controls which cards are tested and how
+type TestOptions struct {
+	Card          string // Only test this card key
+	WithPrereqs   bool   // Run each card's prerequisites first to establish their state
+	Repeat        int    // Run each card this many times to check cleanup restores state
+	Comprehensive bool   // Test every solution alternative, not just the first
+	DryRun        bool   // Plan the steps without starting containers
+}
+
+// StepResult records one command run during a card test
+type StepResult struct {
+	Step     string
+	Command  string
+	ExitCode int
+	Stdout   string
+	Stderr   string
+	Duration time.Duration
+	Passed   bool
+	Skipped  bool // Set for every step of a dry run
+}
+
+// CardTestResult records one end-to-end run of a card
+type CardTestResult struct {
+	Key      string
+	Title    string
+	Run      int      // 1-based repetition number
+	Solution string   // Solution alternative that was tested
+	Prereqs  []string // Prerequisite cards run first with --with-prereqs
+	Steps    []StepResult
+	Passed   bool
+	Failure  string   // Why the card failed
+	Warnings []string // Problems that do not fail the card
+}
+
+// TestReport summarizes a deck test run
+type TestReport struct {
+	Deck    string
+	Version string
+	DryRun  bool
+	Results []CardTestResult
+	Passed  int
+	Failed  int
+	Warned  int
+}
+
+// OK reports whether every tested card passed
+func (r *TestReport) OK() bool {
+	return r.Failed == 0
+}
+
+// Tester runs deck cards end-to-end through a sandbox
+type Tester struct {
+	sandbox sandbox.Sandbox
+	mounts  []sandbox.Mount // The deck's assets directory, set by Run
+	session string          // Sandbox session the run's containers belong to, set by Run
+}
+
+// NewTester creates a tester backed by sb
+// sb may be nil when only dry runs are needed.
+func NewTester(sb sandbox.Sandbox) *Tester {
+	return &Tester{sandbox: sb}
+}
+
+// Run tests the cards of a validated deck in prerequisite order
+// For each card it runs setup, verify (which should fail before the solution), the
+// solution, verify again, and cleanup. Each card starts in a fresh container;
+// repetitions and solution alternatives of the same card share it, so a cleanup that
+// does not restore state shows up as a failure on the next run.
+func (t *Tester) Run(ctx context.Context, source *Source, opts TestOptions) (*TestReport, error) {
+	if !source.Validation.Valid {
+		return nil, &InvalidDeckError{Result: source.Validation}
+	}
+	if !opts.DryRun && t.sandbox == nil {
+		return nil, fmt.Errorf("a sandbox is required unless running a dry run")
+	}
+
+	mounts, err := assetMounts(source.Dir)
+	if err != nil {
+		return nil, err
+	}
+	t.mounts = mounts
+	// A session of its own keeps the run's containers apart from reviews and other runs
+	t.session = "deck-test-" + uuid.New().String()
+
+	spec := source.Spec
+	ordered := prerequisiteOrder(source.Cards)
+	byKey := make(map[string]CardSpec, len(ordered))
+	for _, card := range ordered {
+		byKey[card.Key] = card
+	}
+
+	if opts.Card != "" {
+		card, exists := byKey[opts.Card]
+		if !exists {
+			return nil, fmt.Errorf("card %q not found in deck %s", opts.Card, spec.Name)
+		}
+		ordered = []CardSpec{card}
+	}
+
+	repeat := opts.Repeat
+	if repeat < 1 {
+		repeat = 1
+	}
+
+	report := &TestReport{Deck: spec.Name, Version: spec.Version, DryRun: opts.DryRun}
+	for _, card := range ordered {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		// Start every card in a fresh container
+		if !opts.DryRun {
+			if err := t.sandbox.Cleanup(ctx, t.session); err != nil {
+				return report, fmt.Errorf("failed to reset sandbox: %w", err)
+			}
+		}
+
+		var prereqs []CardSpec
+		if opts.WithPrereqs {
+			prereqs = prerequisiteChain(card.Key, byKey)
+		}
+
+		solutions := SolutionAlternatives(card)
+		if !opts.Comprehensive {
+			solutions = solutions[:1]
+		}
+
+		for i, solution := range solutions {
+			for run := 1; run <= repeat; run++ {
+				result := CardTestResult{
+					Key:      card.Key,
+					Title:    card.Title,
+					Run:      run,
+					Solution: solution,
+				}
+
+				// Prerequisites establish state once per container
+				if len(prereqs) > 0 && run == 1 && i == 0 {
+					t.runPrereqs(ctx, spec, prereqs, opts.DryRun, &result)
+				}
+				if result.Failure == "" {
+					t.runCard(ctx, spec, card, solution, opts.DryRun, &result)
+				}
+
+				result.Passed = result.Failure == ""
+				if result.Passed {
+					report.Passed++
+				} else {
+					report.Failed++
+				}
+				if len(result.Warnings) > 0 {
+					report.Warned++
+				}
+				report.Results = append(report.Results, result)
+			}
+		}
+	}
+
+	if !opts.DryRun {
+		if err := t.sandbox.Cleanup(ctx, t.session); err != nil {
+			return report, fmt.Errorf("failed to clean up sandbox: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// runPrereqs runs the setup and first solution of each prerequisite card
+func (t *Tester) runPrereqs(ctx context.Context, spec *DeckSpec, prereqs []CardSpec, dryRun bool, result *CardTestResult) {
+	for _, prereq := range prereqs {
+		result.Prereqs = append(result.Prereqs, prereq.Key)
+
+		workingDir := spec.workingDir()
+		if prereq.Setup != "" {
+			step, dir := t.runSetup(ctx, spec, prereq.Setup, workingDir, dryRun)
+			result.Steps = append(result.Steps, prefixStep(prereq.Key, step))
+			if !step.Passed {
+				result.Failure = fmt.Sprintf("prerequisite %s: setup failed", prereq.Key)
+				return
+			}
+			workingDir = dir
+		}
+
+		step := t.runStep(ctx, spec, StepSolution, SolutionAlternatives(prereq)[0], workingDir, spec.commandTimeout(), dryRun)
+		result.Steps = append(result.Steps, prefixStep(prereq.Key, step))
+		if !step.Passed {
+			result.Failure = fmt.Sprintf("prerequisite %s: solution failed", prereq.Key)
+			return
+		}
+	}
+}
+
+// runCard runs one card's steps and records the outcome on result
+func (t *Tester) runCard(ctx context.Context, spec *DeckSpec, card CardSpec, solution string, dryRun bool, result *CardTestResult) {
+	record := func(step StepResult) StepResult {
+		result.Steps = append(result.Steps, step)
+		return step
+	}
+	fail := func(reason string) {
+		if result.Failure == "" {
+			result.Failure = reason
+		}
+	}
+
+	workingDir := spec.workingDir()
+	setupPassed := true
+	if card.Setup != "" {
+		step, dir := t.runSetup(ctx, spec, card.Setup, workingDir, dryRun)
+		record(step)
+		setupPassed = step.Passed
+		if setupPassed {
+			workingDir = dir
+		} else {
+			fail(fmt.Sprintf("setup exited with code %d", step.ExitCode))
+		}
+	}
+
+	if setupPassed {
+		if card.Verify != "" {
+			// verify is expected to fail here, so the step passes when it does
+			step := t.runStep(ctx, spec, StepPreVerify, card.Verify, workingDir, spec.commandTimeout(), dryRun)
+			step.Passed = step.Skipped || step.ExitCode != 0
+			if !record(step).Passed {
+				result.Warnings = append(result.Warnings,
+					"verify passes before the solution runs, so it cannot tell whether the learner did anything")
+			}
+		}
+
+		step := record(t.runStep(ctx, spec, StepSolution, solution, workingDir, spec.commandTimeout(), dryRun))
+		if !step.Passed {
+			fail(fmt.Sprintf("solution exited with code %d", step.ExitCode))
+		} else if card.Verify != "" {
+			step := record(t.runStep(ctx, spec, StepVerify, card.Verify, workingDir, spec.commandTimeout(), dryRun))
+			if !step.Passed {
+				fail(fmt.Sprintf("verify exited with code %d after the solution ran", step.ExitCode))
+			}
+		}
+	}
+
+	// Cleanup always runs so later cards start from a known state
+	if card.Cleanup != "" {
+		step := record(t.runStep(ctx, spec, StepCleanup, card.Cleanup, workingDir, spec.cleanupTimeout(), dryRun))
+		if !step.Passed {
+			fail(fmt.Sprintf("cleanup exited with code %d", step.ExitCode))
+		}
+	}
+}
+
+// runSetup runs a setup command and returns the directory it finished in
+// Each step runs in its own shell, so the only shell state carried forward is the
+// working directory: a setup ending in "cd my_project" makes later steps start there.
+func (t *Tester) runSetup(ctx context.Context, spec *DeckSpec, setup, workingDir string, dryRun bool) (StepResult, string) {
+	step := t.runStep(ctx, spec, StepSetup, sandbox.TrackWorkingDir(setup), workingDir, spec.commandTimeout(), dryRun)
+	step.Command = setup
+	step.Stdout, workingDir = sandbox.TrackedWorkingDir(step.Stdout, workingDir)
+	return step, workingDir
+}
+
+// runStep executes a single command in the sandbox
+func (t *Tester) runStep(ctx context.Context, spec *DeckSpec, name, command, workingDir string, timeout time.Duration, dryRun bool) StepResult {
+	step := StepResult{Step: name, Command: command}
+	if dryRun {
+		step.Passed = true
+		step.Skipped = true
+		return step
+	}
+
+	config := sandbox.NewExecutionConfig().
+		WithImage(spec.image()).
+		WithCommand(sandbox.ShellCommand(spec.Container.Shell, command)...).
+		WithNetworking(spec.Container.Network).
+		WithTimeout(timeout).
+		WithCorrelationID(fmt.Sprintf("deck-test-%s-%s", spec.Name, name)).
+		WithSessionID(t.session)
+	config.WorkingDir = workingDir
+	config.Mounts = t.mounts
+	for key, value := range spec.Container.Environment {
+		config.Environment[key] = value
+	}
+
+	result, err := t.sandbox.Run(ctx, config)
+	if result != nil {
+		step.ExitCode = result.ExitCode
+		step.Stdout = result.Stdout
+		step.Stderr = result.Stderr
+		step.Duration = result.Duration
+		step.Passed = result.Success
+	}
+	if err != nil {
+		step.Passed = false
+		step.ExitCode = -1
+		step.Stderr = strings.TrimSpace(step.Stderr + "\n" + err.Error())
+	}
+
+	return step
+}
+
+// assetMounts mounts the deck's assets/ directory read-only at sandbox.AssetsPath,
+// where installed decks find them during review
+func assetMounts(deckDir string) ([]sandbox.Mount, error) {
+	dir, err := filepath.Abs(filepath.Join(deckDir, "assets"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve assets directory: %w", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+	return []sandbox.Mount{{Source: dir, Target: sandbox.AssetsPath, ReadOnly: true}}, nil
+}
+
+// SolutionAlternatives splits a card's pipe-separated solution field into alternatives
+// Alternatives are separated by " | ", which is also the shell pipe operator, so the
+// field is split into groups with as many pipe segments as the card's command. A
+// card without a solution is solved by its command.
+func SolutionAlternatives(card CardSpec) []string {
+	solution := strings.TrimSpace(card.Solution)
+	if solution == "" {
+		return []string{card.Command}
+	}
+
+	parts := strings.Split(solution, " | ")
+	width := len(strings.Split(card.Command, " | "))
+	if len(parts)%width != 0 {
+		return []string{solution}
+	}
+
+	var alternatives []string
+	for i := 0; i < len(parts); i += width {
+		alternatives = append(alternatives, strings.TrimSpace(strings.Join(parts[i:i+width], " | ")))
+	}
+	return alternatives
+}
+
+// prefixStep labels a prerequisite step with the card it belongs to
+func prefixStep(key string, step StepResult) StepResult {
+	step.Step = key + ":" + step.Step
+	return step
+}
+
+// prerequisiteOrder sorts cards so every card follows its prerequisites
+// Cards otherwise keep their cards.csv order. The deck must be free of cycles.
+func prerequisiteOrder(cards []CardSpec) []CardSpec {
+	byKey := make(map[string]CardSpec, len(cards))
+	for _, card := range cards {
+		byKey[card.Key] = card
+	}
+
+	ordered := make([]CardSpec, 0, len(cards))
+	placed := make(map[string]bool, len(cards))
+	var place func(card CardSpec)
+	place = func(card CardSpec) {
+		if placed[card.Key] {
+			return
+		}
+		placed[card.Key] = true
+		for _, key := range splitList(card.Prerequisites) {
+			if prereq, exists := byKey[key]; exists {
+				place(prereq)
+			}
+		}
+		ordered = append(ordered, card)
+	}
+
+	for _, card := range cards {
+		place(card)
+	}
+	return ordered
+}
+
+// prerequisiteChain returns every card key depends on, directly or not, in run order
+func prerequisiteChain(key string, byKey map[string]CardSpec) []CardSpec {
+	var chain []CardSpec
+	seen := map[string]bool{key: true}
+	var walk func(key string)
+	walk = func(key string) {
+		for _, prereq := range splitList(byKey[key].Prerequisites) {
+			if seen[prereq] {
+				continue
+			}
+			seen[prereq] = true
+			walk(prereq)
+			chain = append(chain, byKey[prereq])
+		}
+	}
+	walk(key)
+	return chain
+}
+
+// image returns the deck's container image, falling back to the storage default
+func (s *DeckSpec) image() string {
+	if s.Container.Image == "" {
+		return storage.DefaultDeckImage
+	}
+	return s.Container.Image
+}
+
+// workingDir returns the directory card commands start in
+func (s *DeckSpec) workingDir() string {
+	if s.Container.WorkingDir == "" {
+		return "/tmp"
+	}
+	return s.Container.WorkingDir
+}
+
+// commandTimeout returns the per-command timeout
+func (s *DeckSpec) commandTimeout() time.Duration {
+	if s.Container.Timeout <= 0 {
+		return storage.DefaultDeckTimeout * time.Second
+	}
+	return time.Duration(s.Container.Timeout) * time.Second
+}
+
+// cleanupTimeout returns the timeout for cleanup commands
+func (s *DeckSpec) cleanupTimeout() time.Duration {
+	if s.Cleanup.Timeout <= 0 {
+		return s.commandTimeout()
+	}
+	return time.Duration(s.Cleanup.Timeout) * time.Second
+}
+
+// PrintTestReport outputs deck test results in a human-readable format
+func PrintTestReport(report *TestReport, verbose bool) {
+	if report.DryRun {
+		fmt.Printf("🔍 Test plan for %s v%s (dry run, no containers started)\n\n", report.Deck, report.Version)
+	} else {
+		fmt.Printf("🧪 Testing %s v%s\n\n", report.Deck, report.Version)
+	}
+
+	for _, result := range report.Results {
+		label := result.Key
+		if result.Run > 1 {
+			label += fmt.Sprintf(" (run %d)", result.Run)
+		}
+		if result.Solution != "" && verbose {
+			label += fmt.Sprintf(" — %s", result.Solution)
+		}
+
+		switch {
+		case report.DryRun:
+			fmt.Printf("  • %s\n", label)
+		case !result.Passed:
+			fmt.Printf("  ❌ %s: %s\n", label, result.Failure)
+		case len(result.Warnings) > 0:
+			fmt.Printf("  ⚠️  %s\n", label)
+		default:
+			fmt.Printf("  ✅ %s\n", label)
+		}
+
+		for _, warning := range result.Warnings {
+			fmt.Printf("     ⚠️  %s\n", warning)
+		}
+
+		// Show every step for dry runs and failures, or always when verbose
+		if !report.DryRun && result.Passed && !verbose {
+			continue
+		}
+		for _, step := range result.Steps {
+			if report.DryRun {
+				fmt.Printf("     %-12s %s\n", step.Step, step.Command)
+				continue
+			}
+			status := "✓"
+			if !step.Passed {
+				status = "✗"
+			}
+			fmt.Printf("     %s %-12s exit %d  %s\n", status, step.Step, step.ExitCode, step.Command)
+			if !step.Passed || verbose {
+				printStepOutput("stdout", step.Stdout)
+				printStepOutput("stderr", step.Stderr)
+			}
+		}
+	}
+
+	if report.DryRun {
+		fmt.Printf("\n%d card runs planned\n", len(report.Results))
+		return
+	}
+	fmt.Printf("\nSummary: %d passed, %d failed, %d with warnings\n", report.Passed, report.Failed, report.Warned)
+}
+
+// printStepOutput prints captured output indented under its step
+func printStepOutput(label, output string) {
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		return
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fmt.Printf("         %s | %s\n", label, line)
+	}
+}
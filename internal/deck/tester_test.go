@@ -0,0 +1,324 @@
+package deck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/justinlyon12/ancli/internal/sandbox"
+)
+
+// hostSandbox runs commands with the host shell, standing in for a container
+type hostSandbox struct {
+	commands []string
+	mounts   []sandbox.Mount
+	sessions map[string]bool // Sessions commands ran in
+	cleanups int
+	cleaned  []string // Sessions cleaned up
+}
+
+func (s *hostSandbox) Run(ctx context.Context, config sandbox.ExecutionConfig) (*sandbox.ExecutionResult, error) {
+	s.commands = append(s.commands, config.Command[len(config.Command)-1])
+	s.mounts = config.Mounts
+	if s.sessions == nil {
+		s.sessions = make(map[string]bool)
+	}
+	s.sessions[config.SessionID] = true
+
+	cmd := exec.CommandContext(ctx, config.Command[0], config.Command[1:]...)
+	cmd.Dir = config.WorkingDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, err
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	return &sandbox.ExecutionResult{
+		ExitCode: exitCode,
+		Success:  exitCode == 0,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}, nil
+}
+
+func (s *hostSandbox) Shell(ctx context.Context, config sandbox.ExecutionConfig, term sandbox.Terminal) (*sandbox.ExecutionResult, error) {
+	return nil, errors.New("host sandbox has no interactive shell")
+}
+
+func (s *hostSandbox) Cleanup(ctx context.Context, sessionID string) error {
+	s.cleanups++
+	s.cleaned = append(s.cleaned, sessionID)
+	return nil
+}
+
+func (s *hostSandbox) CleanupAll(ctx context.Context) error {
+	s.cleanups++
+	return nil
+}
+
+func (s *hostSandbox) Name() string {
+	return "host"
+}
+
+// openTestDeck writes a deck whose commands run in workDir and opens it
+func openTestDeck(t *testing.T, workDir string, rows ...string) *Source {
+	t.Helper()
+
+	deckDir := t.TempDir()
+	createFile(t, filepath.Join(deckDir, "deck.yaml"), `
+name: tester-deck
+version: 1.0.0
+author: Test Author
+description: A deck used by tester tests
+container:
+  working_dir: `+workDir+`
+`)
+	createFile(t, filepath.Join(deckDir, "cards.csv"),
+		"key,title,command,description,setup,cleanup,prerequisites,verify,hint,solution,explanation,difficulty,tags\n"+
+			strings.Join(rows, "\n")+"\n")
+
+	source, err := Open(deckDir)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	t.Cleanup(func() { source.Close() })
+	if !source.Validation.Valid {
+		t.Fatalf("test deck is invalid: %+v", source.Validation.Errors)
+	}
+	return source
+}
+
+func TestTester_PassingCardCarriesSetupDirectory(t *testing.T) {
+	workDir := t.TempDir()
+	source := openTestDeck(t, workDir,
+		`touch,"Touch","touch main.py","Create main.py","mkdir -p proj && cd proj","cd .. && rm -rf proj",,"test -f main.py","Use touch","touch main.py","Creates a file",1,files`,
+	)
+
+	sb := &hostSandbox{}
+	report, err := NewTester(sb).Run(context.Background(), source, TestOptions{})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if !report.OK() || report.Passed != 1 {
+		t.Fatalf("expected card to pass, got %+v", report.Results)
+	}
+
+	result := report.Results[0]
+	var steps []string
+	for _, step := range result.Steps {
+		steps = append(steps, step.Step)
+	}
+	expected := []string{StepSetup, StepPreVerify, StepSolution, StepVerify, StepCleanup}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("expected steps %v, got %v", expected, steps)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", result.Warnings)
+	}
+	if strings.Contains(result.Steps[0].Stdout, "__ANCLI_CWD__") {
+		t.Error("expected working directory marker to be stripped from setup output")
+	}
+	if sb.cleanups != 2 {
+		t.Errorf("expected a fresh sandbox before the card and cleanup at the end, got %d cleanups", sb.cleanups)
+	}
+
+	// The run's commands and cleanups are confined to one session of its own
+	if len(sb.sessions) != 1 || sb.sessions[""] {
+		t.Errorf("expected every command in one named session, got %v", sb.sessions)
+	}
+	for _, session := range sb.cleaned {
+		if !sb.sessions[session] {
+			t.Errorf("expected cleanups of the run's session, got %q", session)
+		}
+	}
+}
+
+func TestTester_ReportsFailuresAndWarnings(t *testing.T) {
+	source := openTestDeck(t, t.TempDir(),
+		`broken,"Broken","touch a","Create a",,"rm -f a",,"test -f b","Use touch","touch a","Creates a",1,files`,
+		`trivial,"Trivial","echo hi","Print hi",,,,"true","Use echo","echo hi","Prints hi",1,output`,
+	)
+
+	report, err := NewTester(&hostSandbox{}).Run(context.Background(), source, TestOptions{})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if report.OK() || report.Failed != 1 || report.Passed != 1 || report.Warned != 1 {
+		t.Fatalf("expected 1 failure and 1 warning, got passed=%d failed=%d warned=%d",
+			report.Passed, report.Failed, report.Warned)
+	}
+
+	broken := report.Results[0]
+	if broken.Passed || !strings.Contains(broken.Failure, "verify exited with code 1") {
+		t.Errorf("expected verify failure for broken card, got %q", broken.Failure)
+	}
+	if last := broken.Steps[len(broken.Steps)-1]; last.Step != StepCleanup {
+		t.Errorf("expected cleanup to run after a failure, last step was %s", last.Step)
+	}
+
+	trivial := report.Results[1]
+	if !trivial.Passed || len(trivial.Warnings) != 1 {
+		t.Errorf("expected trivial verify to pass with a warning, got %+v", trivial)
+	}
+}
+
+func TestTester_RepeatCatchesMissingCleanup(t *testing.T) {
+	source := openTestDeck(t, t.TempDir(),
+		`mkdir,"Make directory","mkdir project","Create project",,,,"test -d project","Use mkdir","mkdir project","Creates project",1,directories`,
+	)
+
+	report, err := NewTester(&hostSandbox{}).Run(context.Background(), source, TestOptions{Repeat: 2})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if len(report.Results) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(report.Results))
+	}
+	if !report.Results[0].Passed || report.Results[1].Passed {
+		t.Errorf("expected first run to pass and second to fail, got %v and %v",
+			report.Results[0].Passed, report.Results[1].Passed)
+	}
+}
+
+func TestTester_PrerequisiteOrderAndChain(t *testing.T) {
+	source := openTestDeck(t, t.TempDir(),
+		`write,"Write","echo hi > notes.txt","Write notes",,,make,"test -s notes.txt","Use echo","echo hi > notes.txt","Writes notes",2,files`,
+		`make,"Make","touch notes.txt","Create notes",,,,"test -f notes.txt","Use touch","touch notes.txt","Creates notes",1,files`,
+	)
+
+	report, err := NewTester(nil).Run(context.Background(), source, TestOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if report.Results[0].Key != "make" || report.Results[1].Key != "write" {
+		t.Errorf("expected prerequisites first, got %s then %s", report.Results[0].Key, report.Results[1].Key)
+	}
+
+	sb := &hostSandbox{}
+	report, err = NewTester(sb).Run(context.Background(), source, TestOptions{Card: "write", WithPrereqs: true})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	result := report.Results[0]
+	if !reflect.DeepEqual(result.Prereqs, []string{"make"}) {
+		t.Errorf("expected make to run first, got %v", result.Prereqs)
+	}
+	if result.Steps[0].Step != "make:"+StepSolution || sb.commands[0] != "touch notes.txt" {
+		t.Errorf("expected the prerequisite solution to run first, got %s", result.Steps[0].Step)
+	}
+
+	if _, err := NewTester(sb).Run(context.Background(), source, TestOptions{Card: "missing"}); err == nil {
+		t.Error("expected error for unknown card")
+	}
+}
+
+func TestTester_DryRunAndComprehensive(t *testing.T) {
+	source := openTestDeck(t, t.TempDir(),
+		`chmod,"Chmod","chmod +x run.sh","Make executable","touch run.sh","rm -f run.sh",,"test -x run.sh","Use chmod","chmod +x run.sh | chmod 755 run.sh","Adds x",2,permissions`,
+	)
+
+	report, err := NewTester(nil).Run(context.Background(), source, TestOptions{DryRun: true, Comprehensive: true})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if !report.DryRun || len(report.Results) != 2 {
+		t.Fatalf("expected a dry-run plan with 2 alternatives, got %d results", len(report.Results))
+	}
+	for _, step := range report.Results[0].Steps {
+		if !step.Skipped {
+			t.Errorf("expected step %s to be skipped in a dry run", step.Step)
+		}
+	}
+
+	report, err = NewTester(&hostSandbox{}).Run(context.Background(), source, TestOptions{Comprehensive: true})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if !report.OK() || report.Passed != 2 {
+		t.Errorf("expected both alternatives to pass, got %+v", report.Results)
+	}
+
+	if _, err := NewTester(nil).Run(context.Background(), source, TestOptions{}); err == nil {
+		t.Error("expected error when running without a sandbox")
+	}
+}
+
+func TestTester_MountsDeckAssets(t *testing.T) {
+	source := openTestDeck(t, t.TempDir(),
+		`list,"List","ls","List files",,,,"true","Use ls","ls","Lists files",1,files`,
+	)
+
+	sb := &hostSandbox{}
+	if _, err := NewTester(sb).Run(context.Background(), source, TestOptions{}); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(sb.mounts) != 0 {
+		t.Errorf("expected no mounts for a deck without assets, got %+v", sb.mounts)
+	}
+
+	createFile(t, filepath.Join(source.Dir, "assets", "config.json"), `{}`)
+	if _, err := NewTester(sb).Run(context.Background(), source, TestOptions{}); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(sb.mounts) != 1 || sb.mounts[0].Target != sandbox.AssetsPath || !sb.mounts[0].ReadOnly ||
+		!filepath.IsAbs(sb.mounts[0].Source) {
+		t.Errorf("expected the assets directory mounted read-only at /assets, got %+v", sb.mounts)
+	}
+}
+
+func TestSolutionAlternatives(t *testing.T) {
+	tests := []struct {
+		name     string
+		card     CardSpec
+		expected []string
+	}{
+		{
+			name:     "no solution falls back to command",
+			card:     CardSpec{Command: "ls -la"},
+			expected: []string{"ls -la"},
+		},
+		{
+			name:     "simple alternatives",
+			card:     CardSpec{Command: "chmod +x main.py", Solution: "chmod +x main.py | chmod 755 main.py | chmod u+x main.py"},
+			expected: []string{"chmod +x main.py", "chmod 755 main.py", "chmod u+x main.py"},
+		},
+		{
+			name:     "single pipeline",
+			card:     CardSpec{Command: "ls | grep x", Solution: "ls | grep x"},
+			expected: []string{"ls | grep x"},
+		},
+		{
+			name:     "pipeline alternatives",
+			card:     CardSpec{Command: "ls | grep x", Solution: "ls | grep x | ls -1 | grep -F x"},
+			expected: []string{"ls | grep x", "ls -1 | grep -F x"},
+		},
+		{
+			name:     "ambiguous split keeps the whole solution",
+			card:     CardSpec{Command: "ls | grep x", Solution: "ls | sort | head"},
+			expected: []string{"ls | sort | head"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SolutionAlternatives(tt.card); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package deck
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/justinlyon12/ancli/internal/storage"
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+)
+
+// UpgradeChanges is the change summary recorded in deck_versions for each upgrade
+type UpgradeChanges struct {
+	FromVersion string   `json:"from_version"`
+	ToVersion   string   `json:"to_version"`
+	Added       []string `json:"added,omitempty"`    // New card keys
+	Updated     []string `json:"updated,omitempty"`  // Content changed, progress kept
+	Reset       []string `json:"reset,omitempty"`    // Command changed, progress reset
+	Archived    []string `json:"archived,omitempty"` // Removed from the deck, history kept
+	Restored    []string `json:"restored,omitempty"` // Previously archived cards back in the deck
+	Unchanged   int      `json:"unchanged"`
+	Assets      int      `json:"assets"`
+}
+
+// UpgradeResult summarizes a planned or applied deck upgrade
+type UpgradeResult struct {
+	Deck       *storage.Deck
+	Changes    *UpgradeChanges
+	Validation *ValidationResult
+	DryRun     bool
+}
+
+// Upgrade brings an installed deck up to the version at deckPath
+// Cards are matched by card_key. Cards whose content changed are updated in place and
+// keep their FSRS state, unless their command changed, in which case they start over
+// as new cards. Cards missing from the new version are archived rather than deleted so
+// their review history survives. Assets are replaced wholesale. Everything is applied
+// in one transaction and summarized in deck_versions; with dryRun nothing is written.
+// Only a newer version can be installed over the current one.
+func Upgrade(db *storage.DB, deckPath string, dryRun bool) (*UpgradeResult, error) {
+	source, err := Open(deckPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open deck: %w", err)
+	}
+	defer source.Close()
+
+	if !source.Validation.Valid {
+		return nil, &InvalidDeckError{Result: source.Validation}
+	}
+	spec := source.Spec
+
+	assets, err := readAssets(source.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UpgradeResult{Validation: source.Validation, DryRun: dryRun}
+	err = db.WithTx(func(tx *storage.DB) error {
+		installed, err := tx.GetDeckByName(spec.Name)
+		if err != nil {
+			return fmt.Errorf("deck %q is not installed: %w", spec.Name, err)
+		}
+		newer, err := compareVersions(spec.Version, installed.Version)
+		if err != nil {
+			return err
+		}
+		if newer == 0 {
+			return fmt.Errorf("deck %q is already at version %s", spec.Name, spec.Version)
+		}
+		if newer < 0 {
+			return fmt.Errorf("deck %q version %s is older than the installed %s", spec.Name, spec.Version, installed.Version)
+		}
+
+		existing, err := tx.GetCardsByDeckWithArchived(installed.ID)
+		if err != nil {
+			return err
+		}
+		byKey := make(map[string]*storage.Card, len(existing))
+		for _, card := range existing {
+			byKey[card.CardKey] = card
+		}
+
+		changes := &UpgradeChanges{
+			FromVersion: installed.Version,
+			ToVersion:   spec.Version,
+			Assets:      len(assets),
+		}
+
+		var creates, updates []*storage.Card
+		seen := make(map[string]bool, len(source.Cards))
+		for _, cardSpec := range source.Cards {
+			seen[cardSpec.Key] = true
+
+			card, err := cardSpec.toStorageCard(spec, installed.ID)
+			if err != nil {
+				return err
+			}
+
+			current, exists := byKey[cardSpec.Key]
+			if !exists {
+				changes.Added = append(changes.Added, cardSpec.Key)
+				creates = append(creates, card)
+				continue
+			}
+
+			switch {
+			case current.Archived:
+				changes.Restored = append(changes.Restored, cardSpec.Key)
+			case current.Command != card.Command:
+				changes.Reset = append(changes.Reset, cardSpec.Key)
+			case cardContentChanged(current, card):
+				changes.Updated = append(changes.Updated, cardSpec.Key)
+			default:
+				changes.Unchanged++
+				continue
+			}
+
+			updates = append(updates, mergeCardContent(current, card, current.Command != card.Command))
+		}
+
+		for _, card := range existing {
+			if !seen[card.CardKey] && !card.Archived {
+				changes.Archived = append(changes.Archived, card.CardKey)
+				archived := *card
+				archived.Archived = true
+				updates = append(updates, &archived)
+			}
+		}
+		sort.Strings(changes.Archived)
+
+		deck, err := spec.toStorageDeck()
+		if err != nil {
+			return err
+		}
+		deck.ID = installed.ID
+		deck.CreatedAt = installed.CreatedAt
+
+		result.Deck = deck
+		result.Changes = changes
+		if dryRun {
+			return nil
+		}
+
+		if err := tx.UpdateDeck(deck); err != nil {
+			return err
+		}
+		for _, card := range creates {
+			if err := tx.CreateCard(card); err != nil {
+				return fmt.Errorf("card %q: %w", card.CardKey, err)
+			}
+		}
+		for _, card := range updates {
+			if err := tx.UpdateCard(card); err != nil {
+				return fmt.Errorf("card %q: %w", card.CardKey, err)
+			}
+		}
+
+		if err := tx.DeleteDeckAssets(installed.ID); err != nil {
+			return err
+		}
+		for _, asset := range assets {
+			asset.DeckID = installed.ID
+			if err := tx.StoreAsset(asset); err != nil {
+				return fmt.Errorf("asset %q: %w", asset.Filename, err)
+			}
+		}
+
+		summary, err := json.Marshal(changes)
+		if err != nil {
+			return fmt.Errorf("failed to encode change summary: %w", err)
+		}
+		return tx.CreateDeckVersion(&storage.DeckVersion{
+			DeckID:  installed.ID,
+			Version: spec.Version,
+			Changes: string(summary),
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upgrade deck: %w", err)
+	}
+
+	return result, nil
+}
+
+// compareVersions compares two dotted version numbers such as 1.2.0
+// It returns a negative number when a is older than b, zero when they are the same
+// version, and a positive number when a is newer. Missing parts count as zero, so
+// 1.2 and 1.2.0 are the same version.
+func compareVersions(a, b string) (int, error) {
+	aParts, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	bParts, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < max(len(aParts), len(bParts)); i++ {
+		var aPart, bPart int
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+		if aPart != bPart {
+			return aPart - bPart, nil
+		}
+	}
+	return 0, nil
+}
+
+// parseVersion splits a dotted version number into its numeric parts
+func parseVersion(version string) ([]int, error) {
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		part, err := strconv.Atoi(field)
+		if err != nil || part < 0 {
+			return nil, fmt.Errorf("version %q is not a dotted version number like 1.2.0", version)
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// cardContentChanged reports whether any author-controlled field differs
+func cardContentChanged(current, next *storage.Card) bool {
+	return current.Title != next.Title ||
+		current.Description != next.Description ||
+		current.Command != next.Command ||
+		current.WorkingDir != next.WorkingDir ||
+		current.EnvironmentVars != next.EnvironmentVars ||
+		current.DifficultyLevel != next.DifficultyLevel ||
+		current.Tags != next.Tags ||
+		current.Prerequisites != next.Prerequisites ||
+		current.PrerequisiteMode != next.PrerequisiteMode ||
+		current.Setup != next.Setup ||
+		current.Cleanup != next.Cleanup ||
+		current.Verify != next.Verify ||
+		current.Hint != next.Hint ||
+		current.Solution != next.Solution ||
+		current.Explanation != next.Explanation
+}
+
+// mergeCardContent copies the new content onto the installed card
+// The installed card's identity and suspension are kept, and so is its FSRS state
+// unless resetProgress is set, in which case the card is rescheduled as new.
+func mergeCardContent(current, next *storage.Card, resetProgress bool) *storage.Card {
+	merged := *next
+	merged.ID = current.ID
+	merged.DeckID = current.DeckID
+	merged.CreatedAt = current.CreatedAt
+	merged.Archived = false
+	merged.Suspended = current.Suspended
+	merged.BuriedUntil = current.BuriedUntil
+
+	if resetProgress {
+		merged.UpdateFromFSRSCard(fsrs.NewCard())
+		merged.FSRSLastReview = nil
+		return &merged
+	}
+
+	merged.FSRSDue = current.FSRSDue
+	merged.FSRSStability = current.FSRSStability
+	merged.FSRSDifficulty = current.FSRSDifficulty
+	merged.FSRSElapsedDays = current.FSRSElapsedDays
+	merged.FSRSScheduledDays = current.FSRSScheduledDays
+	merged.FSRSReps = current.FSRSReps
+	merged.FSRSLapses = current.FSRSLapses
+	merged.FSRSState = current.FSRSState
+	merged.FSRSLastReview = current.FSRSLastReview
+	return &merged
+}
@@ -0,0 +1,306 @@
+package deck
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/justinlyon12/ancli/internal/storage"
+)
+
+// writeUpgradedDeck writes version 1.3.0 of the install-test deck:
+// basic gets a new title, second is removed, third is added, and the asset changes
+func writeUpgradedDeck(t *testing.T, dir string) {
+	t.Helper()
+
+	createFile(t, filepath.Join(dir, "deck.yaml"), `
+name: install-test
+version: 1.3.0
+author: Test Author
+description: A deck used by install tests, now with more cards
+container:
+  image: alpine:3.19
+  working_dir: /workspace
+settings:
+  prerequisite_mode: enforce
+`)
+	createFile(t, filepath.Join(dir, "cards.csv"), `key,title,command,description,setup,cleanup,prerequisites,verify,hint,solution,explanation,difficulty,tags
+basic,"Basic Output","echo hello","Print hello",,,,,"Use echo","echo hello","Prints hello",1,"basics, output"
+third,"Third","echo again","Print again",,,basic,,"Use echo","echo again","Prints again",2,"basics"
+`)
+	createFile(t, filepath.Join(dir, "assets", "notes.txt"), "new asset\n")
+}
+
+func installWithProgress(t *testing.T, db *storage.DB) *storage.Deck {
+	t.Helper()
+
+	deckDir := t.TempDir()
+	createInstallableDeck(t, deckDir)
+	result, err := Install(db, deckDir)
+	if err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+
+	cards, err := db.GetCardsByDeck(result.Deck.ID)
+	if err != nil {
+		t.Fatalf("GetCardsByDeck returned error: %v", err)
+	}
+	for _, card := range cards {
+		card.FSRSReps = 5
+		card.FSRSStability = 12.5
+		if err := db.UpdateCardFSRS(card); err != nil {
+			t.Fatalf("UpdateCardFSRS returned error: %v", err)
+		}
+	}
+
+	return result.Deck
+}
+
+func cardsByKey(t *testing.T, db *storage.DB, deckID int) map[string]*storage.Card {
+	t.Helper()
+
+	cards, err := db.GetCardsByDeckWithArchived(deckID)
+	if err != nil {
+		t.Fatalf("GetCardsByDeckWithArchived returned error: %v", err)
+	}
+	byKey := make(map[string]*storage.Card, len(cards))
+	for _, card := range cards {
+		byKey[card.CardKey] = card
+	}
+	return byKey
+}
+
+// setDeckVersion rewrites the version in a deck directory's deck.yaml
+func setDeckVersion(t *testing.T, dir, version string) {
+	t.Helper()
+
+	path := filepath.Join(dir, "deck.yaml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	data = regexp.MustCompile(`(?m)^version: .*$`).ReplaceAll(data, []byte("version: "+version))
+	createFile(t, path, string(data))
+}
+
+func TestUpgrade_KeepsProgressAndArchivesRemovedCards(t *testing.T) {
+	db := setupInstallDB(t)
+	installed := installWithProgress(t, db)
+
+	before := cardsByKey(t, db, installed.ID)
+	if err := db.CreateReview(&storage.Review{
+		CardID: before["second"].ID, Rating: 3, ExecutionSuccess: true, Attempts: 1,
+		FSRSDueBefore: before["second"].FSRSDue, FSRSDueAfter: before["second"].FSRSDue,
+	}); err != nil {
+		t.Fatalf("CreateReview returned error: %v", err)
+	}
+	before["basic"].Suspended = true
+	if err := db.UpdateCard(before["basic"]); err != nil {
+		t.Fatalf("UpdateCard returned error: %v", err)
+	}
+
+	deckDir := t.TempDir()
+	writeUpgradedDeck(t, deckDir)
+
+	result, err := Upgrade(db, deckDir, false)
+	if err != nil {
+		t.Fatalf("Upgrade returned error: %v", err)
+	}
+
+	changes := result.Changes
+	if changes.FromVersion != "1.2.0" || changes.ToVersion != "1.3.0" {
+		t.Errorf("unexpected versions: %s -> %s", changes.FromVersion, changes.ToVersion)
+	}
+	if len(changes.Updated) != 1 || changes.Updated[0] != "basic" {
+		t.Errorf("expected basic to be updated, got %v", changes.Updated)
+	}
+	if len(changes.Added) != 1 || changes.Added[0] != "third" {
+		t.Errorf("expected third to be added, got %v", changes.Added)
+	}
+	if len(changes.Archived) != 1 || changes.Archived[0] != "second" {
+		t.Errorf("expected second to be archived, got %v", changes.Archived)
+	}
+
+	after := cardsByKey(t, db, installed.ID)
+	basic := after["basic"]
+	if basic.ID != before["basic"].ID || basic.Title != "Basic Output" {
+		t.Errorf("expected basic to be updated in place, got ID %d title %q", basic.ID, basic.Title)
+	}
+	if basic.FSRSReps != 5 || basic.FSRSStability != 12.5 {
+		t.Errorf("expected basic to keep its FSRS state, got reps=%d stability=%v", basic.FSRSReps, basic.FSRSStability)
+	}
+	if !basic.Suspended {
+		t.Error("expected basic to stay suspended")
+	}
+
+	second := after["second"]
+	if second == nil || !second.Archived || second.FSRSReps != 5 {
+		t.Errorf("expected second to be archived with its progress, got %+v", second)
+	}
+	if after["third"] == nil || after["third"].FSRSReps != 0 {
+		t.Errorf("expected third to be added as a new card, got %+v", after["third"])
+	}
+
+	active, err := db.GetCardsByDeck(installed.ID)
+	if err != nil {
+		t.Fatalf("GetCardsByDeck returned error: %v", err)
+	}
+	if len(active) != 2 {
+		t.Errorf("expected 2 active cards after upgrade, got %d", len(active))
+	}
+
+	deck, err := db.GetDeck(installed.ID)
+	if err != nil {
+		t.Fatalf("GetDeck returned error: %v", err)
+	}
+	if deck.Version != "1.3.0" || deck.DefaultImage != "alpine:3.19" {
+		t.Errorf("expected deck metadata to be updated, got version %s image %s", deck.Version, deck.DefaultImage)
+	}
+
+	assets, err := db.ListDeckAssets(installed.ID)
+	if err != nil {
+		t.Fatalf("ListDeckAssets returned error: %v", err)
+	}
+	if len(assets) != 1 || assets[0].Filename != "notes.txt" {
+		t.Errorf("expected assets to be replaced, got %d assets", len(assets))
+	}
+
+	versions, err := db.ListDeckVersions(installed.ID)
+	if err != nil {
+		t.Fatalf("ListDeckVersions returned error: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Version != "1.3.0" {
+		t.Fatalf("expected one recorded version, got %+v", versions)
+	}
+	var recorded UpgradeChanges
+	if err := json.Unmarshal([]byte(versions[0].Changes), &recorded); err != nil {
+		t.Fatalf("change summary is not valid JSON: %v", err)
+	}
+	if recorded.FromVersion != "1.2.0" || len(recorded.Archived) != 1 {
+		t.Errorf("unexpected recorded changes: %+v", recorded)
+	}
+}
+
+func TestUpgrade_CommandChangeResetsProgress(t *testing.T) {
+	db := setupInstallDB(t)
+	installed := installWithProgress(t, db)
+
+	deckDir := t.TempDir()
+	createInstallableDeck(t, deckDir)
+	createFile(t, filepath.Join(deckDir, "deck.yaml"), `
+name: install-test
+version: 2.0.0
+author: Test Author
+description: A deck used by install tests
+container:
+  working_dir: /workspace
+  environment:
+    USER: student
+settings:
+  prerequisite_mode: enforce
+`)
+	createFile(t, filepath.Join(deckDir, "cards.csv"), `key,title,command,description,setup,cleanup,prerequisites,verify,hint,solution,explanation,difficulty,tags
+basic,"Basic","echo hello","Print hello",,,,,"Use echo","echo hello","Prints hello",1,"basics, output"
+second,"Second","printf world","Print world",,,basic,,"Use printf","printf world","Prints world",2,"basics"
+`)
+
+	result, err := Upgrade(db, deckDir, false)
+	if err != nil {
+		t.Fatalf("Upgrade returned error: %v", err)
+	}
+	if result.Changes.Unchanged != 1 || len(result.Changes.Reset) != 1 {
+		t.Errorf("expected 1 unchanged and 1 reset card, got %+v", result.Changes)
+	}
+
+	after := cardsByKey(t, db, installed.ID)
+	if after["basic"].FSRSReps != 5 {
+		t.Errorf("expected unchanged card to keep progress, got reps=%d", after["basic"].FSRSReps)
+	}
+	if after["second"].FSRSReps != 0 || after["second"].FSRSLastReview != nil {
+		t.Errorf("expected changed command to reset progress, got reps=%d", after["second"].FSRSReps)
+	}
+}
+
+func TestUpgrade_DryRunWritesNothing(t *testing.T) {
+	db := setupInstallDB(t)
+	installed := installWithProgress(t, db)
+
+	deckDir := t.TempDir()
+	writeUpgradedDeck(t, deckDir)
+
+	result, err := Upgrade(db, deckDir, true)
+	if err != nil {
+		t.Fatalf("Upgrade returned error: %v", err)
+	}
+	if !result.DryRun || len(result.Changes.Archived) != 1 {
+		t.Errorf("expected a dry-run plan archiving one card, got %+v", result.Changes)
+	}
+
+	deck, err := db.GetDeck(installed.ID)
+	if err != nil {
+		t.Fatalf("GetDeck returned error: %v", err)
+	}
+	if deck.Version != "1.2.0" {
+		t.Errorf("expected dry run to leave version at 1.2.0, got %s", deck.Version)
+	}
+	if cards := cardsByKey(t, db, installed.ID); len(cards) != 2 || cards["second"].Archived {
+		t.Error("expected dry run to leave cards untouched")
+	}
+	if versions, _ := db.ListDeckVersions(installed.ID); len(versions) != 0 {
+		t.Errorf("expected no recorded versions, got %d", len(versions))
+	}
+}
+
+func TestUpgrade_RejectsOlderVersions(t *testing.T) {
+	db := setupInstallDB(t)
+
+	deckDir := t.TempDir()
+	createInstallableDeck(t, deckDir)
+	installed, err := Install(db, deckDir)
+	if err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+
+	// 1.10.0 is newer than 1.9.0, though it sorts before it as a string
+	for _, version := range []string{"1.9.0", "1.10.0"} {
+		setDeckVersion(t, deckDir, version)
+		if _, err := Upgrade(db, deckDir, false); err != nil {
+			t.Fatalf("Upgrade to %s returned error: %v", version, err)
+		}
+	}
+
+	for _, version := range []string{"1.9.0", "1.10", "1.x"} {
+		setDeckVersion(t, deckDir, version)
+		if _, err := Upgrade(db, deckDir, true); err == nil {
+			t.Errorf("expected upgrading 1.10.0 to %s to be refused", version)
+		}
+	}
+
+	deck, err := db.GetDeck(installed.Deck.ID)
+	if err != nil {
+		t.Fatalf("GetDeck returned error: %v", err)
+	}
+	if deck.Version != "1.10.0" {
+		t.Errorf("expected the deck to stay at 1.10.0, got %s", deck.Version)
+	}
+}
+
+func TestUpgrade_RejectsSameVersionAndMissingDeck(t *testing.T) {
+	db := setupInstallDB(t)
+
+	deckDir := t.TempDir()
+	createInstallableDeck(t, deckDir)
+
+	if _, err := Upgrade(db, deckDir, false); err == nil {
+		t.Error("expected error when upgrading a deck that is not installed")
+	}
+
+	if _, err := Install(db, deckDir); err != nil {
+		t.Fatalf("Install returned error: %v", err)
+	}
+	if _, err := Upgrade(db, deckDir, false); err == nil {
+		t.Error("expected error when upgrading to the installed version")
+	}
+}
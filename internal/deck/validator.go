@@ -10,6 +10,8 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/justinlyon12/ancli/internal/sandbox"
 )
 
 // ValidationResult contains the outcome of deck validation
@@ -55,6 +57,7 @@ const (
 	STRUCT001 = "STRUCT001" // Missing required file
 	STRUCT002 = "STRUCT002" // Invalid file format
 	STRUCT003 = "STRUCT003" // File encoding error
+	STRUCT004 = "STRUCT004" // Archive manifest mismatch
 
 	// Deck Errors (DECK)
 	DECK001 = "DECK001" // Missing required field
@@ -70,6 +73,7 @@ const (
 	CARD004 = "CARD004" // Circular dependency detected
 	CARD005 = "CARD005" // Command syntax error
 	CARD006 = "CARD006" // Setup without cleanup
+	CARD007 = "CARD007" // Bash-only syntax with a non-bash shell
 
 	// Security Warnings (SEC)
 	SEC001 = "SEC001" // Network enabled globally
@@ -106,6 +110,7 @@ type DeckSpec struct {
 		Network     bool              `yaml:"network"`
 		Environment map[string]string `yaml:"environment"`
 		WorkingDir  string            `yaml:"working_dir"`
+		Shell       string            `yaml:"shell"` // Runs card commands, e.g. "/bin/sh -c" (the default) or "bash"
 	} `yaml:"container"`
 
 	Cleanup struct {
@@ -123,9 +128,11 @@ type DeckSpec struct {
 	Settings struct {
 		ShuffleCards     bool   `yaml:"shuffle_cards"`
 		PrerequisiteMode string `yaml:"prerequisite_mode"`
-		ShowSolutions    bool   `yaml:"show_solutions"`
-		ShowExplanations bool   `yaml:"show_explanations"`
+		ShowSolutions    *bool  `yaml:"show_solutions"`    // Unset means solutions may be shown
+		ShowExplanations *bool  `yaml:"show_explanations"` // Unset means explanations may be shown
 		AutoCleanup      bool   `yaml:"auto_cleanup"`
+		NewCardsPerDay   *int   `yaml:"new_cards_per_day"` // Unset uses the configured limit, 0 = unlimited
+		ReviewsPerDay    *int   `yaml:"reviews_per_day"`   // Unset uses the configured limit, 0 = unlimited
 	} `yaml:"settings"`
 }
 
@@ -146,36 +153,50 @@ type CardSpec struct {
 	Tags          string
 }
 
-// ValidateDeck performs comprehensive validation of a deck directory
+// ValidateDeck performs comprehensive validation of a deck directory or .ancli archive
 func ValidateDeck(deckPath string) (*ValidationResult, error) {
-	result := &ValidationResult{
+	source, err := Open(deckPath)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	return source.Validation, nil
+}
+
+// newValidationResult returns an empty, passing result
+func newValidationResult() *ValidationResult {
+	return &ValidationResult{
 		Valid:    true,
 		Errors:   []ValidationError{},
 		Warnings: []ValidationWarning{},
 		Info:     []ValidationInfo{},
 	}
+}
 
+// validateDeck runs every validation phase on a deck directory and returns the parsed specs
+func validateDeck(deckPath string, result *ValidationResult) (*DeckSpec, []CardSpec, error) {
 	// Phase 1: Structure validation
 	if err := validateStructure(deckPath, result); err != nil {
-		return result, err
+		return nil, nil, err
 	}
 
 	// Stop if structure validation failed
 	if len(result.Errors) > 0 {
 		result.Valid = false
-		return result, nil
+		return nil, nil, nil
 	}
 
 	// Phase 2: Parse and validate deck.yaml
 	deckSpec, err := parseDeckYAML(deckPath, result)
 	if err != nil {
-		return result, err
+		return nil, nil, err
 	}
 
 	// Phase 3: Parse and validate cards.csv
 	cards, err := parseCardsCSV(deckPath, result)
 	if err != nil {
-		return result, err
+		return nil, nil, err
 	}
 
 	// Phase 4: Cross-validation between deck and cards
@@ -193,7 +214,7 @@ func ValidateDeck(deckPath string) (*ValidationResult, error) {
 	// Set final validation result
 	result.Valid = len(result.Errors) == 0
 
-	return result, nil
+	return deckSpec, cards, nil
 }
 
 // validateStructure checks that required files exist and are readable
@@ -713,6 +734,11 @@ func validateDeckCardConsistency(spec *DeckSpec, cards []CardSpec, result *Valid
 		return
 	}
 
+	// Bash syntax fails in the POSIX sh most images default to
+	if !sandbox.IsBash(spec.Container.Shell) {
+		validateShellSyntax(spec, cards, result)
+	}
+
 	// Check if difficulty range in deck matches actual cards
 	if len(spec.DifficultyRange) == 2 {
 		minDifficulty, maxDifficulty := spec.DifficultyRange[0], spec.DifficultyRange[1]
@@ -732,6 +758,96 @@ func validateDeckCardConsistency(spec *DeckSpec, cards []CardSpec, result *Valid
 	}
 }
 
+// bashOnlySyntax lists bash constructs that POSIX sh does not support
+var bashOnlySyntax = []struct {
+	pattern *regexp.Regexp
+	name    string
+}{
+	{regexp.MustCompile(`\[\[`), "[[ ]] tests"},
+	{regexp.MustCompile(`[<>]\(`), "process substitution"},
+	{regexp.MustCompile(`&>|\|&`), "&> or |& redirection"},
+	{regexp.MustCompile("(?:^|[\\s;&|(`])\\$'"), "$'...' quoting"},
+	{regexp.MustCompile(`(?:^|[^$])\{[^{}\s]*,[^{}\s]*\}`), "brace expansion"},
+	{regexp.MustCompile(`\{[a-zA-Z0-9]+\.\.[a-zA-Z0-9]+\}`), "sequence expressions"},
+	{regexp.MustCompile(`\$\{[A-Za-z_]\w*//?`), "${var/pattern/replacement}"},
+	{regexp.MustCompile(`(?:^|[;&|\s])source\s`), "source"},
+	{regexp.MustCompile(`\bfunction\s+\w+`), "the function keyword"},
+	{regexp.MustCompile(`\b[A-Za-z_]\w*=\(`), "arrays"},
+}
+
+// validateShellSyntax warns about cards using bash-only syntax when the deck's shell
+// is not bash
+func validateShellSyntax(spec *DeckSpec, cards []CardSpec, result *ValidationResult) {
+	shell := spec.Container.Shell
+	if shell == "" {
+		shell = sandbox.DefaultShell
+	}
+
+	for _, card := range cards {
+		fields := []struct{ name, script string }{
+			{"command", card.Command},
+			{"setup", card.Setup},
+			{"verify", card.Verify},
+			{"cleanup", card.Cleanup},
+		}
+		if card.Solution != "" {
+			for _, solution := range SolutionAlternatives(card) {
+				fields = append(fields, struct{ name, script string }{"solution", solution})
+			}
+		}
+
+		for _, field := range fields {
+			script := blankSingleQuotes(field.script)
+			for _, syntax := range bashOnlySyntax {
+				if !syntax.pattern.MatchString(script) {
+					continue
+				}
+				result.Warnings = append(result.Warnings, ValidationWarning{
+					Level: "warning",
+					File:  "cards.csv",
+					Code:  CARD007,
+					Message: fmt.Sprintf("Card '%s' %s uses bash-only syntax (%s) but the deck's shell is %s",
+						card.Key, field.name, syntax.name, shell),
+					Details: "Set container.shell to bash in deck.yaml, or rewrite the command for POSIX sh",
+				})
+				break
+			}
+		}
+	}
+}
+
+// blankSingleQuotes empties single-quoted strings, which the shell passes through
+// literally, so regexes and jq filters in them are not mistaken for bash syntax.
+// ANSI-C strings keep their leading $' so that quoting is still reported.
+func blankSingleQuotes(script string) string {
+	var b strings.Builder
+	inDouble := false
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\\' && i+1 < len(script):
+			b.WriteString(script[i : i+2])
+			i++
+		case c == '"':
+			inDouble = !inDouble
+			b.WriteByte(c)
+		case c == '\'' && !inDouble:
+			ansiC := i > 0 && script[i-1] == '$'
+			j := i + 1
+			for ; j < len(script) && script[j] != '\''; j++ {
+				if ansiC && script[j] == '\\' {
+					j++
+				}
+			}
+			b.WriteString("''")
+			i = j
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
 // Helper functions
 
 func getColumn(slice []string, index int) string {
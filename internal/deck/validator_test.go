@@ -3,6 +3,7 @@ package deck
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -247,6 +248,61 @@ func TestValidateCardFields_BasicFunctionality(t *testing.T) {
 	}
 }
 
+func TestValidateShellSyntax(t *testing.T) {
+	cards := []CardSpec{
+		{Key: "posix", Command: "ls | grep main.py", Setup: "mkdir -p my_project && cd my_project"},
+		{Key: "brace", Command: "touch file{1,2}.txt", Solution: "touch file1.txt file2.txt"},
+		{Key: "test", Command: "true", Verify: "[[ -f file1.txt ]]"},
+		{Key: "awk", Command: "awk '{print $1,$2}' data.txt"},
+	}
+
+	spec := &DeckSpec{}
+	result := newValidationResult()
+	validateDeckCardConsistency(spec, cards, result)
+
+	var flagged []string
+	for _, warning := range result.Warnings {
+		if warning.Code == CARD007 {
+			flagged = append(flagged, warning.Message)
+		}
+	}
+	if len(flagged) != 2 || !strings.Contains(flagged[0], "'brace' command uses bash-only syntax (brace expansion)") ||
+		!strings.Contains(flagged[1], "'test' verify uses bash-only syntax ([[ ]] tests)") {
+		t.Errorf("expected the brace and test cards flagged, got %q", flagged)
+	}
+
+	// Quoted regexes and jq filters are passed through literally, so they are not
+	// bash syntax, while $'...' at the start of a word still is
+	quoted := []CardSpec{
+		{Key: "regex", Command: `ls | grep '\.py$'`},
+		{Key: "jq", Command: `jq '{name,version}' package.json`},
+		{Key: "escaped", Command: `echo it\'s {a,b}`},
+		{Key: "ansi", Command: `printf '%s' $'a\tb'`},
+	}
+	result = newValidationResult()
+	validateDeckCardConsistency(spec, quoted, result)
+	flagged = nil
+	for _, warning := range result.Warnings {
+		if warning.Code == CARD007 {
+			flagged = append(flagged, warning.Message)
+		}
+	}
+	if len(flagged) != 2 || !strings.Contains(flagged[0], "'escaped' command uses bash-only syntax (brace expansion)") ||
+		!strings.Contains(flagged[1], "'ansi' command uses bash-only syntax ($'...' quoting)") {
+		t.Errorf("expected only the escaped and ansi cards flagged, got %q", flagged)
+	}
+
+	// A bash deck can use bash syntax
+	spec.Container.Shell = "bash"
+	result = newValidationResult()
+	validateDeckCardConsistency(spec, cards, result)
+	for _, warning := range result.Warnings {
+		if warning.Code == CARD007 {
+			t.Errorf("expected no bash syntax warnings with a bash shell, got %q", warning.Message)
+		}
+	}
+}
+
 // Helper function to create test files
 func createFile(t *testing.T, path, content string) {
 	t.Helper()
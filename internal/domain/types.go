@@ -87,6 +87,12 @@ type ExecutionResult struct {
 	ContainerID    string        `json:"container_id"`
 	ImageUsed      string        `json:"image_used"`
 	NetworkEnabled bool          `json:"network_enabled"`
+	Attempts       int           `json:"attempts"`      // Commands run before rating, including retries
+	HelpAccessed   bool          `json:"help_accessed"` // Hint or solution was shown before rating
+
+	// Objective outcome of the attempt
+	AttemptPassed   *bool  `json:"attempt_passed,omitempty"`   // Nil when the attempt was not judged
+	SuggestedRating Rating `json:"suggested_rating,omitempty"` // Zero when no rating was suggested
 }
 
 // InvalidRatingError indicates an invalid rating input
@@ -0,0 +1,52 @@
+package review
+
+import (
+	"sort"
+	"time"
+
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+)
+
+// learningCard is a card rated into a learning step that is due again this session
+type learningCard struct {
+	id  int
+	due time.Time
+}
+
+// dueInSession reports whether a just-reviewed card should come back in the session:
+// it is in a learning or relearning step that is due again before the day ends
+func dueInSession(card fsrs.Card, now time.Time) bool {
+	state := card.State
+	return (state == fsrs.Learning || state == fsrs.Relearning) && card.Due.Before(startOfNextDay(now))
+}
+
+// addLearning queues a card to come back when its learning step is due
+// Cards due at the same time keep the order they were rated in.
+func (st *sessionState) addLearning(cardID int, due time.Time) {
+	i := sort.Search(len(st.learning), func(i int) bool { return st.learning[i].due.After(due) })
+	st.learning = append(st.learning, learningCard{})
+	copy(st.learning[i+1:], st.learning[i:])
+	st.learning[i] = learningCard{id: cardID, due: due}
+}
+
+// nextCardID picks the card to show next
+// A learning card whose step is due comes first, then the queue in order. Once the
+// queue is empty the session doesn't end while learning cards remain: the next one
+// is shown early rather than waiting out its step.
+func (st *sessionState) nextCardID(now time.Time) (int, bool) {
+	if len(st.learning) > 0 && !st.learning[0].due.After(now) {
+		return st.learning[0].id, true
+	}
+	if len(st.cardQueue) > 0 {
+		return st.cardQueue[0], true
+	}
+	if len(st.learning) > 0 {
+		return st.learning[0].id, true
+	}
+	return 0, false
+}
+
+// remaining counts the cards still to be shown in the session
+func (st *sessionState) remaining() int {
+	return len(st.cardQueue) + len(st.learning)
+}
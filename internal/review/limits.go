@@ -0,0 +1,176 @@
+package review
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+
+	"github.com/justinlyon12/ancli/internal/storage"
+)
+
+// DailyLimits caps how many new cards each deck introduces and how many due reviews
+// it shows per local day
+// Decks can override either limit in their settings. Zero means unlimited.
+type DailyLimits struct {
+	NewCards int `json:"new_cards"`
+	Reviews  int `json:"reviews"`
+}
+
+// DailyAllowance is what is left of the daily limits, summed over a session's decks
+// Nil means at least one deck is unlimited.
+type DailyAllowance struct {
+	NewCards *int `json:"new_cards"`
+	Reviews  *int `json:"reviews"`
+}
+
+// SetDailyLimits sets the limits used for decks that don't set their own
+func (s *Service) SetDailyLimits(limits DailyLimits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = limits
+}
+
+// deckAllowance is one deck's remaining allowance for today, -1 when unlimited
+type deckAllowance struct {
+	newCards int
+	reviews  int
+}
+
+// applyDailyLimits drops new cards and due reviews beyond each deck's remaining
+// allowance for today
+// queue must already be in session order. Due reviews are admitted most overdue
+// first; new cards are admitted in queue order, so a prerequisite queued ahead of the
+// card that needs it is kept first. Cards in learning or relearning steps are never
+// limited. The allowance summed over the cards' decks is returned for reporting.
+func (s *Service) applyDailyLimits(queue []*storage.Card, now time.Time) ([]*storage.Card, *DailyAllowance, error) {
+	allowances, total, err := s.dailyAllowances(queue, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reviews []*storage.Card
+	for _, card := range queue {
+		if card.FSRSReps > 0 && card.FSRSState == int(fsrs.Review) {
+			reviews = append(reviews, card)
+		}
+	}
+
+	sort.SliceStable(reviews, func(i, j int) bool { return reviews[i].FSRSDue.Before(reviews[j].FSRSDue) })
+	admitted := make(map[int]bool, len(reviews))
+	for _, card := range reviews {
+		if take(&allowances[card.DeckID].reviews) {
+			admitted[card.ID] = true
+		}
+	}
+
+	limited := make([]*storage.Card, 0, len(queue))
+	for _, card := range queue {
+		switch {
+		case card.FSRSReps == 0:
+			if !take(&allowances[card.DeckID].newCards) {
+				continue
+			}
+		case card.FSRSState == int(fsrs.Review):
+			if !admitted[card.ID] {
+				continue
+			}
+		}
+		limited = append(limited, card)
+	}
+
+	return limited, total, nil
+}
+
+// dailyAllowances resolves today's remaining allowance for each of the cards' decks
+// and their sum
+func (s *Service) dailyAllowances(cards []*storage.Card, now time.Time) (map[int]*deckAllowance, *DailyAllowance, error) {
+	counts, err := s.storage.CountReviewsSince(startOfDay(now))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allowances := make(map[int]*deckAllowance)
+	total := &DailyAllowance{NewCards: new(int), Reviews: new(int)}
+	for _, card := range cards {
+		if _, ok := allowances[card.DeckID]; ok {
+			continue
+		}
+		allowance, err := s.deckAllowance(card.DeckID, counts[card.DeckID])
+		if err != nil {
+			return nil, nil, err
+		}
+		allowances[card.DeckID] = allowance
+		total.add(allowance)
+	}
+
+	return allowances, total, nil
+}
+
+// take uses up one card of a remaining allowance, reporting false when none is left
+func take(remaining *int) bool {
+	switch {
+	case *remaining < 0:
+		return true
+	case *remaining == 0:
+		return false
+	}
+	*remaining--
+	return true
+}
+
+// deckAllowance resolves a deck's limits and subtracts what it has shown today
+func (s *Service) deckAllowance(deckID int, done storage.ReviewCounts) (*deckAllowance, error) {
+	deck, err := s.storage.GetDeck(deckID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deck %d: %w", deckID, err)
+	}
+	settings, err := deck.ParseSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	limits := s.limits
+	s.mu.Unlock()
+	if settings.NewCardsPerDay != nil {
+		limits.NewCards = *settings.NewCardsPerDay
+	}
+	if settings.ReviewsPerDay != nil {
+		limits.Reviews = *settings.ReviewsPerDay
+	}
+
+	return &deckAllowance{
+		newCards: remainingToday(limits.NewCards, done.NewCards),
+		reviews:  remainingToday(limits.Reviews, done.Reviews),
+	}, nil
+}
+
+// remainingToday returns what is left of a daily limit, -1 when it is unlimited
+func remainingToday(limit, done int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return max(limit-done, 0)
+}
+
+// add sums a deck's remaining allowance into the total
+func (a *DailyAllowance) add(deck *deckAllowance) {
+	if deck.newCards < 0 {
+		a.NewCards = nil
+	} else if a.NewCards != nil {
+		*a.NewCards += deck.newCards
+	}
+	if deck.reviews < 0 {
+		a.Reviews = nil
+	} else if a.Reviews != nil {
+		*a.Reviews += deck.reviews
+	}
+}
+
+// startOfDay returns midnight at the start of now's day, in now's location
+func startOfDay(now time.Time) time.Time {
+	year, month, day := now.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+}
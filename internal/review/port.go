@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/justinlyon12/ancli/internal/domain"
+	"github.com/justinlyon12/ancli/internal/sandbox"
 )
 
 // ReviewService defines the interface for managing review sessions
@@ -12,35 +13,76 @@ type ReviewService interface {
 	// StartSession begins a new review session
 	StartSession(ctx context.Context, opts SessionOptions) (*Session, error)
 
-	// GetNextCard retrieves the next card due for review in the session
+	// SessionStatus returns a snapshot of the session's progress
+	SessionStatus(ctx context.Context, sessionID string) (*Session, error)
+
+	// GetNextCard retrieves the next card due for review in the session, or ErrQueueEmpty
 	GetNextCard(ctx context.Context, sessionID string) (*ReviewCard, error)
 
+	// AttemptCard runs the learner's typed command for the current card and judges it
+	AttemptCard(ctx context.Context, sessionID string, card *ReviewCard, command string) (*Attempt, error)
+
+	// ShellAttempt attaches the learner's terminal to a shell in the current card's container
+	ShellAttempt(ctx context.Context, sessionID string, card *ReviewCard, term sandbox.Terminal) (*Attempt, error)
+
+	// ResetEnvironment discards the session's sandbox state so its next attempt starts fresh
+	ResetEnvironment(ctx context.Context, sessionID string) error
+
+	// PreviewSchedule reports a card's retrievability and the interval each rating would give it
+	PreviewSchedule(ctx context.Context, cardID int) (*SchedulePreview, error)
+
 	// SubmitReview processes a card review and updates FSRS state
 	SubmitReview(ctx context.Context, sessionID string, cardID int, rating domain.Rating, executionResult *domain.ExecutionResult) error
 
+	// UndoReview takes back the session's most recent rating and queues the card again
+	UndoReview(ctx context.Context, sessionID string) (*UndoneReview, error)
+
+	// SkipCard moves a card to the end of the session's queue without rating it
+	SkipCard(ctx context.Context, sessionID string, cardID int) error
+
+	// BuryCard takes a card out of the session and out of every session until tomorrow
+	BuryCard(ctx context.Context, sessionID string, cardID int) error
+
+	// SuspendCard takes a card out of the session and out of every session until it is unsuspended
+	SuspendCard(ctx context.Context, sessionID string, cardID int) error
+
 	// EndSession finalizes the review session and returns statistics
 	EndSession(ctx context.Context, sessionID string) (*SessionStats, error)
+
+	// PauseSession parks the session, and its current card, for ResumeSession to continue
+	PauseSession(ctx context.Context, sessionID string) error
+
+	// ResumeSession continues the most recent session that was paused or interrupted
+	ResumeSession(ctx context.Context) (*Session, error)
+
+	// ExplainQueue reports how a session with opts would be queued, without starting one
+	ExplainQueue(ctx context.Context, opts SessionOptions) (*QueueExplanation, error)
 }
 
 // SessionOptions configures a review session
+// Options are saved with the session, so their JSON names must stay stable.
 type SessionOptions struct {
-	DeckID          *int // If nil, review from all decks
-	MaxCards        int  // Maximum cards per session (0 = unlimited)
-	NewCardsOnly    bool // Only show new cards
-	ReviewCardsOnly bool // Only show cards due for review
-	ShuffleCards    bool // Randomize card order
-	NetworkEnabled  bool // Allow network access for this session
+	DeckID          *int   `json:"deck_id,omitempty"` // If nil, review from all decks
+	MaxCards        int    `json:"max_cards"`         // Maximum cards per session (0 = unlimited)
+	NewCardsOnly    bool   `json:"new_cards_only"`    // Only show new cards
+	ReviewCardsOnly bool   `json:"review_cards_only"` // Only show cards due for review
+	ShuffleCards    bool   `json:"shuffle_cards"`     // Randomize card order
+	NetworkEnabled  bool   `json:"network_enabled"`   // Allow network access for this session
+	Mode            string `json:"mode,omitempty"`    // Front-end review mode, restored on resume
 }
 
 // Session represents an active review session
+// The service hands out copies; SessionStatus gets the current progress.
 type Session struct {
-	ID             string         `json:"id"`
-	StartedAt      time.Time      `json:"started_at"`
-	DeckID         *int           `json:"deck_id"`
-	Options        SessionOptions `json:"options"`
-	CardsReviewed  int            `json:"cards_reviewed"`
-	CardsRemaining int            `json:"cards_remaining"`
-	CurrentCardID  *int           `json:"current_card_id"`
+	ID             string          `json:"id"`
+	StartedAt      time.Time       `json:"started_at"`
+	DeckID         *int            `json:"deck_id"`
+	Options        SessionOptions  `json:"options"`
+	CardsReviewed  int             `json:"cards_reviewed"`
+	CardsRemaining int             `json:"cards_remaining"`
+	CurrentCardID  *int            `json:"current_card_id"`
+	Resumed        bool            `json:"resumed,omitempty"`   // Continued from an earlier pause or interruption
+	Allowance      *DailyAllowance `json:"allowance,omitempty"` // Daily limits left when the session started or resumed
 }
 
 // ReviewCard represents a card ready for review with resolved configuration
@@ -55,6 +97,7 @@ type ReviewCard struct {
 
 	// Command execution
 	Command         string            `json:"command"`
+	Shell           string            `json:"shell"` // Deck's shell setting the card's commands run with, empty for sandbox.DefaultShell
 	WorkingDir      string            `json:"working_dir"`
 	EnvironmentVars map[string]string `json:"environment_vars"`
 
@@ -63,6 +106,19 @@ type ReviewCard struct {
 	Timeout        time.Duration `json:"timeout"`
 	NetworkEnabled bool          `json:"network_enabled"`
 	Capabilities   []string      `json:"capabilities"`
+	AssetsDir      string        `json:"assets_dir,omitempty"` // Host directory mounted at /assets, empty when the deck has none
+
+	// Pedagogy
+	Setup       string `json:"setup"`   // Run before the learner's attempt
+	Cleanup     string `json:"cleanup"` // Run after each attempt
+	Verify      string `json:"verify"`  // Checks the learner's work
+	Hint        string `json:"hint"`
+	Solution    string `json:"solution"` // Pipe-separated alternatives
+	Explanation string `json:"explanation"`
+
+	// Deck settings controlling what front-ends may reveal
+	ShowSolutions    bool `json:"show_solutions"`
+	ShowExplanations bool `json:"show_explanations"`
 
 	// Learning metadata
 	DifficultyLevel int      `json:"difficulty_level"`
@@ -80,16 +136,48 @@ type ReviewCard struct {
 	LastReview    *time.Time       `json:"last_review"`
 }
 
+// SandboxConfig builds the execution config for running command against this card
+// The deck's assets, if any, are mounted read-only at sandbox.AssetsPath.
+func (c *ReviewCard) SandboxConfig(command ...string) sandbox.ExecutionConfig {
+	config := sandbox.ExecutionConfig{
+		Command:        command,
+		WorkingDir:     c.WorkingDir,
+		Image:          c.Image,
+		Timeout:        c.Timeout,
+		NetworkEnabled: c.NetworkEnabled,
+		Capabilities:   c.Capabilities,
+		Environment:    c.EnvironmentVars,
+	}
+	if c.AssetsDir != "" {
+		config = config.WithMount(sandbox.Mount{Source: c.AssetsDir, Target: sandbox.AssetsPath, ReadOnly: true})
+	}
+	return config
+}
+
 // SessionStats provides summary information about a completed session
+// Counts are of reviews, so a card rated more than once in the session counts each time.
 type SessionStats struct {
-	SessionID     string        `json:"session_id"`
-	Duration      time.Duration `json:"duration"`
-	CardsReviewed int           `json:"cards_reviewed"`
-	NewCards      int           `json:"new_cards"`
-	ReviewCards   int           `json:"review_cards"`
-	AgainCount    int           `json:"again_count"`
-	HardCount     int           `json:"hard_count"`
-	GoodCount     int           `json:"good_count"`
-	EasyCount     int           `json:"easy_count"`
-	AverageRating float64       `json:"average_rating"`
+	SessionID       string        `json:"session_id"`
+	Duration        time.Duration `json:"duration"`
+	CardsReviewed   int           `json:"cards_reviewed"`
+	NewCards        int           `json:"new_cards"`        // Cards seen for the first time
+	LearningCards   int           `json:"learning_cards"`   // Cards in their learning steps
+	ReviewCards     int           `json:"review_cards"`     // Cards due for review
+	RelearningCards int           `json:"relearning_cards"` // Cards relearned after a lapse
+	AgainCount      int           `json:"again_count"`
+	HardCount       int           `json:"hard_count"`
+	GoodCount       int           `json:"good_count"`
+	EasyCount       int           `json:"easy_count"`
+	AverageRating   float64       `json:"average_rating"`
+
+	// Time per card, averaged over the reviews that recorded it
+	AverageThinkingTime  time.Duration `json:"average_thinking_time"`  // From the card being shown to the first attempt
+	AverageExecutionTime time.Duration `json:"average_execution_time"` // Running the command in the sandbox
+
+	// Commands that ran in the sandbox, and how many succeeded
+	CommandsRun        int     `json:"commands_run"`
+	CommandsSucceeded  int     `json:"commands_succeeded"`
+	CommandSuccessRate float64 `json:"command_success_rate"` // 0 to 1, 0 when no command ran
+
+	LapsedCards []LapsedCard `json:"lapsed_cards"` // Cards in Review that were rated Again
 }
@@ -0,0 +1,78 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+
+	"github.com/justinlyon12/ancli/internal/domain"
+)
+
+// SchedulePreview shows what each rating would do to a card's schedule
+type SchedulePreview struct {
+	CardID         int            `json:"card_id"`
+	Retrievability float64        `json:"retrievability"` // Estimated chance of recalling the card now, 0 for new cards
+	Options        []RatingOption `json:"options"`        // One per rating, Again to Easy
+}
+
+// RatingOption is the schedule a card would get for one rating
+type RatingOption struct {
+	Rating   domain.Rating `json:"rating"`
+	Due      time.Time     `json:"due"`
+	Interval time.Duration `json:"interval"` // Time from the preview until Due
+}
+
+// ratings lists every rating in prompt order
+var ratings = []domain.Rating{domain.Again, domain.Hard, domain.Good, domain.Easy}
+
+// PreviewSchedule reports the card's current retrievability and the next interval
+// each rating would give it, without changing the card
+func (s *Service) PreviewSchedule(ctx context.Context, cardID int) (*SchedulePreview, error) {
+	card, err := s.storage.GetCard(cardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get card: %w", err)
+	}
+
+	fsrsCard := card.ToFSRSCard()
+	now := time.Now()
+	outcomes := s.scheduler.GetSchedulingOptions(fsrsCard)
+
+	preview := &SchedulePreview{
+		CardID:         cardID,
+		Retrievability: s.scheduler.GetRetrievability(fsrsCard),
+		Options:        make([]RatingOption, 0, len(ratings)),
+	}
+	for _, rating := range ratings {
+		fsrsRating, err := toFSRSRating(rating)
+		if err != nil {
+			return nil, err
+		}
+		due := outcomes[fsrsRating].Card.Due
+		preview.Options = append(preview.Options, RatingOption{
+			Rating:   rating,
+			Due:      due,
+			Interval: max(due.Sub(now), 0),
+		})
+	}
+
+	return preview, nil
+}
+
+// toFSRSRating converts a domain rating to the scheduler's rating
+// TODO: Refactor when domain.Rating replaces local Rating types
+func toFSRSRating(rating domain.Rating) (fsrs.Rating, error) {
+	switch rating {
+	case domain.Again:
+		return fsrs.Again, nil
+	case domain.Hard:
+		return fsrs.Hard, nil
+	case domain.Good:
+		return fsrs.Good, nil
+	case domain.Easy:
+		return fsrs.Easy, nil
+	default:
+		return 0, fmt.Errorf("invalid rating: %d", rating)
+	}
+}
@@ -0,0 +1,172 @@
+package review
+
+import (
+	"encoding/json"
+
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+
+	"github.com/justinlyon12/ancli/internal/storage"
+)
+
+// Prerequisite modes stored on each card
+const (
+	PrerequisiteEnforce = "enforce" // New cards wait until every prerequisite is in Review
+	PrerequisiteLink    = "link"    // Prerequisites are only queued first
+	PrerequisiteNone    = "none"    // Prerequisites are ignored
+)
+
+// QueuedCard is a card in a session queue
+type QueuedCard struct {
+	CardID        int      `json:"card_id"`
+	CardKey       string   `json:"card_key"`
+	Title         string   `json:"title"`
+	Prerequisites []string `json:"prerequisites,omitempty"` // Prerequisites queued ahead of this card
+}
+
+// HeldCard is a new card kept out of a session until its prerequisites are learned
+type HeldCard struct {
+	CardID    int      `json:"card_id"`
+	CardKey   string   `json:"card_key"`
+	Title     string   `json:"title"`
+	WaitingOn []string `json:"waiting_on"` // Prerequisites not yet in the Review state
+}
+
+// QueueExplanation describes how a session's queue was built
+type QueueExplanation struct {
+	Queue     []QueuedCard    `json:"queue"`
+	HeldBack  []HeldCard      `json:"held_back"`
+	Allowance *DailyAllowance `json:"allowance"` // Daily limits left today for the queued decks
+}
+
+// cardRef identifies a card by its key within a deck
+type cardRef struct {
+	deckID int
+	key    string
+}
+
+// prerequisiteIndex resolves card prerequisites, which are card keys within a deck
+type prerequisiteIndex map[cardRef]*storage.Card
+
+// newPrerequisiteIndex indexes every active card so prerequisites can be looked up
+// even when they are not due in this session
+func newPrerequisiteIndex(cards []*storage.Card) prerequisiteIndex {
+	index := make(prerequisiteIndex, len(cards))
+	for _, card := range cards {
+		index[cardRef{card.DeckID, card.CardKey}] = card
+	}
+	return index
+}
+
+// prerequisites returns a card's prerequisite keys
+func prerequisites(card *storage.Card) []string {
+	var keys []string
+	if card.Prerequisites != "" {
+		_ = json.Unmarshal([]byte(card.Prerequisites), &keys)
+	}
+	return keys
+}
+
+// unmet returns the prerequisites of card that have not reached the Review state
+// A card in Relearning has reached Review before and counts as learned. Prerequisites
+// that no longer exist, for example because an upgrade archived them or they were
+// suspended, cannot be learned and do not block.
+func (idx prerequisiteIndex) unmet(card *storage.Card) []string {
+	var waiting []string
+	for _, key := range prerequisites(card) {
+		prereq, ok := idx[cardRef{card.DeckID, key}]
+		if !ok {
+			continue
+		}
+		switch fsrs.State(prereq.FSRSState) {
+		case fsrs.Review, fsrs.Relearning:
+		default:
+			waiting = append(waiting, key)
+		}
+	}
+	return waiting
+}
+
+// holdBack splits out new cards in enforce mode whose prerequisites are not learned
+func (idx prerequisiteIndex) holdBack(cards []*storage.Card) ([]*storage.Card, []HeldCard) {
+	var ready []*storage.Card
+	var held []HeldCard
+	for _, card := range cards {
+		if card.PrerequisiteMode == PrerequisiteEnforce && fsrs.State(card.FSRSState) == fsrs.New {
+			if waiting := idx.unmet(card); len(waiting) > 0 {
+				held = append(held, HeldCard{
+					CardID:    card.ID,
+					CardKey:   card.CardKey,
+					Title:     card.Title,
+					WaitingOn: waiting,
+				})
+				continue
+			}
+		}
+		ready = append(ready, card)
+	}
+	return ready, held
+}
+
+// orderByPrerequisites moves each card's prerequisites ahead of it, except in none mode
+// The sort is stable: cards keep their relative order unless a prerequisite has to
+// move up, so shuffled sessions stay shuffled apart from prerequisite chains.
+func orderByPrerequisites(cards []*storage.Card) []*storage.Card {
+	inQueue := make(map[cardRef]*storage.Card, len(cards))
+	for _, card := range cards {
+		inQueue[cardRef{card.DeckID, card.CardKey}] = card
+	}
+
+	ordered := make([]*storage.Card, 0, len(cards))
+	visited := make(map[int]bool, len(cards))
+	var visit func(card *storage.Card)
+	visit = func(card *storage.Card) {
+		if visited[card.ID] {
+			return
+		}
+		visited[card.ID] = true
+		if card.PrerequisiteMode == PrerequisiteNone {
+			ordered = append(ordered, card)
+			return
+		}
+		for _, key := range prerequisites(card) {
+			if prereq, ok := inQueue[cardRef{card.DeckID, key}]; ok {
+				visit(prereq)
+			}
+		}
+		ordered = append(ordered, card)
+	}
+
+	for _, card := range cards {
+		visit(card)
+	}
+	return ordered
+}
+
+// explainQueue records why each card is queued where it is
+func explainQueue(queue []*storage.Card, held []HeldCard) *QueueExplanation {
+	explanation := &QueueExplanation{
+		Queue:    make([]QueuedCard, 0, len(queue)),
+		HeldBack: held,
+	}
+	if explanation.HeldBack == nil {
+		explanation.HeldBack = []HeldCard{}
+	}
+
+	queued := make(map[cardRef]bool, len(queue))
+	for _, card := range queue {
+		var ahead []string
+		for _, key := range prerequisites(card) {
+			if queued[cardRef{card.DeckID, key}] {
+				ahead = append(ahead, key)
+			}
+		}
+		explanation.Queue = append(explanation.Queue, QueuedCard{
+			CardID:        card.ID,
+			CardKey:       card.CardKey,
+			Title:         card.Title,
+			Prerequisites: ahead,
+		})
+		queued[cardRef{card.DeckID, card.CardKey}] = true
+	}
+	return explanation
+}
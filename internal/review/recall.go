@@ -0,0 +1,191 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/justinlyon12/ancli/internal/deck"
+	"github.com/justinlyon12/ancli/internal/domain"
+	"github.com/justinlyon12/ancli/internal/sandbox"
+)
+
+// Attempt is the outcome of running a learner's typed command against a card
+type Attempt struct {
+	Command  string                   `json:"command"`
+	Result   *sandbox.ExecutionResult `json:"result"`
+	Matched  string                   `json:"matched,omitempty"`  // Solution alternative the command matched
+	Verified *bool                    `json:"verified,omitempty"` // Outcome of the card's verify, nil when it has none
+	Correct  bool                     `json:"correct"`
+
+	Interactive bool `json:"interactive,omitempty"` // Made in an interactive shell rather than by typing one command
+}
+
+// Judged reports whether the attempt could be checked
+// Shell attempts have no single command to match, so only a verify can judge them.
+func (a *Attempt) Judged() bool {
+	return !a.Interactive || a.Verified != nil
+}
+
+// Thinking times that separate Easy from Good and Good from Hard in suggested ratings
+const (
+	easyThinkingTime = 15 * time.Second
+	hardThinkingTime = time.Minute
+)
+
+// Outcome is the objective result of a learner's work on a card
+type Outcome struct {
+	Passed       bool          // The last attempt was correct
+	Attempts     int           // Commands run before rating, including the last
+	ThinkingTime time.Duration // Time from card shown to the last command
+	HelpAccessed bool          // The answer was revealed
+}
+
+// SuggestRating proposes a rating from an objective outcome
+// A failed card is Again. A card passed after retries, after revealing the answer,
+// or after a long think is Hard; otherwise a quick answer is Easy and the rest Good.
+func SuggestRating(outcome Outcome) domain.Rating {
+	switch {
+	case !outcome.Passed:
+		return domain.Again
+	case outcome.Attempts > 1, outcome.HelpAccessed, outcome.ThinkingTime > hardThinkingTime:
+		return domain.Hard
+	case outcome.ThinkingTime <= easyThinkingTime:
+		return domain.Easy
+	default:
+		return domain.Good
+	}
+}
+
+// SolutionAlternatives returns the accepted answers for the card
+// A card without a solution is solved by its command.
+func (c *ReviewCard) SolutionAlternatives() []string {
+	return deck.SolutionAlternatives(deck.CardSpec{Command: c.Command, Solution: c.Solution})
+}
+
+// MatchSolution returns the solution alternative command matches, or "" if none does
+// Commands are compared with runs of whitespace collapsed.
+func (c *ReviewCard) MatchSolution(command string) string {
+	typed := normalizeCommand(command)
+	for _, solution := range c.SolutionAlternatives() {
+		if normalizeCommand(solution) == typed {
+			return solution
+		}
+	}
+	return ""
+}
+
+// normalizeCommand collapses whitespace so spacing differences don't fail a match
+func normalizeCommand(command string) string {
+	return strings.Join(strings.Fields(command), " ")
+}
+
+// AttemptCard runs command as the learner's answer to the session's current card
+func (s *Service) AttemptCard(ctx context.Context, sessionID string, card *ReviewCard, command string) (*Attempt, error) {
+	return s.attempt(ctx, sessionID, card, func(workingDir string) (*Attempt, error) {
+		result, err := s.runStep(ctx, sessionID, card, command, workingDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run command: %w", err)
+		}
+		return &Attempt{Command: command, Result: result, Matched: card.MatchSolution(command)}, nil
+	})
+}
+
+// ShellAttempt attaches term to an interactive shell for the session's current card
+// The shell ends when the learner exits it or, for cards with a verify, types
+// "verify". The attempt's Result.Stdout holds the terminal transcript.
+func (s *Service) ShellAttempt(ctx context.Context, sessionID string, card *ReviewCard, term sandbox.Terminal) (*Attempt, error) {
+	return s.attempt(ctx, sessionID, card, func(workingDir string) (*Attempt, error) {
+		config := card.SandboxConfig(interactiveShell(card.Shell, card.Verify)...).WithSessionID(sessionID)
+		config.WorkingDir = workingDir
+		result, err := s.sandbox.Shell(ctx, config, term)
+		if err != nil {
+			return nil, fmt.Errorf("interactive shell failed: %w", err)
+		}
+		return &Attempt{Result: result, Interactive: true}, nil
+	})
+}
+
+// attempt runs one attempt at the session's current card and judges it
+// The card's setup runs first, and the attempt and verify start in the directory it
+// finished in. For cards with a verify, its exit status decides whether the attempt is
+// correct; other cards are correct when the command matches a solution alternative.
+// Cleanup runs last whatever the outcome so the next attempt starts from a known state.
+func (s *Service) attempt(ctx context.Context, sessionID string, card *ReviewCard, run func(workingDir string) (*Attempt, error)) (*Attempt, error) {
+	state, err := s.lockSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer state.mu.Unlock()
+	if state.CurrentCardID == nil || *state.CurrentCardID != card.ID {
+		return nil, fmt.Errorf("card %d is not the current card in session %s", card.ID, sessionID)
+	}
+
+	workingDir := card.WorkingDir
+	if card.Cleanup != "" {
+		defer func() { _, _ = s.runStep(ctx, sessionID, card, card.Cleanup, workingDir) }()
+	}
+
+	if card.Setup != "" {
+		result, err := s.runStep(ctx, sessionID, card, sandbox.TrackWorkingDir(card.Setup), workingDir)
+		if err != nil {
+			return nil, fmt.Errorf("card setup failed: %w", err)
+		}
+		if result.ExitCode != 0 {
+			return nil, fmt.Errorf("card setup exited with code %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr))
+		}
+		_, workingDir = sandbox.TrackedWorkingDir(result.Stdout, workingDir)
+	}
+
+	attempt, err := run(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if card.Verify != "" {
+		verify, err := s.runStep(ctx, sessionID, card, card.Verify, workingDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run verify: %w", err)
+		}
+		// A shell's exit status is whatever the learner ran last, so only verify counts
+		passed := verify.ExitCode == 0 && (attempt.Interactive || attempt.Result.Success)
+		attempt.Verified = &passed
+	}
+
+	if attempt.Verified != nil {
+		attempt.Correct = *attempt.Verified
+	} else {
+		attempt.Correct = attempt.Matched != ""
+	}
+	return attempt, nil
+}
+
+// shellRC is the startup file written for interactive shells inside the container
+const shellRC = "/tmp/.ancli_shellrc"
+
+// interactiveShell builds the command for an interactive session of the deck's shell
+// When the card has a verify, the shell's startup file defines a "verify" function
+// that runs it and exits the shell with its status. bash reads the file with
+// --rcfile; POSIX shells read the file named by ENV.
+func interactiveShell(shell, verify string) []string {
+	rc := ""
+	if verify != "" {
+		rc = "verify() {\n(\n" + verify + "\n)\nexit $?\n}\n"
+	}
+	program := sandbox.ShellArgs(shell)[0]
+	start := `ENV=` + shellRC + ` exec ` + program + ` -i`
+	if sandbox.IsBash(shell) {
+		start = `exec ` + program + ` --rcfile ` + shellRC + ` -i`
+	}
+	script := `printf '%s' "$1" > ` + shellRC + ` && ` + start
+	return append(sandbox.ShellCommand(shell, script), "sh", rc)
+}
+
+// runStep runs one command for card in workingDir with the deck's shell, in the
+// session's container
+func (s *Service) runStep(ctx context.Context, sessionID string, card *ReviewCard, command, workingDir string) (*sandbox.ExecutionResult, error) {
+	config := card.SandboxConfig(sandbox.ShellCommand(card.Shell, command)...).WithSessionID(sessionID)
+	config.WorkingDir = workingDir
+	return s.sandbox.Run(ctx, config)
+}
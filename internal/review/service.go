@@ -3,8 +3,12 @@ package review
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,18 +20,41 @@ import (
 	"github.com/justinlyon12/ancli/internal/storage"
 )
 
+// ErrQueueEmpty is returned by GetNextCard when the session has no cards left to show
+var ErrQueueEmpty = errors.New("no more cards remaining in session")
+
 // Service implements ReviewService using storage, scheduler, and sandbox adapters
+// It is safe for concurrent use: sessions can run side by side, each with its own
+// sandbox container, while calls on one session are applied one at a time.
 type Service struct {
 	storage   storage.Storage
 	scheduler *scheduler.Scheduler
 	sandbox   sandbox.Sandbox
-	sessions  map[string]*sessionState // In-memory session tracking
+
+	mu       sync.Mutex               // Guards sessions and limits
+	sessions map[string]*sessionState // In-memory session tracking
+	limits   DailyLimits              // Daily limits for decks without their own
+
+	assetsMu sync.Mutex        // Guards assets
+	assets   map[int]*assetDir // Asset directories by deck ID
+}
+
+// assetDir is a host directory holding a deck's assets for mounting into the sandbox
+type assetDir struct {
+	path      string    // Empty when the deck has no assets
+	updatedAt time.Time // Deck version the directory was written for
 }
 
 // sessionState tracks the internal state of a review session
 type sessionState struct {
+	mu     sync.Mutex // Held for the whole of each call on the session
+	closed bool       // Paused or ended and dropped from the service
+
 	*Session
-	cardQueue []int // Card IDs in order
+	cardQueue    []int          // Card IDs in order
+	learning     []learningCard // Cards in learning steps due again this session, by due time
+	activeBefore time.Duration  // Review time before the session was last resumed
+	resumedAt    time.Time      // When the session was started or last resumed
 }
 
 // NewService creates a new review service
@@ -37,20 +64,40 @@ func NewService(storage storage.Storage, scheduler *scheduler.Scheduler, sandbox
 		scheduler: scheduler,
 		sandbox:   sandbox,
 		sessions:  make(map[string]*sessionState),
+		assets:    make(map[int]*assetDir),
+	}
+}
+
+// Close removes the asset directories written for review sessions
+func (s *Service) Close() error {
+	s.assetsMu.Lock()
+	defer s.assetsMu.Unlock()
+
+	var errs []error
+	for deckID, dir := range s.assets {
+		if dir.path != "" {
+			if err := os.RemoveAll(dir.path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		delete(s.assets, deckID)
 	}
+	return errors.Join(errs...)
 }
 
 // StartSession begins a new review session
 func (s *Service) StartSession(ctx context.Context, opts SessionOptions) (*Session, error) {
 	sessionID := uuid.New().String()
 
-	// Query cards based on options
-	cards, err := s.queryCardsForSession(ctx, opts)
+	cards, held, allowance, err := s.buildQueue(ctx, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query cards for session: %w", err)
+		return nil, err
 	}
 
 	if len(cards) == 0 {
+		if len(held) > 0 {
+			return nil, fmt.Errorf("no cards available for review: %d new cards are waiting on prerequisites", len(held))
+		}
 		return nil, fmt.Errorf("no cards available for review with the given options")
 	}
 
@@ -60,18 +107,6 @@ func (s *Service) StartSession(ctx context.Context, opts SessionOptions) (*Sessi
 		cardQueue[i] = card.ID
 	}
 
-	// Shuffle if requested
-	if opts.ShuffleCards {
-		rand.Shuffle(len(cardQueue), func(i, j int) {
-			cardQueue[i], cardQueue[j] = cardQueue[j], cardQueue[i]
-		})
-	}
-
-	// Limit cards if maxCards is set
-	if opts.MaxCards > 0 && len(cardQueue) > opts.MaxCards {
-		cardQueue = cardQueue[:opts.MaxCards]
-	}
-
 	// Create session
 	session := &Session{
 		ID:             sessionID,
@@ -81,30 +116,101 @@ func (s *Service) StartSession(ctx context.Context, opts SessionOptions) (*Sessi
 		CardsReviewed:  0,
 		CardsRemaining: len(cardQueue),
 		CurrentCardID:  nil,
+		Allowance:      allowance,
 	}
 
-	// Store session state
-	s.sessions[sessionID] = &sessionState{
+	state := &sessionState{
 		Session:   session,
 		cardQueue: cardQueue,
+		resumedAt: session.StartedAt,
+	}
+
+	// Persist the session so it can be resumed if interrupted
+	record, err := state.record()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storage.CreateSession(record); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
 	}
 
-	return session, nil
+	// Store session state
+	snapshot := state.snapshot()
+	s.mu.Lock()
+	s.sessions[sessionID] = state
+	s.mu.Unlock()
+
+	return snapshot, nil
 }
 
-// GetNextCard retrieves the next card due for review in the session
-func (s *Service) GetNextCard(ctx context.Context, sessionID string) (*ReviewCard, error) {
+// SessionStatus returns a copy of the session as it stands, safe to read while other
+// calls change it
+func (s *Service) SessionStatus(ctx context.Context, sessionID string) (*Session, error) {
+	state, err := s.lockSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer state.mu.Unlock()
+	return state.snapshot(), nil
+}
+
+// snapshot copies the session so callers never share it with the service
+// The caller must hold the session's lock, or be its only user.
+func (st *sessionState) snapshot() *Session {
+	session := *st.Session
+	if st.CurrentCardID != nil {
+		id := *st.CurrentCardID
+		session.CurrentCardID = &id
+	}
+	if st.Allowance != nil {
+		allowance := *st.Allowance
+		session.Allowance = &allowance
+	}
+	return &session
+}
+
+// lockSession finds a session and locks it for one call; the caller must unlock it
+func (s *Service) lockSession(sessionID string) (*sessionState, error) {
+	s.mu.Lock()
 	state, exists := s.sessions[sessionID]
+	s.mu.Unlock()
 	if !exists {
 		return nil, fmt.Errorf("session %s not found", sessionID)
 	}
 
-	if len(state.cardQueue) == 0 {
-		return nil, fmt.Errorf("no more cards remaining in session")
+	state.mu.Lock()
+	// The session may have been paused or ended while we waited for it
+	if state.closed {
+		state.mu.Unlock()
+		return nil, fmt.Errorf("session %s not found", sessionID)
+	}
+	return state, nil
+}
+
+// closeSession drops a locked session from the service and removes its container
+// The container is removed on a best-effort basis; the sandbox's CleanupAll at exit
+// catches any left behind.
+func (s *Service) closeSession(ctx context.Context, state *sessionState) {
+	state.closed = true
+	s.mu.Lock()
+	delete(s.sessions, state.ID)
+	s.mu.Unlock()
+
+	_ = s.sandbox.Cleanup(ctx, state.ID)
+}
+
+// GetNextCard retrieves the next card due for review in the session
+func (s *Service) GetNextCard(ctx context.Context, sessionID string) (*ReviewCard, error) {
+	state, err := s.lockSession(sessionID)
+	if err != nil {
+		return nil, err
 	}
+	defer state.mu.Unlock()
 
-	// Get next card ID from queue
-	cardID := state.cardQueue[0]
+	cardID, ok := state.nextCardID(time.Now())
+	if !ok {
+		return nil, ErrQueueEmpty
+	}
 
 	// Get card from storage
 	storageCard, err := s.storage.GetCard(cardID)
@@ -120,16 +226,20 @@ func (s *Service) GetNextCard(ctx context.Context, sessionID string) (*ReviewCar
 
 	// Update session state
 	state.CurrentCardID = &cardID
+	if err := s.saveSession(state); err != nil {
+		return nil, err
+	}
 
 	return reviewCard, nil
 }
 
 // SubmitReview processes a card review and updates FSRS state
 func (s *Service) SubmitReview(ctx context.Context, sessionID string, cardID int, rating domain.Rating, executionResult *domain.ExecutionResult) error {
-	state, exists := s.sessions[sessionID]
-	if !exists {
-		return fmt.Errorf("session %s not found", sessionID)
+	state, err := s.lockSession(sessionID)
+	if err != nil {
+		return err
 	}
+	defer state.mu.Unlock()
 
 	// Get the card from storage
 	card, err := s.storage.GetCard(cardID)
@@ -141,19 +251,9 @@ func (s *Service) SubmitReview(ctx context.Context, sessionID string, cardID int
 	fsrsCard := card.ToFSRSCard()
 
 	// Convert domain.Rating to fsrs.Rating
-	// TODO: Refactor when domain.Rating replaces local Rating types
-	var fsrsRating fsrs.Rating
-	switch rating {
-	case domain.Again:
-		fsrsRating = fsrs.Again
-	case domain.Hard:
-		fsrsRating = fsrs.Hard
-	case domain.Good:
-		fsrsRating = fsrs.Good
-	case domain.Easy:
-		fsrsRating = fsrs.Easy
-	default:
-		return fmt.Errorf("invalid rating: %d", rating)
+	fsrsRating, err := toFSRSRating(rating)
+	if err != nil {
+		return err
 	}
 
 	// Schedule the next review
@@ -162,55 +262,74 @@ func (s *Service) SubmitReview(ctx context.Context, sessionID string, cardID int
 	// Update card using existing method
 	card.UpdateFromFSRSCard(scheduleInfo.Card)
 
-	// Update card in storage
-	if err := s.storage.UpdateCard(card); err != nil {
-		return fmt.Errorf("failed to update card: %w", err)
-	}
-
-	// Record the review
-	if err := s.createReviewRecord(ctx, cardID, rating, executionResult, fsrsCard, scheduleInfo.Card); err != nil {
-		return fmt.Errorf("failed to create review record: %w", err)
+	// Save the card and the review together, so neither is kept without the other
+	review := newReviewRecord(sessionID, cardID, rating, executionResult, fsrsCard, scheduleInfo.Card)
+	if err := s.storage.RecordReview(card, review); err != nil {
+		return fmt.Errorf("failed to record review: %w", err)
 	}
 
 	// Update session state
 	state.CardsReviewed++
-	state.CardsRemaining--
 	state.CurrentCardID = nil
 
-	// Remove card from queue
-	if len(state.cardQueue) > 0 && state.cardQueue[0] == cardID {
-		state.cardQueue = state.cardQueue[1:]
+	// Remove card from queue; a card in a learning step due before the session ends
+	// is shown again when it is due
+	state.dequeue(cardID)
+	if dueInSession(scheduleInfo.Card, time.Now()) {
+		state.addLearning(cardID, scheduleInfo.Card.Due)
 	}
+	state.CardsRemaining = state.remaining()
 
-	return nil
+	return s.saveSession(state)
 }
 
 // EndSession finalizes the review session and returns statistics
 func (s *Service) EndSession(ctx context.Context, sessionID string) (*SessionStats, error) {
-	state, exists := s.sessions[sessionID]
-	if !exists {
-		return nil, fmt.Errorf("session %s not found", sessionID)
+	state, err := s.lockSession(sessionID)
+	if err != nil {
+		return nil, err
 	}
+	defer state.mu.Unlock()
 
-	duration := time.Since(state.StartedAt)
+	stats, err := s.sessionStats(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	stats.Duration = state.activeTime()
+	stats.CardsReviewed = state.CardsReviewed
 
-	// TODO: Calculate detailed stats from review records
-	stats := &SessionStats{
-		SessionID:     sessionID,
-		Duration:      duration,
-		CardsReviewed: state.CardsReviewed,
-		NewCards:      0, // TODO: Calculate from review records
-		ReviewCards:   0, // TODO: Calculate from review records
+	// Mark the session finished so it is never offered for resuming
+	endedAt := time.Now()
+	if err := s.saveSession(state, func(record *storage.Session) { record.EndedAt = &endedAt }); err != nil {
+		return nil, err
 	}
 
 	// Clean up session
-	delete(s.sessions, sessionID)
+	s.closeSession(ctx, state)
 
 	return stats, nil
 }
 
+// ResetEnvironment discards the session's container so its next attempt starts from a
+// fresh one, undoing anything the learner changed
+// Other sessions' containers are left alone.
+func (s *Service) ResetEnvironment(ctx context.Context, sessionID string) error {
+	state, err := s.lockSession(sessionID)
+	if err != nil {
+		return err
+	}
+	defer state.mu.Unlock()
+
+	if err := s.sandbox.Cleanup(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to reset sandbox: %w", err)
+	}
+	return nil
+}
+
 // queryCardsForSession queries cards based on session options
-func (s *Service) queryCardsForSession(ctx context.Context, opts SessionOptions) ([]*storage.Card, error) {
+// Suspended and buried cards are left out. New cards whose prerequisites are enforced
+// and not yet learned are returned separately as held back.
+func (s *Service) queryCardsForSession(ctx context.Context, opts SessionOptions) ([]*storage.Card, []HeldCard, error) {
 	var cards []*storage.Card
 	var err error
 
@@ -221,14 +340,22 @@ func (s *Service) queryCardsForSession(ctx context.Context, opts SessionOptions)
 	}
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Filter based on options
-	var filtered []*storage.Card
+	var filtered, active []*storage.Card
 	now := time.Now()
 
 	for _, card := range cards {
+		// Suspended cards can't be learned, so they don't hold back cards that build on them
+		if !card.Suspended {
+			active = append(active, card)
+		}
+		if setAsideNow(card, now) {
+			continue
+		}
+
 		// Filter by new/review status
 		if opts.NewCardsOnly && card.FSRSReps > 0 {
 			continue
@@ -245,7 +372,53 @@ func (s *Service) queryCardsForSession(ctx context.Context, opts SessionOptions)
 		filtered = append(filtered, card)
 	}
 
-	return filtered, nil
+	ready, held := newPrerequisiteIndex(active).holdBack(filtered)
+	return ready, held, nil
+}
+
+// ExplainQueue builds the queue a session with opts would get, without starting one,
+// and reports why cards are ordered or held back
+func (s *Service) ExplainQueue(ctx context.Context, opts SessionOptions) (*QueueExplanation, error) {
+	cards, held, allowance, err := s.buildQueue(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	explanation := explainQueue(cards, held)
+	explanation.Allowance = allowance
+	return explanation, nil
+}
+
+// buildQueue selects, orders, and limits the cards for a session
+// What is left of the daily limits today is returned with the queue.
+func (s *Service) buildQueue(ctx context.Context, opts SessionOptions) ([]*storage.Card, []HeldCard, *DailyAllowance, error) {
+	// Query cards based on options
+	cards, held, err := s.queryCardsForSession(ctx, opts)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to query cards for session: %w", err)
+	}
+
+	// Shuffle if requested
+	if opts.ShuffleCards {
+		rand.Shuffle(len(cards), func(i, j int) {
+			cards[i], cards[j] = cards[j], cards[i]
+		})
+	}
+
+	// Prerequisites come before the cards that build on them
+	cards = orderByPrerequisites(cards)
+
+	// Daily limits apply to the ordered queue, so they keep prerequisites first
+	cards, allowance, err := s.applyDailyLimits(cards, time.Now())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to apply daily limits: %w", err)
+	}
+
+	// Limit cards if maxCards is set
+	if opts.MaxCards > 0 && len(cards) > opts.MaxCards {
+		cards = cards[:opts.MaxCards]
+	}
+
+	return cards, held, allowance, nil
 }
 
 // convertToReviewCard converts storage.Card to ReviewCard with resolved configuration
@@ -292,39 +465,123 @@ func (s *Service) convertToReviewCard(ctx context.Context, storageCard *storage.
 		networkEnabled = *storageCard.NetworkEnabled
 	}
 
+	settings, err := deck.ParseSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	assetsDir, err := s.prepareAssets(deck)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ReviewCard{
-		ID:              storageCard.ID,
-		DeckID:          storageCard.DeckID,
-		CardKey:         storageCard.CardKey,
-		Title:           storageCard.Title,
-		Description:     storageCard.Description,
-		Command:         storageCard.Command,
-		WorkingDir:      storageCard.WorkingDir,
-		EnvironmentVars: envVars,
-		Image:           image,
-		Timeout:         timeout,
-		NetworkEnabled:  networkEnabled,
-		Capabilities:    capabilities,
-		DifficultyLevel: storageCard.DifficultyLevel,
-		Tags:            tags,
-		DueAt:           storageCard.FSRSDue,
-		Stability:       storageCard.FSRSStability,
-		Difficulty:      storageCard.FSRSDifficulty,
-		ElapsedDays:     storageCard.FSRSElapsedDays,
-		ScheduledDays:   storageCard.FSRSScheduledDays,
-		Reps:            storageCard.FSRSReps,
-		Lapses:          storageCard.FSRSLapses,
-		State:           domain.CardState(storageCard.FSRSState),
-		LastReview:      storageCard.FSRSLastReview,
+		ID:               storageCard.ID,
+		DeckID:           storageCard.DeckID,
+		CardKey:          storageCard.CardKey,
+		Title:            storageCard.Title,
+		Description:      storageCard.Description,
+		Command:          storageCard.Command,
+		Shell:            deck.DefaultShell,
+		WorkingDir:       storageCard.WorkingDir,
+		EnvironmentVars:  envVars,
+		Image:            image,
+		Timeout:          timeout,
+		NetworkEnabled:   networkEnabled,
+		Capabilities:     capabilities,
+		AssetsDir:        assetsDir,
+		Setup:            storageCard.Setup,
+		Cleanup:          storageCard.Cleanup,
+		Verify:           storageCard.Verify,
+		Hint:             storageCard.Hint,
+		Solution:         storageCard.Solution,
+		Explanation:      storageCard.Explanation,
+		ShowSolutions:    settings.ShowSolutions == nil || *settings.ShowSolutions,
+		ShowExplanations: settings.ShowExplanations == nil || *settings.ShowExplanations,
+		DifficultyLevel:  storageCard.DifficultyLevel,
+		Tags:             tags,
+		DueAt:            storageCard.FSRSDue,
+		Stability:        storageCard.FSRSStability,
+		Difficulty:       storageCard.FSRSDifficulty,
+		ElapsedDays:      storageCard.FSRSElapsedDays,
+		ScheduledDays:    storageCard.FSRSScheduledDays,
+		Reps:             storageCard.FSRSReps,
+		Lapses:           storageCard.FSRSLapses,
+		State:            domain.CardState(storageCard.FSRSState),
+		LastReview:       storageCard.FSRSLastReview,
 	}, nil
 }
 
-// createReviewRecord creates a review record
-func (s *Service) createReviewRecord(ctx context.Context, cardID int, rating domain.Rating,
-	executionResult *domain.ExecutionResult, fsrsCardBefore, fsrsCardAfter fsrs.Card) error {
+// prepareAssets writes a deck's assets to a host directory for mounting at /assets
+// The directory is reused until the deck is updated; then a fresh one replaces it, so
+// the new path makes the sandbox driver start a container with the refreshed mount.
+func (s *Service) prepareAssets(deck *storage.Deck) (string, error) {
+	s.assetsMu.Lock()
+	defer s.assetsMu.Unlock()
+
+	if dir, ok := s.assets[deck.ID]; ok && dir.updatedAt.Equal(deck.UpdatedAt) {
+		return dir.path, nil
+	}
+
+	assets, err := s.storage.ListDeckAssets(deck.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to list deck assets: %w", err)
+	}
+
+	path := ""
+	if len(assets) > 0 {
+		path, err = writeAssets(assets)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if old, ok := s.assets[deck.ID]; ok && old.path != "" {
+		_ = os.RemoveAll(old.path)
+	}
+	s.assets[deck.ID] = &assetDir{path: path, updatedAt: deck.UpdatedAt}
+
+	return path, nil
+}
+
+// writeAssets writes assets into a new temporary directory and returns its path
+func writeAssets(assets []*storage.DeckAsset) (string, error) {
+	dir, err := os.MkdirTemp("", "ancli-assets-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create assets directory: %w", err)
+	}
+	if err := os.Chmod(dir, 0755); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to create assets directory: %w", err)
+	}
+
+	for _, asset := range assets {
+		if !filepath.IsLocal(asset.Filename) {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("asset %q escapes the assets directory", asset.Filename)
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(asset.Filename))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("failed to write asset %q: %w", asset.Filename, err)
+		}
+		if err := os.WriteFile(path, asset.Content, 0644); err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("failed to write asset %q: %w", asset.Filename, err)
+		}
+	}
+
+	return dir, nil
+}
+
+// newReviewRecord builds the review record for a rating
+func newReviewRecord(sessionID string, cardID int, rating domain.Rating,
+	executionResult *domain.ExecutionResult, fsrsCardBefore, fsrsCardAfter fsrs.Card) *storage.Review {
 
 	review := &storage.Review{
 		CardID:               cardID,
+		SessionID:            &sessionID,
 		ReviewedAt:           time.Now(),
 		Rating:               int(rating),
 		FSRSDueBefore:        fsrsCardBefore.Due,
@@ -333,6 +590,17 @@ func (s *Service) createReviewRecord(ctx context.Context, cardID int, rating dom
 		FSRSStabilityAfter:   fsrsCardAfter.Stability,
 		FSRSDifficultyBefore: fsrsCardBefore.Difficulty,
 		FSRSDifficultyAfter:  fsrsCardAfter.Difficulty,
+		FSRSStateBefore:      int(fsrsCardBefore.State),
+		FSRSStateAfter:       int(fsrsCardAfter.State),
+		Attempts:             1,
+	}
+	elapsedDays, scheduledDays := int(fsrsCardBefore.ElapsedDays), int(fsrsCardBefore.ScheduledDays)
+	reps, lapses := int(fsrsCardBefore.Reps), int(fsrsCardBefore.Lapses)
+	review.FSRSElapsedDaysBefore, review.FSRSScheduledDaysBefore = &elapsedDays, &scheduledDays
+	review.FSRSRepsBefore, review.FSRSLapsesBefore = &reps, &lapses
+	if !fsrsCardBefore.LastReview.IsZero() {
+		lastReview := fsrsCardBefore.LastReview
+		review.FSRSLastReviewBefore = &lastReview
 	}
 
 	if executionResult != nil {
@@ -340,6 +608,15 @@ func (s *Service) createReviewRecord(ctx context.Context, cardID int, rating dom
 		review.ExitCode = &executionResult.ExitCode
 		review.Stdout = executionResult.Stdout
 		review.Stderr = executionResult.Stderr
+		review.HelpAccessed = executionResult.HelpAccessed
+		if executionResult.Attempts > 0 {
+			review.Attempts = executionResult.Attempts
+		}
+		review.AttemptPassed = executionResult.AttemptPassed
+		if executionResult.SuggestedRating != 0 {
+			suggested := int(executionResult.SuggestedRating)
+			review.SuggestedRating = &suggested
+		}
 
 		if executionResult.Duration > 0 {
 			ms := int(executionResult.Duration.Nanoseconds() / 1000000)
@@ -351,5 +628,5 @@ func (s *Service) createReviewRecord(ctx context.Context, cardID int, rating dom
 		}
 	}
 
-	return s.storage.CreateReview(review)
+	return review
 }
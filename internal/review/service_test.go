@@ -1,10 +1,21 @@
 package review
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+
 	"github.com/justinlyon12/ancli/internal/domain"
 	"github.com/justinlyon12/ancli/internal/sandbox"
 	"github.com/justinlyon12/ancli/internal/scheduler"
@@ -13,16 +24,20 @@ import (
 
 // mockDB implements a simple mock for testing
 type mockDB struct {
-	decks   map[int]*storage.Deck
-	cards   map[int]*storage.Card
-	reviews []storage.Review
+	decks    map[int]*storage.Deck
+	cards    map[int]*storage.Card
+	reviews  []storage.Review
+	assets   map[int][]*storage.DeckAsset
+	sessions map[string]*storage.Session
 }
 
 func newMockDB() *mockDB {
 	return &mockDB{
-		decks:   make(map[int]*storage.Deck),
-		cards:   make(map[int]*storage.Card),
-		reviews: make([]storage.Review, 0),
+		decks:    make(map[int]*storage.Deck),
+		cards:    make(map[int]*storage.Card),
+		reviews:  make([]storage.Review, 0),
+		assets:   make(map[int][]*storage.DeckAsset),
+		sessions: make(map[string]*storage.Session),
 	}
 }
 
@@ -49,10 +64,66 @@ func (m *mockDB) UpdateCard(card *storage.Card) error {
 
 func (m *mockDB) CreateReview(review *storage.Review) error {
 	review.ID = len(m.reviews) + 1
+	if review.ReviewedAt.IsZero() {
+		review.ReviewedAt = time.Now()
+	}
 	m.reviews = append(m.reviews, *review)
 	return nil
 }
 
+func (m *mockDB) RecordReview(card *storage.Card, review *storage.Review) error {
+	if err := m.UpdateCard(card); err != nil {
+		return err
+	}
+	return m.CreateReview(review)
+}
+
+func (m *mockDB) RevertReview(review *storage.Review) error {
+	for i := range m.reviews {
+		if m.reviews[i].ID == review.ID {
+			if err := m.cards[review.CardID].RestoreFromReview(review); err != nil {
+				return err
+			}
+			m.reviews = append(m.reviews[:i:i], m.reviews[i+1:]...)
+			return nil
+		}
+	}
+	return &NotFoundError{Resource: "review", ID: review.ID}
+}
+
+func (m *mockDB) CountReviewsSince(since time.Time) (map[int]storage.ReviewCounts, error) {
+	counts := make(map[int]storage.ReviewCounts)
+	introduced := make(map[int]bool)
+	for _, review := range m.reviews {
+		card, ok := m.cards[review.CardID]
+		if !ok || review.ReviewedAt.Before(since) {
+			continue
+		}
+		count := counts[card.DeckID]
+		switch review.FSRSStateBefore {
+		case 0:
+			if !introduced[review.CardID] {
+				introduced[review.CardID] = true
+				count.NewCards++
+			}
+		case 2:
+			count.Reviews++
+		}
+		counts[card.DeckID] = count
+	}
+	return counts, nil
+}
+
+func (m *mockDB) GetReviewsBySession(sessionID string) ([]*storage.Review, error) {
+	var reviews []*storage.Review
+	for i := range m.reviews {
+		if review := &m.reviews[i]; review.SessionID != nil && *review.SessionID == sessionID {
+			reviews = append(reviews, review)
+		}
+	}
+	return reviews, nil
+}
+
 func (m *mockDB) GetCardsByDeck(deckID int) ([]*storage.Card, error) {
 	var cards []*storage.Card
 	for _, card := range m.cards {
@@ -60,7 +131,7 @@ func (m *mockDB) GetCardsByDeck(deckID int) ([]*storage.Card, error) {
 			cards = append(cards, card)
 		}
 	}
-	return cards, nil
+	return sortedByKey(cards), nil
 }
 
 func (m *mockDB) GetAllCards() ([]*storage.Card, error) {
@@ -68,7 +139,60 @@ func (m *mockDB) GetAllCards() ([]*storage.Card, error) {
 	for _, card := range m.cards {
 		cards = append(cards, card)
 	}
-	return cards, nil
+	return sortedByKey(cards), nil
+}
+
+// sortedByKey orders cards by deck and card key, as storage returns them
+func sortedByKey(cards []*storage.Card) []*storage.Card {
+	sort.Slice(cards, func(i, j int) bool {
+		if cards[i].DeckID != cards[j].DeckID {
+			return cards[i].DeckID < cards[j].DeckID
+		}
+		return cards[i].CardKey < cards[j].CardKey
+	})
+	return cards
+}
+
+func (m *mockDB) ListDeckAssets(deckID int) ([]*storage.DeckAsset, error) {
+	return m.assets[deckID], nil
+}
+
+func (m *mockDB) CreateSession(session *storage.Session) error {
+	saved := *session
+	m.sessions[session.ID] = &saved
+	return nil
+}
+
+func (m *mockDB) UpdateSession(session *storage.Session) error {
+	if _, exists := m.sessions[session.ID]; !exists {
+		return &NotFoundError{Resource: "session"}
+	}
+	session.UpdatedAt = time.Now()
+	saved := *session
+	m.sessions[session.ID] = &saved
+	return nil
+}
+
+func (m *mockDB) GetSession(id string) (*storage.Session, error) {
+	if session, exists := m.sessions[id]; exists {
+		saved := *session
+		return &saved, nil
+	}
+	return nil, &NotFoundError{Resource: "session"}
+}
+
+func (m *mockDB) GetUnfinishedSession() (*storage.Session, error) {
+	var latest *storage.Session
+	for _, session := range m.sessions {
+		if session.EndedAt == nil && (latest == nil || session.UpdatedAt.After(latest.UpdatedAt)) {
+			latest = session
+		}
+	}
+	if latest == nil {
+		return nil, &NotFoundError{Resource: "session"}
+	}
+	saved := *latest
+	return &saved, nil
 }
 
 // NotFoundError represents a resource not found error
@@ -81,9 +205,14 @@ func (e *NotFoundError) Error() string {
 	return "not found"
 }
 
+func (e *NotFoundError) Unwrap() error { return storage.ErrNotFound }
+
 // mockSandbox implements the sandbox interface for testing
 type mockSandbox struct {
-	results map[string]*sandbox.ExecutionResult
+	mu      sync.Mutex
+	results map[string]*sandbox.ExecutionResult // Keyed by the last command argument, the script for shell commands
+	runs    []sandbox.ExecutionConfig
+	cleaned []string // Sessions whose container was removed
 }
 
 func newMockSandbox() *mockSandbox {
@@ -93,8 +222,12 @@ func newMockSandbox() *mockSandbox {
 }
 
 func (m *mockSandbox) Run(ctx context.Context, config sandbox.ExecutionConfig) (*sandbox.ExecutionResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs = append(m.runs, config)
+
 	// Return a mock result based on the command
-	cmdStr := config.Command[0]
+	cmdStr := config.Command[len(config.Command)-1]
 	if result, exists := m.results[cmdStr]; exists {
 		return result, nil
 	}
@@ -113,7 +246,25 @@ func (m *mockSandbox) Run(ctx context.Context, config sandbox.ExecutionConfig) (
 	}, nil
 }
 
-func (m *mockSandbox) Cleanup(ctx context.Context) error {
+func (m *mockSandbox) Shell(ctx context.Context, config sandbox.ExecutionConfig, term sandbox.Terminal) (*sandbox.ExecutionResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs = append(m.runs, config)
+
+	// The learner's session: the last command they ran failed
+	transcript := "$ mkdir my_project\n$ false\n$ exit\n"
+	fmt.Fprint(term.Out, transcript)
+	return &sandbox.ExecutionResult{ExitCode: 1, Stdout: transcript, ContainerID: "mock-container"}, nil
+}
+
+func (m *mockSandbox) Cleanup(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cleaned = append(m.cleaned, sessionID)
+	return nil
+}
+
+func (m *mockSandbox) CleanupAll(ctx context.Context) error {
 	return nil
 }
 
@@ -260,6 +411,7 @@ func TestSubmitReview_Success(t *testing.T) {
 		Stdout:       "hello",
 		Duration:     100 * time.Millisecond,
 		ThinkingTime: 2 * time.Second,
+		HelpAccessed: true,
 	}
 
 	err = service.SubmitReview(ctx, session.ID, 1, domain.Good, executionResult)
@@ -288,6 +440,12 @@ func TestSubmitReview_Success(t *testing.T) {
 	if !review.ExecutionSuccess {
 		t.Error("expected execution success to be true")
 	}
+	if !review.HelpAccessed {
+		t.Error("expected help accessed to be recorded")
+	}
+	if review.Attempts != 1 {
+		t.Errorf("expected a single attempt by default, got %d", review.Attempts)
+	}
 }
 
 func TestEndSession_Success(t *testing.T) {
@@ -338,6 +496,62 @@ func TestEndSession_Success(t *testing.T) {
 	}
 }
 
+func TestEndSession_StatsFromReviews(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Test Deck", DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+	db.cards[1] = &storage.Card{ID: 1, DeckID: 1, CardKey: "new-card", Title: "New Card", Command: "true",
+		FSRSDue: time.Now().Add(-time.Hour)}
+	lastReview := time.Now().Add(-10 * 24 * time.Hour)
+	db.cards[2] = &storage.Card{ID: 2, DeckID: 1, CardKey: "known-card", Title: "Known Card", Command: "true",
+		FSRSDue: time.Now().Add(-time.Hour), FSRSReps: 3, FSRSState: int(fsrs.Review), FSRSStability: 10,
+		FSRSDifficulty: 5, FSRSScheduledDays: 10, FSRSLastReview: &lastReview}
+
+	ctx := context.Background()
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	session, err := service.StartSession(ctx, SessionOptions{})
+	if err != nil {
+		t.Fatalf("failed to start session: %v", err)
+	}
+
+	// The new card's command ran and passed; the known card was forgotten without running
+	passed := &domain.ExecutionResult{Success: true, Duration: 200 * time.Millisecond, ThinkingTime: 4 * time.Second}
+	if err := service.SubmitReview(ctx, session.ID, 1, domain.Good, passed); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+	notRun := &domain.ExecutionResult{ExitCode: -1, ThinkingTime: 2 * time.Second}
+	if err := service.SubmitReview(ctx, session.ID, 2, domain.Again, notRun); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+	// The lapsed card comes back in relearning
+	if err := service.SubmitReview(ctx, session.ID, 2, domain.Good, nil); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+
+	stats, err := service.EndSession(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("failed to end session: %v", err)
+	}
+
+	if stats.CardsReviewed != 3 || stats.NewCards != 1 || stats.ReviewCards != 1 || stats.RelearningCards != 1 {
+		t.Errorf("expected 3 reviews of 1 new, 1 review, and 1 relearning card, got %+v", stats)
+	}
+	if stats.AgainCount != 1 || stats.GoodCount != 2 || stats.HardCount != 0 || stats.EasyCount != 0 {
+		t.Errorf("unexpected rating counts: %+v", stats)
+	}
+	if want := 7.0 / 3; stats.AverageRating != want {
+		t.Errorf("expected average rating %.2f, got %.2f", want, stats.AverageRating)
+	}
+	if stats.AverageThinkingTime != 3*time.Second || stats.AverageExecutionTime != 200*time.Millisecond {
+		t.Errorf("expected 3s thinking and 200ms execution per card, got %v and %v", stats.AverageThinkingTime, stats.AverageExecutionTime)
+	}
+	if stats.CommandsRun != 1 || stats.CommandsSucceeded != 1 || stats.CommandSuccessRate != 1 {
+		t.Errorf("expected 1 of 1 commands to succeed, got %d of %d (%.2f)", stats.CommandsSucceeded, stats.CommandsRun, stats.CommandSuccessRate)
+	}
+	if len(stats.LapsedCards) != 1 || stats.LapsedCards[0].CardKey != "known-card" {
+		t.Errorf("expected known-card to have lapsed, got %+v", stats.LapsedCards)
+	}
+}
+
 func TestDomainRatingParsing(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -372,3 +586,1139 @@ func TestDomainRatingParsing(t *testing.T) {
 		})
 	}
 }
+
+func TestGetNextCard_MountsDeckAssets(t *testing.T) {
+	db := newMockDB()
+	deck := &storage.Deck{ID: 1, Name: "Fixtures", DefaultImage: "alpine:3.18", DefaultTimeout: 30, UpdatedAt: time.Now()}
+	db.decks[1] = deck
+	db.cards[1] = &storage.Card{ID: 1, DeckID: 1, CardKey: "jq", Title: "jq", Command: "jq . /assets/data/config.json"}
+	db.assets[1] = []*storage.DeckAsset{{DeckID: 1, Filename: "data/config.json", Content: []byte(`{"key": "value"}`)}}
+
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	defer service.Close()
+	ctx := context.Background()
+
+	session, err := service.StartSession(ctx, SessionOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	card, err := service.GetNextCard(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(card.AssetsDir, "data", "config.json"))
+	if err != nil || string(content) != `{"key": "value"}` {
+		t.Fatalf("expected asset to be written to the assets directory, got %q (%v)", content, err)
+	}
+
+	config := card.SandboxConfig("cat", "/assets/data/config.json")
+	if len(config.Mounts) != 1 || config.Mounts[0].Source != card.AssetsDir ||
+		config.Mounts[0].Target != sandbox.AssetsPath || !config.Mounts[0].ReadOnly {
+		t.Errorf("expected a read-only /assets mount, got %+v", config.Mounts)
+	}
+
+	// The same deck version reuses the directory; an updated deck gets a fresh one
+	again, err := service.GetNextCard(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again.AssetsDir != card.AssetsDir {
+		t.Errorf("expected assets directory to be reused, got %s and %s", card.AssetsDir, again.AssetsDir)
+	}
+
+	deck.UpdatedAt = deck.UpdatedAt.Add(time.Minute)
+	db.assets[1][0].Content = []byte(`{"key": "updated"}`)
+	refreshed, err := service.GetNextCard(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refreshed.AssetsDir == card.AssetsDir {
+		t.Error("expected a new assets directory after the deck changed")
+	}
+	if _, err := os.Stat(card.AssetsDir); !os.IsNotExist(err) {
+		t.Error("expected the stale assets directory to be removed")
+	}
+
+	if err := service.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if _, err := os.Stat(refreshed.AssetsDir); !os.IsNotExist(err) {
+		t.Error("expected Close to remove the assets directory")
+	}
+}
+
+func TestGetNextCard_NoAssetsNoMount(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Plain", DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+	db.cards[1] = &storage.Card{ID: 1, DeckID: 1, CardKey: "ls", Title: "ls", Command: "ls"}
+
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	ctx := context.Background()
+
+	session, err := service.StartSession(ctx, SessionOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	card, err := service.GetNextCard(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if card.AssetsDir != "" || len(card.SandboxConfig("ls").Mounts) != 0 {
+		t.Errorf("expected no assets mount for a deck without assets, got %q", card.AssetsDir)
+	}
+}
+
+// addPrerequisiteCards adds a chain basics <- files <- perms to deck 1 in the given mode
+func addPrerequisiteCards(db *mockDB, mode string) {
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Chain", DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+	db.cards[1] = &storage.Card{ID: 1, DeckID: 1, CardKey: "perms", Title: "Perms", Command: "chmod +x a",
+		Prerequisites: `["files"]`, PrerequisiteMode: mode}
+	db.cards[2] = &storage.Card{ID: 2, DeckID: 1, CardKey: "files", Title: "Files", Command: "touch a",
+		Prerequisites: `["basics"]`, PrerequisiteMode: mode}
+	db.cards[3] = &storage.Card{ID: 3, DeckID: 1, CardKey: "basics", Title: "Basics", Command: "ls",
+		Prerequisites: `[]`, PrerequisiteMode: mode}
+}
+
+func TestStartSession_EnforceHoldsBackNewCards(t *testing.T) {
+	db := newMockDB()
+	addPrerequisiteCards(db, PrerequisiteEnforce)
+
+	// basics has been learned; files is new and ready, perms still waits on files
+	db.cards[3].FSRSState = int(fsrs.Review)
+	db.cards[3].FSRSReps = 3
+	db.cards[3].FSRSDue = time.Now().Add(-time.Hour)
+
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	ctx := context.Background()
+
+	session, err := service.StartSession(ctx, SessionOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if session.CardsRemaining != 2 {
+		t.Errorf("expected perms to be held back leaving 2 cards, got %d", session.CardsRemaining)
+	}
+
+	explanation, err := service.ExplainQueue(ctx, SessionOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(explanation.HeldBack) != 1 || explanation.HeldBack[0].CardKey != "perms" ||
+		len(explanation.HeldBack[0].WaitingOn) != 1 || explanation.HeldBack[0].WaitingOn[0] != "files" {
+		t.Errorf("expected perms waiting on files, got %+v", explanation.HeldBack)
+	}
+
+	// Once every prerequisite is in Review nothing is held back
+	db.cards[2].FSRSState = int(fsrs.Review)
+	db.cards[2].FSRSReps = 2
+	db.cards[2].FSRSDue = time.Now().Add(24 * time.Hour)
+	explanation, err = service.ExplainQueue(ctx, SessionOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(explanation.HeldBack) != 0 {
+		t.Errorf("expected no held back cards, got %+v", explanation.HeldBack)
+	}
+}
+
+func TestStartSession_LinkQueuesPrerequisitesFirst(t *testing.T) {
+	db := newMockDB()
+	addPrerequisiteCards(db, PrerequisiteLink)
+
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	ctx := context.Background()
+
+	for range 5 {
+		explanation, err := service.ExplainQueue(ctx, SessionOptions{ShuffleCards: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(explanation.HeldBack) != 0 {
+			t.Errorf("link mode should not hold cards back, got %+v", explanation.HeldBack)
+		}
+
+		var keys []string
+		for _, card := range explanation.Queue {
+			keys = append(keys, card.CardKey)
+		}
+		if len(keys) != 3 || keys[0] != "basics" || keys[1] != "files" || keys[2] != "perms" {
+			t.Fatalf("expected prerequisites first, got %v", keys)
+		}
+		if explanation.Queue[2].Prerequisites[0] != "files" {
+			t.Errorf("expected perms to be explained as after files, got %+v", explanation.Queue[2])
+		}
+	}
+}
+
+func TestStartSession_AllCardsHeldBack(t *testing.T) {
+	db := newMockDB()
+	addPrerequisiteCards(db, PrerequisiteEnforce)
+
+	// basics is still learning and not due, so both of its dependents have to wait
+	db.cards[3].FSRSState = int(fsrs.Learning)
+	db.cards[3].FSRSReps = 1
+	db.cards[3].FSRSDue = time.Now().Add(24 * time.Hour)
+
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	_, err := service.StartSession(context.Background(), SessionOptions{})
+	if err == nil || !strings.Contains(err.Error(), "2 new cards are waiting on prerequisites") {
+		t.Errorf("expected held back cards to be reported, got %v", err)
+	}
+}
+
+func TestReviewCard_MatchSolution(t *testing.T) {
+	card := &ReviewCard{
+		Command:  "chmod +x main.py && ls -l main.py",
+		Solution: "chmod +x main.py | chmod 755 main.py",
+	}
+
+	tests := map[string]string{
+		"chmod +x main.py":      "chmod +x main.py",
+		"  chmod   755 main.py": "chmod 755 main.py",
+		"chmod u+x main.py":     "",
+		"":                      "",
+	}
+	for typed, expected := range tests {
+		if matched := card.MatchSolution(typed); matched != expected {
+			t.Errorf("MatchSolution(%q) = %q, expected %q", typed, matched, expected)
+		}
+	}
+
+	// Without a solution the card's command is the answer
+	card = &ReviewCard{Command: "ls | grep main.py"}
+	if matched := card.MatchSolution("ls | grep main.py"); matched != "ls | grep main.py" {
+		t.Errorf("expected the command to be accepted, got %q", matched)
+	}
+}
+
+// startAttemptSession starts a session on a single card and makes it current
+// sessionStatus returns the session's current progress
+func sessionStatus(t *testing.T, service *Service, sessionID string) *Session {
+	t.Helper()
+	session, err := service.SessionStatus(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("failed to get session status: %v", err)
+	}
+	return session
+}
+
+func startAttemptSession(t *testing.T, db *mockDB, sb *mockSandbox) (*Service, string, *ReviewCard) {
+	t.Helper()
+
+	service := NewService(db, scheduler.NewScheduler(), sb)
+	t.Cleanup(func() { service.Close() })
+	ctx := context.Background()
+
+	session, err := service.StartSession(ctx, SessionOptions{})
+	if err != nil {
+		t.Fatalf("failed to start session: %v", err)
+	}
+	card, err := service.GetNextCard(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	return service, session.ID, card
+}
+
+func TestAttemptCard_RunsSetupCommandVerifyAndCleanup(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Files", DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+	db.cards[1] = &storage.Card{
+		ID: 1, DeckID: 1, CardKey: "create-file", Title: "Create empty file",
+		Command: "touch main.py && ls", WorkingDir: "/workspace",
+		Setup: "mkdir -p my_project && cd my_project", Verify: "ls | grep main.py",
+		Cleanup: "cd /workspace && rm -rf my_project", Solution: "touch main.py && ls",
+	}
+
+	sb := newMockSandbox()
+	sb.results[sandbox.TrackWorkingDir("mkdir -p my_project && cd my_project")] = &sandbox.ExecutionResult{
+		Success: true, Stdout: "\n__ANCLI_CWD__=/workspace/my_project\n",
+	}
+	service, sessionID, card := startAttemptSession(t, db, sb)
+
+	attempt, err := service.AttemptCard(context.Background(), sessionID, card, ": > main.py")
+	if err != nil {
+		t.Fatalf("AttemptCard returned error: %v", err)
+	}
+	if attempt.Matched != "" || attempt.Verified == nil || !*attempt.Verified || !attempt.Correct {
+		t.Errorf("expected an unmatched command accepted by verify, got %+v", attempt)
+	}
+
+	var steps, dirs []string
+	for _, run := range sb.runs {
+		steps = append(steps, run.Command[len(run.Command)-1])
+		dirs = append(dirs, run.WorkingDir)
+	}
+	expectedSteps := []string{
+		sandbox.TrackWorkingDir("mkdir -p my_project && cd my_project"),
+		": > main.py",
+		"ls | grep main.py",
+		"cd /workspace && rm -rf my_project",
+	}
+	if strings.Join(steps, "\x00") != strings.Join(expectedSteps, "\x00") {
+		t.Errorf("expected steps %q, got %q", expectedSteps, steps)
+	}
+	for i, dir := range dirs[1:] {
+		if dir != "/workspace/my_project" {
+			t.Errorf("expected step %d to run where setup finished, got %s", i+1, dir)
+		}
+	}
+}
+
+func TestAttemptCard_VerifyFailure(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Files", DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+	db.cards[1] = &storage.Card{
+		ID: 1, DeckID: 1, CardKey: "create-dir", Title: "Create project directory",
+		Command: "mkdir my_project", Verify: "ls | grep my_project",
+	}
+
+	sb := newMockSandbox()
+	sb.results["ls | grep my_project"] = &sandbox.ExecutionResult{ExitCode: 1}
+	service, sessionID, card := startAttemptSession(t, db, sb)
+
+	attempt, err := service.AttemptCard(context.Background(), sessionID, card, "mkdir project")
+	if err != nil {
+		t.Fatalf("AttemptCard returned error: %v", err)
+	}
+	if attempt.Correct || attempt.Verified == nil || *attempt.Verified {
+		t.Errorf("expected a failed verify to mark the attempt incorrect, got %+v", attempt)
+	}
+
+	// Verify is the objective check, even when the typed command is a solution
+	attempt, err = service.AttemptCard(context.Background(), sessionID, card, "mkdir  my_project")
+	if err != nil {
+		t.Fatalf("AttemptCard returned error: %v", err)
+	}
+	if attempt.Correct || attempt.Matched != "mkdir my_project" {
+		t.Errorf("expected a matched solution to fail with verify, got %+v", attempt)
+	}
+
+	other := *card
+	other.ID = 2
+	if _, err := service.AttemptCard(context.Background(), sessionID, &other, "mkdir my_project"); err == nil {
+		t.Error("expected an error attempting a card that is not current")
+	}
+}
+
+func TestSuggestRating(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcome  Outcome
+		expected domain.Rating
+	}{
+		{"failed", Outcome{Passed: false, Attempts: 1, ThinkingTime: 5 * time.Second}, domain.Again},
+		{"quick", Outcome{Passed: true, Attempts: 1, ThinkingTime: 5 * time.Second}, domain.Easy},
+		{"steady", Outcome{Passed: true, Attempts: 1, ThinkingTime: 30 * time.Second}, domain.Good},
+		{"slow", Outcome{Passed: true, Attempts: 1, ThinkingTime: 2 * time.Minute}, domain.Hard},
+		{"retried", Outcome{Passed: true, Attempts: 2, ThinkingTime: 5 * time.Second}, domain.Hard},
+		{"revealed", Outcome{Passed: true, Attempts: 1, ThinkingTime: 5 * time.Second, HelpAccessed: true}, domain.Hard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rating := SuggestRating(tt.outcome); rating != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, rating)
+			}
+		})
+	}
+}
+
+func TestSubmitReview_RecordsObjectiveOutcome(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Files", DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+	db.cards[1] = &storage.Card{ID: 1, DeckID: 1, CardKey: "create-dir", Title: "Create", Command: "mkdir my_project"}
+	service, sessionID, card := startAttemptSession(t, db, newMockSandbox())
+
+	passed := true
+	err := service.SubmitReview(context.Background(), sessionID, card.ID, domain.Hard, &domain.ExecutionResult{
+		Success: true, Attempts: 3, AttemptPassed: &passed, SuggestedRating: domain.Easy,
+	})
+	if err != nil {
+		t.Fatalf("SubmitReview returned error: %v", err)
+	}
+
+	review := db.reviews[0]
+	if review.Attempts != 3 {
+		t.Errorf("expected 3 attempts to be recorded, got %d", review.Attempts)
+	}
+	if review.AttemptPassed == nil || !*review.AttemptPassed {
+		t.Errorf("expected attempt_passed to be recorded, got %v", review.AttemptPassed)
+	}
+	if review.SuggestedRating == nil || *review.SuggestedRating != int(domain.Easy) || review.Rating != int(domain.Hard) {
+		t.Errorf("expected suggested Easy and rated Hard, got suggested %v rated %d", review.SuggestedRating, review.Rating)
+	}
+}
+
+func TestShellAttempt_JudgedByVerifyWithTranscript(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Files", DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+	db.cards[1] = &storage.Card{
+		ID: 1, DeckID: 1, CardKey: "create-dir", Title: "Create project directory",
+		Command: "mkdir my_project", WorkingDir: "/workspace",
+		Setup: "cd /tmp", Verify: "ls | grep my_project",
+	}
+
+	sb := newMockSandbox()
+	sb.results[sandbox.TrackWorkingDir("cd /tmp")] = &sandbox.ExecutionResult{
+		Success: true, Stdout: "\n__ANCLI_CWD__=/tmp\n",
+	}
+	service, sessionID, card := startAttemptSession(t, db, sb)
+
+	var terminal bytes.Buffer
+	attempt, err := service.ShellAttempt(context.Background(), sessionID, card, sandbox.Terminal{Out: &terminal})
+	if err != nil {
+		t.Fatalf("ShellAttempt returned error: %v", err)
+	}
+	if !attempt.Interactive || !attempt.Judged() || !attempt.Correct {
+		t.Errorf("expected a verified interactive attempt despite the shell's exit status, got %+v", attempt)
+	}
+	if attempt.Result.Stdout != terminal.String() || !strings.Contains(attempt.Result.Stdout, "mkdir my_project") {
+		t.Errorf("expected the transcript in the result, got %q", attempt.Result.Stdout)
+	}
+
+	shell := sb.runs[1]
+	if shell.WorkingDir != "/tmp" {
+		t.Errorf("expected the shell to start where setup finished, got %s", shell.WorkingDir)
+	}
+	if rc := shell.Command[len(shell.Command)-1]; !strings.Contains(rc, "verify() {") || !strings.Contains(rc, "ls | grep my_project") {
+		t.Errorf("expected the shell to define verify, got %q", rc)
+	}
+}
+
+func TestShellAttempt_UnjudgedWithoutVerify(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Files", DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+	db.cards[1] = &storage.Card{ID: 1, DeckID: 1, CardKey: "list", Title: "List", Command: "ls"}
+	service, sessionID, card := startAttemptSession(t, db, newMockSandbox())
+
+	attempt, err := service.ShellAttempt(context.Background(), sessionID, card, sandbox.Terminal{Out: &bytes.Buffer{}})
+	if err != nil {
+		t.Fatalf("ShellAttempt returned error: %v", err)
+	}
+	if attempt.Judged() || attempt.Correct {
+		t.Errorf("expected a shell attempt without verify to be unjudged, got %+v", attempt)
+	}
+}
+
+func TestAttemptCard_RunsWithDeckShell(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Bash", DefaultImage: "bash:5", DefaultTimeout: 30, DefaultShell: "bash"}
+	db.cards[1] = &storage.Card{ID: 1, DeckID: 1, CardKey: "diff", Title: "Compare listings",
+		Command: "diff <(ls a) <(ls b)", Verify: "[[ -d a ]]"}
+
+	sb := newMockSandbox()
+	service, sessionID, card := startAttemptSession(t, db, sb)
+	ctx := context.Background()
+
+	if _, err := service.AttemptCard(ctx, sessionID, card, card.Command); err != nil {
+		t.Fatalf("AttemptCard returned error: %v", err)
+	}
+	for _, run := range sb.runs {
+		if run.Command[0] != "bash" || run.Command[1] != "-c" {
+			t.Errorf("expected every step to run with bash -c, got %q", run.Command)
+		}
+	}
+
+	// Interactive shells start the deck's shell too, with bash reading the verify function via --rcfile
+	if _, err := service.ShellAttempt(ctx, sessionID, card, sandbox.Terminal{Out: &bytes.Buffer{}}); err != nil {
+		t.Fatalf("ShellAttempt returned error: %v", err)
+	}
+	shell := sb.runs[len(sb.runs)-2]
+	if shell.Command[0] != "bash" || !strings.Contains(shell.Command[2], "exec bash --rcfile "+shellRC+" -i") {
+		t.Errorf("expected an interactive bash shell, got %q", shell.Command)
+	}
+}
+
+func TestGetNextCard_ResolvesRevealSettings(t *testing.T) {
+	tests := []struct {
+		settings         string
+		showSolutions    bool
+		showExplanations bool
+	}{
+		{"", true, true},
+		{"{}", true, true},
+		{`{"show_solutions":false}`, false, true},
+		{`{"show_solutions":true,"show_explanations":false}`, true, false},
+	}
+
+	for _, tt := range tests {
+		db := newMockDB()
+		db.decks[1] = &storage.Deck{ID: 1, Name: "Settings", DefaultImage: "alpine:3.18", DefaultTimeout: 30, Settings: tt.settings}
+		db.cards[1] = &storage.Card{ID: 1, DeckID: 1, CardKey: "ls", Title: "List", Command: "ls"}
+
+		_, _, card := startAttemptSession(t, db, newMockSandbox())
+		if card.ShowSolutions != tt.showSolutions || card.ShowExplanations != tt.showExplanations {
+			t.Errorf("settings %q: expected solutions=%v explanations=%v, got %v and %v", tt.settings,
+				tt.showSolutions, tt.showExplanations, card.ShowSolutions, card.ShowExplanations)
+		}
+	}
+}
+
+func TestPauseAndResumeSession(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Test Deck", DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+	for id := 1; id <= 3; id++ {
+		db.cards[id] = &storage.Card{ID: id, DeckID: 1, CardKey: fmt.Sprintf("card-%d", id), Title: "Card",
+			Command: "true", FSRSDue: time.Now().Add(-time.Hour)}
+	}
+
+	ctx := context.Background()
+	limits := DailyLimits{NewCards: 5, Reviews: 10}
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	service.SetDailyLimits(limits)
+	session, err := service.StartSession(ctx, SessionOptions{Mode: "recall"})
+	if err != nil {
+		t.Fatalf("failed to start session: %v", err)
+	}
+	if session.Allowance == nil || session.Allowance.NewCards == nil || *session.Allowance.NewCards != 5 {
+		t.Errorf("expected 5 new cards left when the session started, got %+v", session.Allowance)
+	}
+
+	first, err := service.GetNextCard(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	if err := service.SubmitReview(ctx, session.ID, first.ID, domain.Good, nil); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+	if db.reviews[0].SessionID == nil || *db.reviews[0].SessionID != session.ID {
+		t.Errorf("expected review linked to session %s, got %v", session.ID, db.reviews[0].SessionID)
+	}
+
+	// The second card is shown, then parked
+	parked, err := service.GetNextCard(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	if err := service.PauseSession(ctx, session.ID); err != nil {
+		t.Fatalf("failed to pause session: %v", err)
+	}
+	if db.sessions[session.ID].PausedAt == nil {
+		t.Error("expected paused session to record when it was paused")
+	}
+
+	// A card removed since the pause is dropped from the queue
+	var last int
+	for _, id := range []int{1, 2, 3} {
+		if id != first.ID && id != parked.ID {
+			last = id
+		}
+	}
+	db.cards[last].Archived = true
+
+	// A new service, as after restarting ancli, resumes from storage
+	resumedService := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	resumedService.SetDailyLimits(limits)
+	resumed, err := resumedService.ResumeSession(ctx)
+	if err != nil {
+		t.Fatalf("failed to resume session: %v", err)
+	}
+	// The card introduced before the pause counts against today's allowance
+	if allowance := resumed.Allowance; allowance == nil || allowance.NewCards == nil || *allowance.NewCards != 4 ||
+		allowance.Reviews == nil || *allowance.Reviews != 10 {
+		t.Errorf("expected 4 new cards and 10 reviews left after resuming, got %+v", allowance)
+	}
+	if resumed.ID != session.ID || !resumed.Resumed {
+		t.Errorf("expected session %s to be resumed, got %+v", session.ID, resumed)
+	}
+	if resumed.Options.Mode != "recall" {
+		t.Errorf("expected options to be restored, got %+v", resumed.Options)
+	}
+	// The first card, rated into a learning step, is still due again in the session
+	if resumed.CardsReviewed != 1 || resumed.CardsRemaining != 2 {
+		t.Errorf("expected 1 reviewed and 2 remaining, got %d and %d", resumed.CardsReviewed, resumed.CardsRemaining)
+	}
+	if learning := resumedService.sessions[resumed.ID].learning; len(learning) != 1 || learning[0].id != first.ID {
+		t.Errorf("expected card %d to be restored as a learning card, got %+v", first.ID, learning)
+	}
+
+	card, err := resumedService.GetNextCard(ctx, resumed.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	if card.ID != parked.ID {
+		t.Errorf("expected the parked card %d first, got %d", parked.ID, card.ID)
+	}
+	if err := resumedService.SubmitReview(ctx, resumed.ID, card.ID, domain.Good, nil); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+	if _, err := resumedService.EndSession(ctx, resumed.ID); err != nil {
+		t.Fatalf("failed to end session: %v", err)
+	}
+
+	if _, err := NewService(db, scheduler.NewScheduler(), newMockSandbox()).ResumeSession(ctx); !errors.Is(err, ErrNoSessionToResume) {
+		t.Errorf("expected ErrNoSessionToResume after the session ended, got %v", err)
+	}
+}
+
+func TestPreviewSchedule(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Test Deck", DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+	lastReview := time.Now().Add(-12 * 24 * time.Hour)
+	db.cards[1] = &storage.Card{ID: 1, DeckID: 1, CardKey: "new", Title: "New", Command: "true", FSRSDue: time.Now()}
+	db.cards[2] = &storage.Card{ID: 2, DeckID: 1, CardKey: "learned", Title: "Learned", Command: "true",
+		FSRSDue: time.Now().Add(-24 * time.Hour), FSRSStability: 10, FSRSDifficulty: 5, FSRSReps: 3,
+		FSRSState: int(fsrs.Review), FSRSLastReview: &lastReview}
+
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	ctx := context.Background()
+
+	preview, err := service.PreviewSchedule(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to preview schedule: %v", err)
+	}
+	if preview.Retrievability != 0 {
+		t.Errorf("expected no retrievability for a new card, got %f", preview.Retrievability)
+	}
+	if len(preview.Options) != 4 {
+		t.Fatalf("expected an option per rating, got %d", len(preview.Options))
+	}
+	for i, option := range preview.Options {
+		if option.Rating != domain.Rating(i+1) {
+			t.Errorf("expected option %d to be %s, got %s", i, domain.Rating(i+1), option.Rating)
+		}
+		if i > 0 && option.Interval < preview.Options[i-1].Interval {
+			t.Errorf("expected %s interval %v to be at least %s interval %v",
+				option.Rating, option.Interval, preview.Options[i-1].Rating, preview.Options[i-1].Interval)
+		}
+	}
+
+	preview, err = service.PreviewSchedule(ctx, 2)
+	if err != nil {
+		t.Fatalf("failed to preview schedule: %v", err)
+	}
+	if preview.Retrievability <= 0 || preview.Retrievability >= 1 {
+		t.Errorf("expected retrievability between 0 and 1 for a reviewed card, got %f", preview.Retrievability)
+	}
+	if good := preview.Options[domain.Good-1].Interval; good < 24*time.Hour {
+		t.Errorf("expected a reviewed card's Good interval to be days, got %v", good)
+	}
+
+	// Previewing leaves the card's schedule alone
+	if db.cards[2].FSRSReps != 3 || len(db.reviews) != 0 {
+		t.Error("expected preview not to change the card or record a review")
+	}
+}
+
+func TestSkipBuryAndSuspendCards(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Test Deck", DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+	for id := 1; id <= 4; id++ {
+		db.cards[id] = &storage.Card{ID: id, DeckID: 1, CardKey: fmt.Sprintf("card-%d", id), Title: "Card",
+			Command: "true", FSRSDue: time.Now().Add(-time.Hour)}
+	}
+
+	ctx := context.Background()
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	session, err := service.StartSession(ctx, SessionOptions{})
+	if err != nil {
+		t.Fatalf("failed to start session: %v", err)
+	}
+	queue := append([]int(nil), service.sessions[session.ID].cardQueue...)
+
+	// A skipped card goes to the back of the queue
+	first, err := service.GetNextCard(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	if err := service.SkipCard(ctx, session.ID, first.ID); err != nil {
+		t.Fatalf("failed to skip card: %v", err)
+	}
+	session = sessionStatus(t, service, session.ID)
+	if got := service.sessions[session.ID].cardQueue; got[len(got)-1] != first.ID || session.CardsRemaining != 4 {
+		t.Errorf("expected card %d at the back of 4 cards, got queue %v", first.ID, got)
+	}
+
+	// Buried and suspended cards leave the session and the cards' schedules alone
+	if err := service.BuryCard(ctx, session.ID, queue[1]); err != nil {
+		t.Fatalf("failed to bury card: %v", err)
+	}
+	if err := service.SuspendCard(ctx, session.ID, queue[2]); err != nil {
+		t.Fatalf("failed to suspend card: %v", err)
+	}
+	session = sessionStatus(t, service, session.ID)
+	if session.CardsRemaining != 2 || len(db.reviews) != 0 {
+		t.Errorf("expected 2 cards left and no reviews, got %d left and %d reviews", session.CardsRemaining, len(db.reviews))
+	}
+	buried := db.cards[queue[1]]
+	if buried.BuriedUntil == nil || !buried.BuriedUntil.After(time.Now()) || buried.BuriedUntil.After(time.Now().Add(24*time.Hour)) {
+		t.Errorf("expected card buried until tomorrow, got %v", buried.BuriedUntil)
+	}
+	if !db.cards[queue[2]].Suspended {
+		t.Error("expected card to be suspended")
+	}
+	if saved, _ := db.GetSession(session.ID); saved.Queue != fmt.Sprintf("[%d,%d]", queue[3], queue[0]) {
+		t.Errorf("expected the saved queue to match, got %s", saved.Queue)
+	}
+
+	// Neither is queued for the next session
+	next, err := service.StartSession(ctx, SessionOptions{})
+	if err != nil {
+		t.Fatalf("failed to start session: %v", err)
+	}
+	if next.CardsRemaining != 2 {
+		t.Errorf("expected 2 cards in the next session, got %d", next.CardsRemaining)
+	}
+
+	// Once the burial ends the card is back
+	past := time.Now().Add(-time.Minute)
+	buried.BuriedUntil = &past
+	later, err := service.StartSession(ctx, SessionOptions{})
+	if err != nil {
+		t.Fatalf("failed to start session: %v", err)
+	}
+	if later.CardsRemaining != 3 {
+		t.Errorf("expected the unburied card back, got %d cards", later.CardsRemaining)
+	}
+}
+
+func TestStartSession_SuspendedPrerequisiteDoesNotHoldBack(t *testing.T) {
+	db := newMockDB()
+	addPrerequisiteCards(db, PrerequisiteEnforce)
+	db.cards[3].Suspended = true
+
+	explanation, err := NewService(db, scheduler.NewScheduler(), newMockSandbox()).ExplainQueue(context.Background(), SessionOptions{})
+	if err != nil {
+		t.Fatalf("failed to explain queue: %v", err)
+	}
+	if len(explanation.Queue) != 1 || explanation.Queue[0].CardKey != "files" {
+		t.Errorf("expected only files to be queued, got %+v", explanation.Queue)
+	}
+	if len(explanation.HeldBack) != 1 || explanation.HeldBack[0].CardKey != "perms" {
+		t.Errorf("expected only perms to be held back, got %+v", explanation.HeldBack)
+	}
+}
+
+func TestSubmitReview_LapsedCardsComeBackInSession(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Test Deck", DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+	for id := 1; id <= 2; id++ {
+		db.cards[id] = &storage.Card{ID: id, DeckID: 1, CardKey: fmt.Sprintf("card-%d", id), Title: "Card",
+			Command: "true", FSRSDue: time.Now().Add(-time.Hour)}
+	}
+
+	ctx := context.Background()
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	session, err := service.StartSession(ctx, SessionOptions{})
+	if err != nil {
+		t.Fatalf("failed to start session: %v", err)
+	}
+	state := service.sessions[session.ID]
+	queue := append([]int(nil), state.cardQueue...)
+
+	// Again puts the card in a learning step minutes away, so it stays in the session
+	first, err := service.GetNextCard(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	if err := service.SubmitReview(ctx, session.ID, first.ID, domain.Again, nil); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+	session = sessionStatus(t, service, session.ID)
+	if session.CardsRemaining != 2 || len(state.learning) != 1 || state.learning[0].id != first.ID {
+		t.Fatalf("expected the lapsed card to stay in the session, got %d remaining and learning %+v", session.CardsRemaining, state.learning)
+	}
+	if saved, _ := db.GetSession(session.ID); saved.Learning != fmt.Sprintf("[%d]", first.ID) {
+		t.Errorf("expected the learning card to be saved, got %s", saved.Learning)
+	}
+
+	// Cards that are due come first; the learning card isn't due yet
+	next, err := service.GetNextCard(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	if next.ID != queue[1] {
+		t.Fatalf("expected card %d before the learning card, got %d", queue[1], next.ID)
+	}
+
+	// Once it is due, the learning card is shown ahead of the queue
+	state.learning[0].due = time.Now().Add(-time.Second)
+	if id, _ := state.nextCardID(time.Now()); id != first.ID {
+		t.Errorf("expected the due learning card next, got %d", id)
+	}
+	state.learning[0].due = time.Now().Add(10 * time.Minute)
+
+	// Easy graduates the card out of learning, so it leaves the session
+	if err := service.SubmitReview(ctx, session.ID, next.ID, domain.Easy, nil); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+	session = sessionStatus(t, service, session.ID)
+	if session.CardsRemaining != 1 {
+		t.Errorf("expected only the learning card to remain, got %d", session.CardsRemaining)
+	}
+
+	// With the queue empty the session doesn't finish: the learning card is shown early
+	again, err := service.GetNextCard(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("expected the learning card to be shown early, got %v", err)
+	}
+	if again.ID != first.ID {
+		t.Errorf("expected card %d again, got %d", first.ID, again.ID)
+	}
+	if err := service.SubmitReview(ctx, session.ID, again.ID, domain.Easy, nil); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+	session = sessionStatus(t, service, session.ID)
+	if session.CardsRemaining != 0 || session.CardsReviewed != 3 {
+		t.Errorf("expected the session done after 3 reviews, got %d remaining and %d reviewed", session.CardsRemaining, session.CardsReviewed)
+	}
+	if _, err := service.GetNextCard(ctx, session.ID); !errors.Is(err, ErrQueueEmpty) {
+		t.Errorf("expected ErrQueueEmpty, got %v", err)
+	}
+}
+
+func TestLearningCardsOrderedByDue(t *testing.T) {
+	now := time.Now()
+	state := &sessionState{}
+	state.addLearning(1, now.Add(10*time.Minute))
+	state.addLearning(2, now.Add(time.Minute))
+	state.addLearning(3, now.Add(10*time.Minute))
+
+	var order []int
+	for _, card := range state.learning {
+		order = append(order, card.id)
+	}
+	if fmt.Sprint(order) != "[2 1 3]" {
+		t.Errorf("expected learning cards ordered by due time, got %v", order)
+	}
+}
+
+func TestStartSession_DailyLimits(t *testing.T) {
+	db := newMockDB()
+	now := time.Now()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Limited", DefaultImage: "alpine:3.18"}
+	db.decks[2] = &storage.Deck{ID: 2, Name: "Unlimited new", DefaultImage: "alpine:3.18", Settings: `{"new_cards_per_day": 0, "reviews_per_day": 1}`}
+
+	// Deck 1 has introduced one new card today, before this session
+	db.cards[1] = &storage.Card{ID: 1, DeckID: 1, CardKey: "seen", FSRSReps: 1, FSRSState: 1, FSRSDue: now.Add(time.Hour)}
+	db.reviews = append(db.reviews, storage.Review{CardID: 1, Rating: 3, ReviewedAt: now, FSRSStateBefore: 0})
+	for id := 2; id <= 4; id++ {
+		db.cards[id] = &storage.Card{ID: id, DeckID: 1, CardKey: fmt.Sprintf("new-%d", id), FSRSDue: now.Add(-time.Duration(id) * time.Minute)}
+	}
+	for id := 5; id <= 6; id++ {
+		db.cards[id] = &storage.Card{ID: id, DeckID: 1, CardKey: fmt.Sprintf("due-%d", id), FSRSReps: 3, FSRSState: 2, FSRSDue: now.Add(-time.Hour)}
+	}
+	for id := 7; id <= 9; id++ {
+		db.cards[id] = &storage.Card{ID: id, DeckID: 2, CardKey: fmt.Sprintf("other-%d", id), FSRSDue: now.Add(-time.Minute)}
+	}
+	db.cards[10] = &storage.Card{ID: 10, DeckID: 2, CardKey: "other-due-1", FSRSReps: 2, FSRSState: 2, FSRSDue: now.Add(-time.Hour)}
+	db.cards[11] = &storage.Card{ID: 11, DeckID: 2, CardKey: "other-due-2", FSRSReps: 2, FSRSState: 2, FSRSDue: now.Add(-time.Hour)}
+
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	service.SetDailyLimits(DailyLimits{NewCards: 2, Reviews: 1})
+
+	explanation, err := service.ExplainQueue(context.Background(), SessionOptions{MaxCards: 20})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	perDeck := map[int]map[string]int{1: {}, 2: {}}
+	for _, queued := range explanation.Queue {
+		card := db.cards[queued.CardID]
+		if card.FSRSReps == 0 {
+			perDeck[card.DeckID]["new"]++
+		} else {
+			perDeck[card.DeckID]["review"]++
+		}
+	}
+	if perDeck[1]["new"] != 1 || perDeck[1]["review"] != 1 {
+		t.Errorf("expected deck 1 limited to 1 new card and 1 review, got %v", perDeck[1])
+	}
+	if perDeck[2]["new"] != 3 || perDeck[2]["review"] != 1 {
+		t.Errorf("expected deck 2 to show all 3 new cards and 1 review, got %v", perDeck[2])
+	}
+
+	allowance := explanation.Allowance
+	if allowance == nil || allowance.NewCards != nil {
+		t.Errorf("expected unlimited new cards left, got %+v", allowance)
+	}
+	if allowance != nil && (allowance.Reviews == nil || *allowance.Reviews != 2) {
+		t.Errorf("expected 2 reviews left today, got %v", allowance.Reviews)
+	}
+}
+
+func TestStartSession_DailyLimitsKeepMostOverdueReviews(t *testing.T) {
+	db := newMockDB()
+	now := time.Now()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Reviews", DefaultImage: "alpine:3.18"}
+
+	// Card keys sort the least overdue card first
+	overdue := map[string]time.Duration{"a-recent": time.Hour, "b-oldest": 10 * 24 * time.Hour, "c-older": 3 * 24 * time.Hour}
+	id := 0
+	for key, by := range overdue {
+		id++
+		db.cards[id] = &storage.Card{ID: id, DeckID: 1, CardKey: key, FSRSReps: 3, FSRSState: int(fsrs.Review), FSRSDue: now.Add(-by)}
+	}
+
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	service.SetDailyLimits(DailyLimits{Reviews: 2})
+
+	explanation, err := service.ExplainQueue(context.Background(), SessionOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var keys []string
+	for _, queued := range explanation.Queue {
+		keys = append(keys, queued.CardKey)
+	}
+	if want := []string{"b-oldest", "c-older"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("expected the two most overdue reviews %v, got %v", want, keys)
+	}
+}
+
+func TestStartSession_DailyLimitsKeepLinkedPrerequisites(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Linked", DefaultImage: "alpine:3.18"}
+
+	// The dependent card's key sorts ahead of its prerequisite's
+	db.cards[1] = &storage.Card{ID: 1, DeckID: 1, CardKey: "a-archive", Prerequisites: `["b-basics"]`, PrerequisiteMode: PrerequisiteLink}
+	db.cards[2] = &storage.Card{ID: 2, DeckID: 1, CardKey: "b-basics", PrerequisiteMode: PrerequisiteLink}
+	db.cards[3] = &storage.Card{ID: 3, DeckID: 1, CardKey: "c-copy", PrerequisiteMode: PrerequisiteLink}
+
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	service.SetDailyLimits(DailyLimits{NewCards: 1})
+
+	session, err := service.StartSession(context.Background(), SessionOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	card, err := service.GetNextCard(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	if session.CardsRemaining != 1 || card.CardKey != "b-basics" {
+		t.Errorf("expected only the prerequisite b-basics, got %s of %d cards", card.CardKey, session.CardsRemaining)
+	}
+}
+
+func TestUndoReview_RestoresPriorStateAndRequeues(t *testing.T) {
+	db := newMockDB()
+	now := time.Now()
+	lastReview := now.Add(-10 * 24 * time.Hour)
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Test Deck", DefaultImage: "alpine:3.18"}
+	db.cards[1] = &storage.Card{
+		ID: 1, DeckID: 1, CardKey: "mastered", FSRSDue: now.Add(-time.Hour), FSRSStability: 12.5, FSRSDifficulty: 4.2,
+		FSRSElapsedDays: 10, FSRSScheduledDays: 10, FSRSReps: 5, FSRSLapses: 1, FSRSState: 2, FSRSLastReview: &lastReview,
+	}
+	db.cards[2] = &storage.Card{ID: 2, DeckID: 1, CardKey: "fresh", FSRSDue: now.Add(-time.Minute)}
+	before := map[int]storage.Card{1: *db.cards[1], 2: *db.cards[2]}
+
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	ctx := context.Background()
+	session, err := service.StartSession(ctx, SessionOptions{MaxCards: 10})
+	if err != nil {
+		t.Fatalf("failed to start session: %v", err)
+	}
+
+	var rated []int
+	for _, rating := range []domain.Rating{domain.Again, domain.Good} {
+		card, err := service.GetNextCard(ctx, session.ID)
+		if err != nil {
+			t.Fatalf("failed to get next card: %v", err)
+		}
+		if err := service.SubmitReview(ctx, session.ID, card.ID, rating, nil); err != nil {
+			t.Fatalf("failed to submit review: %v", err)
+		}
+		rated = append(rated, card.ID)
+	}
+
+	// Each undo takes back the latest remaining rating
+	for i := len(rated) - 1; i >= 0; i-- {
+		undone, err := service.UndoReview(ctx, session.ID)
+		if err != nil {
+			t.Fatalf("failed to undo: %v", err)
+		}
+		if undone.CardID != rated[i] {
+			t.Errorf("expected to undo card %d, undid %d", rated[i], undone.CardID)
+		}
+
+		restored, want := *db.cards[rated[i]], before[rated[i]]
+		restored.UpdatedAt = want.UpdatedAt
+		if !reflect.DeepEqual(restored, want) {
+			t.Errorf("card %d not restored exactly:\n got %+v\nwant %+v", rated[i], restored, want)
+		}
+		if len(db.reviews) != i {
+			t.Errorf("expected %d reviews left, got %d", i, len(db.reviews))
+		}
+		session = sessionStatus(t, service, session.ID)
+		if session.CardsReviewed != i || session.CardsRemaining != 2 {
+			t.Errorf("expected %d reviewed and 2 remaining, got %d and %d", i, session.CardsReviewed, session.CardsRemaining)
+		}
+
+		next, err := service.GetNextCard(ctx, session.ID)
+		if err != nil {
+			t.Fatalf("failed to get next card: %v", err)
+		}
+		if next.ID != rated[i] {
+			t.Errorf("expected the undone card %d next, got %d", rated[i], next.ID)
+		}
+	}
+
+	if _, err := service.UndoReview(ctx, session.ID); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("expected ErrNothingToUndo, got %v", err)
+	}
+}
+
+func TestUndoReview_RefusesReviewWithoutPriorState(t *testing.T) {
+	db := newMockDB()
+	db.decks[1] = &storage.Deck{ID: 1, Name: "Test Deck", DefaultImage: "alpine:3.18"}
+	db.cards[1] = &storage.Card{ID: 1, DeckID: 1, CardKey: "legacy", FSRSDue: time.Now().Add(-time.Minute)}
+
+	service := NewService(db, scheduler.NewScheduler(), newMockSandbox())
+	ctx := context.Background()
+	session, err := service.StartSession(ctx, SessionOptions{MaxCards: 10})
+	if err != nil {
+		t.Fatalf("failed to start session: %v", err)
+	}
+	card, err := service.GetNextCard(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("failed to get next card: %v", err)
+	}
+	if err := service.SubmitReview(ctx, session.ID, card.ID, domain.Good, nil); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+
+	// As recorded before the card's prior state was kept
+	legacy := &db.reviews[0]
+	legacy.FSRSElapsedDaysBefore, legacy.FSRSScheduledDaysBefore = nil, nil
+	legacy.FSRSRepsBefore, legacy.FSRSLapsesBefore = nil, nil
+	rated := *db.cards[1]
+
+	if _, err := service.UndoReview(ctx, session.ID); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("expected ErrNothingToUndo, got %v", err)
+	}
+	if !reflect.DeepEqual(*db.cards[1], rated) {
+		t.Errorf("expected the card left as rated, got %+v", *db.cards[1])
+	}
+	if len(db.reviews) != 1 {
+		t.Errorf("expected the review kept, got %d reviews", len(db.reviews))
+	}
+}
+
+func TestConcurrentSessions(t *testing.T) {
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "ancli.db"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	const cardsPerDeck = 10
+	var deckIDs []int
+	for _, name := range []string{"files", "network"} {
+		deck := &storage.Deck{Name: name, DefaultImage: "alpine:3.18", DefaultTimeout: 30}
+		if err := db.CreateDeck(deck); err != nil {
+			t.Fatalf("failed to create deck: %v", err)
+		}
+		for i := range cardsPerDeck {
+			card := &storage.Card{DeckID: deck.ID, CardKey: fmt.Sprintf("%s-%d", name, i), Title: name, Command: "true", Verify: "true"}
+			if err := db.CreateCard(card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+		}
+		deckIDs = append(deckIDs, deck.ID)
+	}
+
+	sb := newMockSandbox()
+	service := NewService(db, scheduler.NewScheduler(), sb)
+	defer service.Close()
+	ctx := context.Background()
+
+	// One learner per deck reviews its whole queue while a watcher reads its progress
+	sessionIDs := make([]string, len(deckIDs))
+	var wg sync.WaitGroup
+	for i, deckID := range deckIDs {
+		session, err := service.StartSession(ctx, SessionOptions{DeckID: &deckID})
+		if err != nil {
+			t.Fatalf("failed to start session: %v", err)
+		}
+		sessionIDs[i] = session.ID
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := reviewSession(ctx, service, session); err != nil {
+				t.Errorf("session %s failed: %v", session.ID, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			watchProgress(t, service, session.ID, cardsPerDeck)
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	if sessionIDs[0] == sessionIDs[1] {
+		t.Fatalf("expected distinct sessions, got %s twice", sessionIDs[0])
+	}
+
+	runs := make(map[string]int)
+	for _, run := range sb.runs {
+		runs[run.SessionID]++
+	}
+	for _, sessionID := range sessionIDs {
+		if runs[sessionID] != 2*cardsPerDeck {
+			t.Errorf("expected %d runs in session %s's container, got %d (runs by session: %v)", 2*cardsPerDeck, sessionID, runs[sessionID], runs)
+		}
+	}
+	if len(sb.cleaned) != 2 || sb.cleaned[0] == sb.cleaned[1] {
+		t.Errorf("expected each session's container cleaned up once, got %v", sb.cleaned)
+	}
+
+	reviews, err := db.GetReviewsBySession(sessionIDs[0])
+	if err != nil {
+		t.Fatalf("failed to get reviews: %v", err)
+	}
+	for _, review := range reviews {
+		if card, _ := db.GetCard(review.CardID); card.DeckID != deckIDs[0] {
+			t.Errorf("session %s reviewed card %d from another deck", sessionIDs[0], review.CardID)
+		}
+	}
+	if len(reviews) != cardsPerDeck {
+		t.Errorf("expected %d reviews in session %s, got %d", cardsPerDeck, sessionIDs[0], len(reviews))
+	}
+}
+
+// reviewSession reviews a session's whole queue, passing and rating every card Easy
+func reviewSession(ctx context.Context, service *Service, session *Session) error {
+	for session.CardsRemaining > 0 {
+		card, err := service.GetNextCard(ctx, session.ID)
+		if err != nil {
+			return err
+		}
+		attempt, err := service.AttemptCard(ctx, session.ID, card, "true")
+		if err != nil {
+			return err
+		}
+		if !attempt.Correct {
+			return fmt.Errorf("expected card %s to pass", card.CardKey)
+		}
+		if err := service.SubmitReview(ctx, session.ID, card.ID, domain.Easy, nil); err != nil {
+			return err
+		}
+		if session, err = service.SessionStatus(ctx, session.ID); err != nil {
+			return err
+		}
+	}
+	_, err := service.EndSession(ctx, session.ID)
+	return err
+}
+
+// watchProgress reads a session's progress until it ends, checking it only moves forward
+func watchProgress(t *testing.T, service *Service, sessionID string, cards int) {
+	reviewed := 0
+	for {
+		status, err := service.SessionStatus(context.Background(), sessionID)
+		if err != nil {
+			return // The session ended
+		}
+		if status.CardsReviewed < reviewed || status.CardsReviewed+status.CardsRemaining != cards {
+			t.Errorf("session %s went from %d reviewed to %d reviewed and %d remaining", sessionID, reviewed, status.CardsReviewed, status.CardsRemaining)
+			return
+		}
+		reviewed = status.CardsReviewed
+	}
+}
@@ -0,0 +1,212 @@
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/justinlyon12/ancli/internal/storage"
+)
+
+// ErrNoSessionToResume is returned by ResumeSession when every session has ended
+var ErrNoSessionToResume = errors.New("no unfinished review session to resume")
+
+// PauseSession parks the session so ResumeSession can continue it later
+// A card that was shown but not rated stays at the front of the queue, so the
+// resumed session starts with it. The session is released from memory.
+func (s *Service) PauseSession(ctx context.Context, sessionID string) error {
+	state, err := s.lockSession(sessionID)
+	if err != nil {
+		return err
+	}
+	defer state.mu.Unlock()
+
+	pausedAt := time.Now()
+	if err := s.saveSession(state, func(record *storage.Session) { record.PausedAt = &pausedAt }); err != nil {
+		return err
+	}
+
+	s.closeSession(ctx, state)
+	return nil
+}
+
+// ResumeSession continues the most recently active session that has not ended
+// Sessions are saved as they progress, so this covers sessions that were paused and
+// ones cut short by a crash. Cards deleted, archived, suspended, or buried since are
+// dropped from the queue. The daily allowance is recomputed for the remaining cards.
+func (s *Service) ResumeSession(ctx context.Context) (*Session, error) {
+	record, err := s.storage.GetUnfinishedSession()
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrNoSessionToResume
+		}
+		return nil, fmt.Errorf("failed to find session to resume: %w", err)
+	}
+
+	if _, exists := s.activeSession(record.ID); exists {
+		return s.SessionStatus(ctx, record.ID)
+	}
+
+	var opts SessionOptions
+	if err := json.Unmarshal([]byte(record.Options), &opts); err != nil {
+		return nil, fmt.Errorf("invalid options for session %s: %w", record.ID, err)
+	}
+
+	queue, err := record.ParseQueue()
+	if err != nil {
+		return nil, err
+	}
+	queueCards, err := s.reviewableCards(queue)
+	if err != nil {
+		return nil, err
+	}
+	learningIDs, err := record.ParseLearning()
+	if err != nil {
+		return nil, err
+	}
+	learningCards, err := s.reviewableCards(learningIDs)
+	if err != nil {
+		return nil, err
+	}
+	// Report what is left of today's limits now, as a new session would
+	_, allowance, err := s.dailyAllowances(append(queueCards, learningCards...), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply daily limits: %w", err)
+	}
+
+	state := &sessionState{
+		Session: &Session{
+			ID:            record.ID,
+			StartedAt:     record.StartedAt,
+			DeckID:        record.DeckID,
+			Options:       opts,
+			CardsReviewed: record.CardsReviewed,
+			Resumed:       true,
+			Allowance:     allowance,
+		},
+		cardQueue:    make([]int, 0, len(queueCards)),
+		activeBefore: time.Duration(record.ActiveMs) * time.Millisecond,
+		resumedAt:    time.Now(),
+	}
+	for _, card := range queueCards {
+		state.cardQueue = append(state.cardQueue, card.ID)
+	}
+	for _, card := range learningCards {
+		state.addLearning(card.ID, card.FSRSDue)
+	}
+	state.CardsRemaining = state.remaining()
+
+	if state.CardsRemaining == 0 {
+		endedAt := time.Now()
+		if err := s.saveSession(state, func(record *storage.Session) { record.EndedAt = &endedAt }); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoSessionToResume
+	}
+
+	if err := s.saveSession(state); err != nil {
+		return nil, err
+	}
+
+	// Another call may have resumed the same session meanwhile; keep the first
+	snapshot := state.snapshot()
+	s.mu.Lock()
+	_, exists := s.sessions[record.ID]
+	if !exists {
+		s.sessions[record.ID] = state
+	}
+	s.mu.Unlock()
+	if exists {
+		return s.SessionStatus(ctx, record.ID)
+	}
+	return snapshot, nil
+}
+
+// activeSession returns the session if it is running in this service
+func (s *Service) activeSession(sessionID string) (*sessionState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, exists := s.sessions[sessionID]
+	return state, exists
+}
+
+// reviewableCards loads the cards of a saved queue that can still be reviewed, in order
+func (s *Service) reviewableCards(queue []int) ([]*storage.Card, error) {
+	reviewable := make([]*storage.Card, 0, len(queue))
+	now := time.Now()
+	for _, cardID := range queue {
+		card, err := s.storage.GetCard(cardID)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get card %d: %w", cardID, err)
+		}
+		if card.Archived || setAsideNow(card, now) {
+			continue
+		}
+		reviewable = append(reviewable, card)
+	}
+	return reviewable, nil
+}
+
+// saveSession persists the session's progress
+// edits adjust the record before it is saved, e.g. to mark the session ended.
+func (s *Service) saveSession(state *sessionState, edits ...func(record *storage.Session)) error {
+	record, err := state.record()
+	if err != nil {
+		return err
+	}
+	for _, edit := range edits {
+		edit(record)
+	}
+	if err := s.storage.UpdateSession(record); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// record converts the session state to its storage form
+func (st *sessionState) record() (*storage.Session, error) {
+	options, err := json.Marshal(st.Options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode session options: %w", err)
+	}
+
+	queue := st.cardQueue
+	if queue == nil {
+		queue = []int{}
+	}
+	queueJSON, err := json.Marshal(queue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode session queue: %w", err)
+	}
+
+	learning := make([]int, 0, len(st.learning))
+	for _, card := range st.learning {
+		learning = append(learning, card.id)
+	}
+	learningJSON, err := json.Marshal(learning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode session learning cards: %w", err)
+	}
+
+	return &storage.Session{
+		ID:            st.ID,
+		DeckID:        st.DeckID,
+		Options:       string(options),
+		Queue:         string(queueJSON),
+		Learning:      string(learningJSON),
+		CurrentCardID: st.CurrentCardID,
+		CardsReviewed: st.CardsReviewed,
+		ActiveMs:      st.activeTime().Milliseconds(),
+		StartedAt:     st.StartedAt,
+	}, nil
+}
+
+// activeTime returns how long the session has been reviewed for, excluding pauses
+func (st *sessionState) activeTime() time.Duration {
+	return st.activeBefore + time.Since(st.resumedAt)
+}
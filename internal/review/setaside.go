@@ -0,0 +1,91 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/justinlyon12/ancli/internal/storage"
+)
+
+// SkipCard moves a card to the end of the session's queue without rating it
+// A card waiting on a learning step is moved to the end of the queue as well.
+func (s *Service) SkipCard(ctx context.Context, sessionID string, cardID int) error {
+	state, err := s.lockSession(sessionID)
+	if err != nil {
+		return err
+	}
+	defer state.mu.Unlock()
+	if !state.dequeue(cardID) {
+		return fmt.Errorf("card %d is not in session %s", cardID, sessionID)
+	}
+
+	state.cardQueue = append(state.cardQueue, cardID)
+	state.CurrentCardID = nil
+	return s.saveSession(state)
+}
+
+// BuryCard takes a card out of the session and keeps it out of sessions until tomorrow
+// Its schedule is unchanged, so it is due again as soon as the burial ends.
+func (s *Service) BuryCard(ctx context.Context, sessionID string, cardID int) error {
+	until := startOfNextDay(time.Now()).UTC()
+	return s.setAside(sessionID, cardID, func(card *storage.Card) { card.BuriedUntil = &until })
+}
+
+// SuspendCard takes a card out of the session and out of every session until it is
+// unsuspended, e.g. with 'ancli card unsuspend'
+func (s *Service) SuspendCard(ctx context.Context, sessionID string, cardID int) error {
+	return s.setAside(sessionID, cardID, func(card *storage.Card) { card.Suspended = true })
+}
+
+// setAside applies edit to a card and drops it from the session's queue
+func (s *Service) setAside(sessionID string, cardID int, edit func(card *storage.Card)) error {
+	state, err := s.lockSession(sessionID)
+	if err != nil {
+		return err
+	}
+	defer state.mu.Unlock()
+
+	card, err := s.storage.GetCard(cardID)
+	if err != nil {
+		return fmt.Errorf("failed to get card: %w", err)
+	}
+	edit(card)
+	if err := s.storage.UpdateCard(card); err != nil {
+		return fmt.Errorf("failed to update card: %w", err)
+	}
+
+	state.dequeue(cardID)
+	state.CardsRemaining = state.remaining()
+	state.CurrentCardID = nil
+	return s.saveSession(state)
+}
+
+// dequeue removes a card from the session's queue or learning cards and reports
+// whether it was there
+func (st *sessionState) dequeue(cardID int) bool {
+	for i, id := range st.cardQueue {
+		if id == cardID {
+			st.cardQueue = append(st.cardQueue[:i:i], st.cardQueue[i+1:]...)
+			return true
+		}
+	}
+	for i, card := range st.learning {
+		if card.id == cardID {
+			st.learning = append(st.learning[:i:i], st.learning[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// setAsideNow reports whether a card is suspended or still buried at now
+func setAsideNow(card *storage.Card, now time.Time) bool {
+	return card.Suspended || (card.BuriedUntil != nil && card.BuriedUntil.After(now))
+}
+
+// startOfNextDay returns midnight at the start of the day after now, in now's location
+func startOfNextDay(now time.Time) time.Time {
+	year, month, day := now.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+}
@@ -0,0 +1,102 @@
+package review
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/justinlyon12/ancli/internal/domain"
+	"github.com/justinlyon12/ancli/internal/storage"
+)
+
+// LapsedCard is a card that was in Review and was forgotten during the session
+type LapsedCard struct {
+	CardID  int    `json:"card_id"`
+	CardKey string `json:"card_key"`
+	Title   string `json:"title"`
+}
+
+// sessionStats tallies the reviews made in a session
+// Every review counts, so a card rated again after a lapse or learning step is
+// counted once for each rating. Duration and CardsReviewed are left to the caller.
+func (s *Service) sessionStats(sessionID string) (*SessionStats, error) {
+	reviews, err := s.storage.GetReviewsBySession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session reviews: %w", err)
+	}
+
+	stats := &SessionStats{SessionID: sessionID, LapsedCards: []LapsedCard{}}
+	var ratingTotal int
+	var thinking, execution time.Duration
+	var thinkingCount, executionCount int
+	lapsed := make(map[int]bool)
+
+	for _, review := range reviews {
+		switch domain.CardState(review.FSRSStateBefore) {
+		case domain.StateNew:
+			stats.NewCards++
+		case domain.StateLearning:
+			stats.LearningCards++
+		case domain.StateReview:
+			stats.ReviewCards++
+		case domain.StateRelearning:
+			stats.RelearningCards++
+		}
+
+		switch domain.Rating(review.Rating) {
+		case domain.Again:
+			stats.AgainCount++
+		case domain.Hard:
+			stats.HardCount++
+		case domain.Good:
+			stats.GoodCount++
+		case domain.Easy:
+			stats.EasyCount++
+		}
+		ratingTotal += review.Rating
+
+		if review.ThinkingTimeMs != nil {
+			thinking += time.Duration(*review.ThinkingTimeMs) * time.Millisecond
+			thinkingCount++
+		}
+		if review.ExecutionTimeMs != nil {
+			execution += time.Duration(*review.ExecutionTimeMs) * time.Millisecond
+			executionCount++
+		}
+
+		// A negative exit code means the command never ran
+		if review.ExitCode != nil && *review.ExitCode >= 0 {
+			stats.CommandsRun++
+			if review.ExecutionSuccess {
+				stats.CommandsSucceeded++
+			}
+		}
+
+		if domain.CardState(review.FSRSStateBefore) == domain.StateReview && domain.Rating(review.Rating) == domain.Again && !lapsed[review.CardID] {
+			lapsed[review.CardID] = true
+			card, err := s.storage.GetCard(review.CardID)
+			if err != nil {
+				if errors.Is(err, storage.ErrNotFound) {
+					continue
+				}
+				return nil, fmt.Errorf("failed to get card %d: %w", review.CardID, err)
+			}
+			stats.LapsedCards = append(stats.LapsedCards, LapsedCard{CardID: card.ID, CardKey: card.CardKey, Title: card.Title})
+		}
+	}
+
+	if len(reviews) > 0 {
+		stats.AverageRating = float64(ratingTotal) / float64(len(reviews))
+	}
+	if thinkingCount > 0 {
+		stats.AverageThinkingTime = thinking / time.Duration(thinkingCount)
+	}
+	if executionCount > 0 {
+		stats.AverageExecutionTime = execution / time.Duration(executionCount)
+	}
+	if stats.CommandsRun > 0 {
+		stats.CommandSuccessRate = float64(stats.CommandsSucceeded) / float64(stats.CommandsRun)
+	}
+
+	return stats, nil
+}
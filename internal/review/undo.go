@@ -0,0 +1,71 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/justinlyon12/ancli/internal/domain"
+	"github.com/justinlyon12/ancli/internal/storage"
+)
+
+// ErrNothingToUndo is returned by UndoReview when the session has no ratings left to undo
+var ErrNothingToUndo = errors.New("no ratings to undo in this session")
+
+// UndoneReview is a rating taken back by UndoReview
+type UndoneReview struct {
+	CardID  int           `json:"card_id"`
+	CardKey string        `json:"card_key"`
+	Title   string        `json:"title"`
+	Rating  domain.Rating `json:"rating"`
+}
+
+// UndoReview takes back the session's most recent rating
+// The card gets back the exact FSRS state it had before, the review is deleted, and
+// the card goes to the front of the queue to be rated again. Calling it again undoes
+// the rating before that, back to the start of the session. A rating recorded before
+// the card's prior state was kept cannot be undone and is refused with ErrNothingToUndo.
+func (s *Service) UndoReview(ctx context.Context, sessionID string) (*UndoneReview, error) {
+	state, err := s.lockSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer state.mu.Unlock()
+
+	reviews, err := s.storage.GetReviewsBySession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session reviews: %w", err)
+	}
+	if len(reviews) == 0 {
+		return nil, ErrNothingToUndo
+	}
+	last := reviews[len(reviews)-1]
+	if !last.HasPriorState() {
+		return nil, fmt.Errorf("%w: %w", ErrNothingToUndo, storage.ErrNoPriorState)
+	}
+
+	if err := s.storage.RevertReview(last); err != nil {
+		return nil, fmt.Errorf("failed to undo review: %w", err)
+	}
+	card, err := s.storage.GetCard(last.CardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get card: %w", err)
+	}
+
+	// A card waiting on a learning step is shown now instead
+	state.dequeue(card.ID)
+	state.cardQueue = append([]int{card.ID}, state.cardQueue...)
+	state.CardsReviewed = max(state.CardsReviewed-1, 0)
+	state.CardsRemaining = state.remaining()
+	state.CurrentCardID = nil
+	if err := s.saveSession(state); err != nil {
+		return nil, err
+	}
+
+	return &UndoneReview{
+		CardID:  card.ID,
+		CardKey: card.CardKey,
+		Title:   card.Title,
+		Rating:  domain.Rating(last.Rating),
+	}, nil
+}
@@ -2,9 +2,14 @@ package sandbox
 
 import (
 	"fmt"
+	"path"
+	"path/filepath"
 	"time"
 )
 
+// AssetsPath is where deck assets are mounted inside the container
+const AssetsPath = "/assets"
+
 // ExecutionConfig defines the parameters for sandboxed command execution
 type ExecutionConfig struct {
 	// Container configuration
@@ -21,6 +26,9 @@ type ExecutionConfig struct {
 	ReadOnlyRootFS bool
 	TmpfsMounts    map[string]string
 
+	// Host directories bind-mounted into the container
+	Mounts []Mount
+
 	// Resource limits
 	Timeout     time.Duration // Per-command timeout, not container lifetime
 	MemoryLimit string        // e.g., "128m"
@@ -28,6 +36,19 @@ type ExecutionConfig struct {
 
 	// Tracing and logging
 	CorrelationID string
+
+	// Session whose container the command runs in; commands of one session share a
+	// container and other sessions get their own. Empty is a session of its own too.
+	SessionID string
+}
+
+// Mount binds a host directory into the container
+// Mounts are fixed when the container starts; a driver that reuses containers
+// must start a new one when the mounts change.
+type Mount struct {
+	Source   string // Absolute host path
+	Target   string // Absolute path inside the container
+	ReadOnly bool
 }
 
 // ContainerLifecycle defines how containers are managed across card executions
@@ -85,12 +106,24 @@ func (c ExecutionConfig) WithTimeout(timeout time.Duration) ExecutionConfig {
 	return c
 }
 
+// WithMount adds a host directory mount
+func (c ExecutionConfig) WithMount(mount Mount) ExecutionConfig {
+	c.Mounts = append(append([]Mount(nil), c.Mounts...), mount)
+	return c
+}
+
 // WithCorrelationID sets the correlation ID for tracing
 func (c ExecutionConfig) WithCorrelationID(id string) ExecutionConfig {
 	c.CorrelationID = id
 	return c
 }
 
+// WithSessionID sets the session whose container the command runs in
+func (c ExecutionConfig) WithSessionID(id string) ExecutionConfig {
+	c.SessionID = id
+	return c
+}
+
 // Validate checks that required fields are set
 func (c ExecutionConfig) Validate() error {
 	if c.Image == "" {
@@ -102,5 +135,10 @@ func (c ExecutionConfig) Validate() error {
 	if c.Timeout <= 0 {
 		return fmt.Errorf("timeout must be positive")
 	}
+	for _, m := range c.Mounts {
+		if !filepath.IsAbs(m.Source) || !path.IsAbs(m.Target) {
+			return fmt.Errorf("mount %s:%s must use absolute paths", m.Source, m.Target)
+		}
+	}
 	return nil
 }
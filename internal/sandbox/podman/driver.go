@@ -3,9 +3,12 @@ package podman
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os/exec"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -15,15 +18,24 @@ import (
 )
 
 // Driver implements the sandbox interface using Podman
-// Supports both per-card and session-reuse container lifecycles
+// Supports both per-card and session-reuse container lifecycles. Each session
+// reuses its own container, so sessions can run concurrently.
 type Driver struct {
 	podmanPath string
 	lifecycle  sandbox.ContainerLifecycle
 
-	// Container state (protected by mutex)
-	mu            sync.Mutex
-	containerID   string
-	containerName string
+	// Session containers (map protected by mutex)
+	mu         sync.Mutex
+	containers map[string]*sessionContainer // By session ID
+}
+
+// sessionContainer is the container one session's commands run in
+type sessionContainer struct {
+	mu      sync.Mutex // Held while the container is checked, started, or replaced
+	id      string
+	name    string
+	options string // Signature of the options the running container was started with
+	removed bool   // Cleaned up and dropped from the driver; a new handle replaces it
 }
 
 // init registers the Podman driver with the sandbox registry
@@ -44,9 +56,26 @@ func New() (*Driver, error) {
 	return &Driver{
 		podmanPath: podmanPath,
 		lifecycle:  sandbox.SessionReuse, // Default to session-reuse for performance
+		containers: make(map[string]*sessionContainer),
 	}, nil
 }
 
+// container returns the session's container handle, creating an empty one if needed
+func (d *Driver) container(sessionID string) *sessionContainer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.containers == nil {
+		d.containers = make(map[string]*sessionContainer)
+	}
+	c, ok := d.containers[sessionID]
+	if !ok {
+		c = &sessionContainer{}
+		d.containers[sessionID] = c
+	}
+	return c
+}
+
 // Name returns the driver identifier
 func (d *Driver) Name() string {
 	return "podman"
@@ -62,6 +91,7 @@ func (d *Driver) Run(ctx context.Context, config sandbox.ExecutionConfig) (*sand
 	startTime := time.Now()
 	logger := slog.With(
 		"correlation_id", config.CorrelationID,
+		"session_id", config.SessionID,
 		"driver", "podman",
 		"image", config.Image,
 		"lifecycle", d.lifecycle,
@@ -87,50 +117,114 @@ func (d *Driver) runPerCard(ctx context.Context, config sandbox.ExecutionConfig,
 // runSessionReuse reuses a container across multiple commands in a session
 func (d *Driver) runSessionReuse(ctx context.Context, config sandbox.ExecutionConfig, logger *slog.Logger, startTime time.Time) (*sandbox.ExecutionResult, error) {
 	// Ensure we have a running container for this session
-	if err := d.ensureContainer(ctx, config, logger); err != nil {
+	containerID, err := d.ensureContainer(ctx, config, logger)
+	if err != nil {
 		return nil, fmt.Errorf("failed to ensure container: %w", err)
 	}
 
 	// Execute command in the running container
-	return d.execInContainer(ctx, config, logger, startTime)
+	return d.execInContainer(ctx, containerID, config, logger, startTime)
 }
 
-// ensureContainer starts a container if one isn't already running
-func (d *Driver) ensureContainer(ctx context.Context, config sandbox.ExecutionConfig, logger *slog.Logger) error {
-	d.mu.Lock()
-	defer d.mu.Unlock()
+// ensureContainer starts a container for the config's session if one isn't already
+// running, and returns its ID
+func (d *Driver) ensureContainer(ctx context.Context, config sandbox.ExecutionConfig, logger *slog.Logger) (string, error) {
+	c := d.container(config.SessionID)
+	c.mu.Lock()
+	for c.removed {
+		// The session was cleaned up while we waited, so start from a new handle
+		c.mu.Unlock()
+		c = d.container(config.SessionID)
+		c.mu.Lock()
+	}
+	defer c.mu.Unlock()
+
+	// The image, network, capabilities, environment, and mounts are set when a
+	// container starts, so a card that needs different ones gets a new container
+	options := containerSignature(config)
+	if c.id != "" && c.options != options {
+		logger.Debug("container options changed, replacing session container", "old_container_id", c.id)
+		if err := d.removeContainer(ctx, c.id); err != nil {
+			logger.Warn("failed to remove replaced container", "error", err)
+		}
+		c.id = ""
+		c.name = ""
+	}
 
-	if d.containerID != "" {
+	if c.id != "" {
 		// Check if container is running (not just exists)
-		checkCmd := exec.CommandContext(ctx, d.podmanPath, "container", "inspect", d.containerID, "--format", "{{.State.Running}}")
+		checkCmd := exec.CommandContext(ctx, d.podmanPath, "container", "inspect", c.id, "--format", "{{.State.Running}}")
 		var output bytes.Buffer
 		checkCmd.Stdout = &output
 
 		if checkCmd.Run() == nil && strings.TrimSpace(output.String()) == "true" {
-			logger.Debug("reusing existing running container", "container_id", d.containerID)
-			return nil
+			logger.Debug("reusing existing running container", "container_id", c.id)
+			return c.id, nil
 		}
 
 		// Container doesn't exist or isn't running, clear the state
-		logger.Debug("container not running, starting new one", "old_container_id", d.containerID)
-		d.containerID = ""
-		d.containerName = ""
+		logger.Debug("container not running, starting new one", "old_container_id", c.id)
+		c.id = ""
+		c.name = ""
 	}
 
 	// Generate a unique container name
-	d.containerName = fmt.Sprintf("ancli-session-%d", time.Now().UnixNano())
+	c.name = fmt.Sprintf("ancli-session-%d", time.Now().UnixNano())
 
 	// Build podman run command for long-running container
+	args := runArgs(c.name, config)
+
+	logger.Debug("starting session container", "args", args)
+
+	// Start the container - capture stdout only for container ID
+	cmd := exec.CommandContext(ctx, d.podmanPath, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to start container: %w, stderr: %s", err, stderr.String())
+	}
+
+	// Extract container ID from stdout only (ignore stderr warnings)
+	c.id = strings.TrimSpace(stdout.String())
+	if c.id == "" {
+		return "", fmt.Errorf("failed to get container ID from stdout")
+	}
+
+	c.options = options
+
+	logger.Info("started session container", "container_id", c.id, "name", c.name)
+	return c.id, nil
+}
+
+// volumeSpec formats a mount as a podman --volume argument
+// The z option relabels the source for SELinux so rootless containers can read it.
+func volumeSpec(m sandbox.Mount) string {
+	mode := "rw"
+	if m.ReadOnly {
+		mode = "ro"
+	}
+	return fmt.Sprintf("%s:%s:%s,z", m.Source, m.Target, mode)
+}
+
+// runArgs builds the podman run arguments for a long-running session container
+func runArgs(name string, config sandbox.ExecutionConfig) []string {
 	args := []string{
 		"run",
-		"--detach",                // Run in background
-		"--name", d.containerName, // Named container for reuse
+		"--detach",     // Run in background
+		"--name", name, // Named container for reuse
 		"--cap-drop=ALL",                             // Drop all capabilities
 		"--security-opt=no-new-privileges",           // Prevent privilege escalation
 		"--read-only",                                // Read-only root filesystem
 		"--tmpfs", "/tmp:rw,noexec,nosuid,size=100m", // Writable /tmp with security
 	}
 
+	// Add back only the capabilities the card asks for
+	for _, capability := range config.Capabilities {
+		args = append(args, "--cap-add="+capability)
+	}
+
 	// Add network configuration
 	if !config.NetworkEnabled {
 		args = append(args, "--network=none")
@@ -146,6 +240,11 @@ func (d *Driver) ensureContainer(ctx context.Context, config sandbox.ExecutionCo
 		args = append(args, "--env", fmt.Sprintf("%s=%s", key, value))
 	}
 
+	// Add host directory mounts
+	for _, m := range config.Mounts {
+		args = append(args, "--volume", volumeSpec(m))
+	}
+
 	// Add resource limits
 	if config.MemoryLimit != "" {
 		args = append(args, "--memory", config.MemoryLimit)
@@ -155,51 +254,37 @@ func (d *Driver) ensureContainer(ctx context.Context, config sandbox.ExecutionCo
 	}
 
 	// Add image and keep-alive command
-	args = append(args, config.Image, "sleep", "3600") // Keep container alive for 1 hour
-
-	logger.Debug("starting session container", "args", args)
-
-	// Start the container - capture stdout only for container ID
-	cmd := exec.CommandContext(ctx, d.podmanPath, args...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	return append(args, config.Image, "sleep", "3600") // Keep container alive for 1 hour
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to start container: %w, stderr: %s", err, stderr.String())
+// containerSignature identifies the options a session container is started with, so
+// container reuse can detect a card that needs different ones
+func containerSignature(config sandbox.ExecutionConfig) string {
+	capabilities := append([]string(nil), config.Capabilities...)
+	sort.Strings(capabilities)
+	env := make([]string, 0, len(config.Environment))
+	for key, value := range config.Environment {
+		env = append(env, key+"="+value)
 	}
+	sort.Strings(env)
 
-	// Extract container ID from stdout only (ignore stderr warnings)
-	d.containerID = strings.TrimSpace(stdout.String())
-	if d.containerID == "" {
-		return fmt.Errorf("failed to get container ID from stdout")
-	}
+	return fmt.Sprintf("image=%q network=%t capabilities=%q env=%q mounts=%q",
+		config.Image, config.NetworkEnabled, capabilities, env, mountSignature(config.Mounts))
+}
 
-	logger.Info("started session container", "container_id", d.containerID, "name", d.containerName)
-	return nil
+// mountSignature identifies a set of mounts so container reuse can detect changes
+func mountSignature(mounts []sandbox.Mount) string {
+	specs := make([]string, len(mounts))
+	for i, m := range mounts {
+		specs[i] = volumeSpec(m)
+	}
+	return strings.Join(specs, " ")
 }
 
 // execInContainer executes a command in the running session container
-func (d *Driver) execInContainer(ctx context.Context, config sandbox.ExecutionConfig, logger *slog.Logger, startTime time.Time) (*sandbox.ExecutionResult, error) {
-	d.mu.Lock()
-	containerID := d.containerID
-	d.mu.Unlock()
-
+func (d *Driver) execInContainer(ctx context.Context, containerID string, config sandbox.ExecutionConfig, logger *slog.Logger, startTime time.Time) (*sandbox.ExecutionResult, error) {
 	// Build podman exec command
-	args := []string{"exec"}
-
-	// Add working directory for this specific command
-	if config.WorkingDir != "" {
-		args = append(args, "--workdir", config.WorkingDir)
-	}
-
-	// Add environment variables for this specific command
-	for key, value := range config.Environment {
-		args = append(args, "--env", fmt.Sprintf("%s=%s", key, value))
-	}
-
-	args = append(args, containerID)
-	args = append(args, config.Command...)
+	args := execArgs(containerID, config)
 
 	// Create context with command timeout
 	execCtx, cancel := context.WithTimeout(ctx, config.Timeout)
@@ -218,20 +303,9 @@ func (d *Driver) execInContainer(ctx context.Context, config sandbox.ExecutionCo
 	duration := time.Since(startTime)
 
 	// Extract exit code
-	exitCode := 0
+	exitCode := exitStatus(err)
 	success := err == nil
 
-	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				exitCode = status.ExitStatus()
-			}
-		} else {
-			// Non-exit error (e.g., timeout)
-			exitCode = -1
-		}
-	}
-
 	result := &sandbox.ExecutionResult{
 		ExitCode:      exitCode,
 		Success:       success,
@@ -260,23 +334,152 @@ func (d *Driver) execInContainer(ctx context.Context, config sandbox.ExecutionCo
 	return result, nil
 }
 
-// Cleanup stops and removes the session container
-func (d *Driver) Cleanup(ctx context.Context) error {
+// Shell attaches an interactive PTY shell in the session container to term
+// The shell runs with podman exec --interactive --tty, so podman puts term.In into raw
+// mode and the shell exits with the learner. Everything written to the terminal is
+// also kept as the result's Stdout.
+func (d *Driver) Shell(ctx context.Context, config sandbox.ExecutionConfig, term sandbox.Terminal) (*sandbox.ExecutionResult, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if d.lifecycle != sandbox.SessionReuse {
+		return nil, fmt.Errorf("interactive shells require the %s lifecycle", sandbox.SessionReuse)
+	}
+
+	startTime := time.Now()
+	logger := slog.With(
+		"correlation_id", config.CorrelationID,
+		"session_id", config.SessionID,
+		"driver", "podman",
+		"image", config.Image,
+	)
+
+	containerID, err := d.ensureContainer(ctx, config, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure container: %w", err)
+	}
+
+	logger.Debug("attaching interactive shell", "command", config.Command, "workdir", config.WorkingDir)
+
+	var transcript bytes.Buffer
+	cmd := exec.CommandContext(ctx, d.podmanPath, execArgs(containerID, config, "--interactive", "--tty")...)
+	cmd.Stdin = term.In
+	cmd.Stdout = io.MultiWriter(term.Out, &transcript)
+	cmd.Stderr = cmd.Stdout
+
+	err = cmd.Run()
+	exitCode := exitStatus(err)
+	result := &sandbox.ExecutionResult{
+		ExitCode:      exitCode,
+		Success:       err == nil,
+		Stdout:        transcript.String(),
+		StartedAt:     startTime,
+		Duration:      time.Since(startTime),
+		ContainerID:   containerID,
+		ImageUsed:     config.Image,
+		CorrelationID: config.CorrelationID,
+	}
+
+	logger.Info("interactive shell ended",
+		"exit_code", exitCode,
+		"duration_ms", result.Duration.Milliseconds(),
+		"transcript_bytes", len(result.Stdout),
+	)
+
+	if err != nil && exitCode == -1 {
+		return result, fmt.Errorf("interactive shell failed: %w", err)
+	}
+	return result, nil
+}
+
+// execArgs builds the podman exec arguments for running config in a container
+func execArgs(containerID string, config sandbox.ExecutionConfig, flags ...string) []string {
+	args := append([]string{"exec"}, flags...)
+
+	// Add working directory for this specific command
+	if config.WorkingDir != "" {
+		args = append(args, "--workdir", config.WorkingDir)
+	}
+
+	// Add environment variables for this specific command
+	for key, value := range config.Environment {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", key, value))
+	}
+
+	args = append(args, containerID)
+	return append(args, config.Command...)
+}
+
+// exitStatus extracts a command's exit code, or -1 when it did not exit normally
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitError, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus()
+		}
+		return 0
+	}
+	// Non-exit error (e.g., timeout)
+	return -1
+}
+
+// Cleanup stops and removes a session's container
+func (d *Driver) Cleanup(ctx context.Context, sessionID string) error {
 	d.mu.Lock()
-	containerID := d.containerID
-	containerName := d.containerName
-	d.containerID = ""
-	d.containerName = ""
+	c, ok := d.containers[sessionID]
+	delete(d.containers, sessionID)
 	d.mu.Unlock()
 
-	if containerID == "" {
+	if !ok {
 		return nil // Nothing to clean up
 	}
 
-	logger := slog.With("container_id", containerID, "driver", "podman")
+	// Wait for a container being started to finish starting, then remove it
+	c.mu.Lock()
+	containerID, containerName := c.id, c.name
+	c.id, c.name, c.options = "", "", ""
+	c.removed = true
+	c.mu.Unlock()
+
+	if containerID == "" {
+		return nil
+	}
+
+	logger := slog.With("container_id", containerID, "session_id", sessionID, "driver", "podman")
 	logger.Debug("cleaning up session container")
 
-	// Stop and remove the container
+	if err := d.removeContainer(ctx, containerID); err != nil {
+		return err
+	}
+
+	logger.Info("session container cleaned up", "container_name", containerName)
+	return nil
+}
+
+// CleanupAll stops and removes every session's container
+func (d *Driver) CleanupAll(ctx context.Context) error {
+	d.mu.Lock()
+	sessionIDs := make([]string, 0, len(d.containers))
+	for sessionID := range d.containers {
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+	d.mu.Unlock()
+
+	var errs []error
+	for _, sessionID := range sessionIDs {
+		if err := d.Cleanup(ctx, sessionID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeContainer stops and removes a container
+func (d *Driver) removeContainer(ctx context.Context, containerID string) error {
+	logger := slog.With("container_id", containerID, "driver", "podman")
+
 	stopCmd := exec.CommandContext(ctx, d.podmanPath, "container", "stop", containerID)
 	if err := stopCmd.Run(); err != nil {
 		logger.Warn("failed to stop container", "error", err)
@@ -288,7 +491,6 @@ func (d *Driver) Cleanup(ctx context.Context) error {
 		return fmt.Errorf("failed to remove container %s: %w", containerID, err)
 	}
 
-	logger.Info("session container cleaned up", "container_name", containerName)
 	return nil
 }
 
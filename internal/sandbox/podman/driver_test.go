@@ -31,8 +31,8 @@ func TestNew(t *testing.T) {
 		t.Errorf("expected default lifecycle SessionReuse, got %s", driver.lifecycle)
 	}
 
-	if driver.containerID != "" {
-		t.Errorf("expected empty container ID on new driver, got %s", driver.containerID)
+	if len(driver.containers) != 0 {
+		t.Errorf("expected no session containers on new driver, got %d", len(driver.containers))
 	}
 }
 
@@ -140,12 +140,38 @@ func TestCleanupWithoutContainer(t *testing.T) {
 	driver := &Driver{podmanPath: "/usr/bin/podman"}
 
 	ctx := context.Background()
-	err := driver.Cleanup(ctx)
+	err := driver.Cleanup(ctx, "session")
 
 	// Cleanup should not fail when no container exists
 	if err != nil {
 		t.Errorf("cleanup should not fail with no container, got: %v", err)
 	}
+	if err := driver.CleanupAll(ctx); err != nil {
+		t.Errorf("cleanup of all sessions should not fail with no containers, got: %v", err)
+	}
+}
+
+func TestSessionContainerHandles(t *testing.T) {
+	driver := &Driver{podmanPath: "/usr/bin/podman"}
+
+	first, second := driver.container("first"), driver.container("second")
+	if first == second {
+		t.Fatal("expected each session to get its own container handle")
+	}
+	if driver.container("first") != first {
+		t.Error("expected a session to keep its container handle")
+	}
+
+	// Cleaning up one session drops only its handle
+	if err := driver.Cleanup(context.Background(), "first"); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	if !first.removed || driver.container("first") == first {
+		t.Error("expected the cleaned up session to get a new handle")
+	}
+	if driver.container("second") != second {
+		t.Error("expected the other session's handle to be kept")
+	}
 }
 
 func TestDriverRegistration(t *testing.T) {
@@ -210,6 +236,92 @@ func TestSecurityDefaults(t *testing.T) {
 	}
 }
 
+func TestVolumeSpecAndMountSignature(t *testing.T) {
+	assets := sandbox.Mount{Source: "/tmp/ancli-assets-1", Target: sandbox.AssetsPath, ReadOnly: true}
+	if got := volumeSpec(assets); got != "/tmp/ancli-assets-1:/assets:ro,z" {
+		t.Errorf("unexpected read-only volume spec: %s", got)
+	}
+
+	writable := sandbox.Mount{Source: "/tmp/work", Target: "/work"}
+	if got := volumeSpec(writable); got != "/tmp/work:/work:rw,z" {
+		t.Errorf("unexpected writable volume spec: %s", got)
+	}
+
+	if mountSignature(nil) != "" {
+		t.Error("expected empty signature without mounts")
+	}
+	refreshed := assets
+	refreshed.Source = "/tmp/ancli-assets-2"
+	if mountSignature([]sandbox.Mount{assets}) == mountSignature([]sandbox.Mount{refreshed}) {
+		t.Error("expected signature to change when a mount source changes")
+	}
+}
+
+func TestContainerSignatureAndRunArgs(t *testing.T) {
+	base := sandbox.ExecutionConfig{
+		Image:       "alpine:3.18",
+		Environment: map[string]string{"USER": "student", "HOME": "/workspace"},
+		Mounts:      []sandbox.Mount{{Source: "/tmp/ancli-assets-1", Target: sandbox.AssetsPath, ReadOnly: true}},
+	}
+
+	// Anything fixed when the container starts changes the signature
+	changes := map[string]func(*sandbox.ExecutionConfig){
+		"image":        func(c *sandbox.ExecutionConfig) { c.Image = "ubuntu:24.04" },
+		"network":      func(c *sandbox.ExecutionConfig) { c.NetworkEnabled = true },
+		"capabilities": func(c *sandbox.ExecutionConfig) { c.Capabilities = []string{"NET_RAW"} },
+		"environment":  func(c *sandbox.ExecutionConfig) { c.Environment = map[string]string{"USER": "root"} },
+		"mounts":       func(c *sandbox.ExecutionConfig) { c.Mounts = nil },
+	}
+	for name, change := range changes {
+		changed := base
+		change(&changed)
+		if containerSignature(changed) == containerSignature(base) {
+			t.Errorf("expected the signature to change with the %s", name)
+		}
+	}
+
+	// Per-exec options and ordering do not
+	same := base
+	same.Command = []string{"ls"}
+	same.WorkingDir = "/tmp"
+	same.Environment = map[string]string{"HOME": "/workspace", "USER": "student"}
+	if containerSignature(same) != containerSignature(base) {
+		t.Error("expected the signature to ignore the command and working directory")
+	}
+	withCaps := base
+	withCaps.Capabilities = []string{"NET_RAW", "CHOWN"}
+	reordered := base
+	reordered.Capabilities = []string{"CHOWN", "NET_RAW"}
+	if containerSignature(withCaps) != containerSignature(reordered) {
+		t.Error("expected the signature to ignore capability order")
+	}
+
+	got := strings.Join(runArgs("ancli-session-1", withCaps), " ")
+	for _, want := range []string{"--cap-drop=ALL --security-opt", "--cap-add=NET_RAW --cap-add=CHOWN", "--network=none", "alpine:3.18 sleep 3600"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected run args to contain %q, got %q", want, got)
+		}
+	}
+	withCaps.NetworkEnabled = true
+	if got := strings.Join(runArgs("ancli-session-1", withCaps), " "); strings.Contains(got, "--network") {
+		t.Errorf("expected no network flag for a networked card, got %q", got)
+	}
+}
+
+func TestExecArgs(t *testing.T) {
+	config := sandbox.ExecutionConfig{
+		Command:     []string{"/bin/sh", "-i"},
+		WorkingDir:  "/workspace",
+		Environment: map[string]string{"USER": "student"},
+	}
+
+	got := strings.Join(execArgs("abc123", config, "--interactive", "--tty"), " ")
+	expected := "exec --interactive --tty --workdir /workspace --env USER=student abc123 /bin/sh -i"
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
 func TestConcurrentAccess(t *testing.T) {
 	_, err := exec.LookPath("podman")
 	if err != nil {
@@ -228,7 +340,7 @@ func TestConcurrentAccess(t *testing.T) {
 	// Start cleanup in one goroutine
 	go func() {
 		defer close(done)
-		err := driver.Cleanup(context.Background())
+		err := driver.Cleanup(context.Background(), "session")
 		errors <- err
 	}()
 
@@ -236,7 +348,7 @@ func TestConcurrentAccess(t *testing.T) {
 	go func() {
 		<-done
 		driver.mu.Lock()
-		_ = driver.containerID // Access protected field
+		_ = driver.containers["session"] // Access protected field
 		driver.mu.Unlock()
 		errors <- nil
 	}()
@@ -4,6 +4,8 @@ package podman
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -26,7 +28,7 @@ func TestPodmanIntegration(t *testing.T) {
 	defer func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		driver.Cleanup(ctx)
+		driver.CleanupAll(ctx)
 	}()
 
 	tests := []struct {
@@ -163,7 +165,7 @@ func TestPodmanSessionReuse(t *testing.T) {
 	defer func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		driver.Cleanup(ctx)
+		driver.CleanupAll(ctx)
 	}()
 
 	ctx := context.Background()
@@ -212,6 +214,28 @@ func TestPodmanSessionReuse(t *testing.T) {
 	if result3.Stdout != "test\n" {
 		t.Errorf("expected 'test\\n', got %q", result3.Stdout)
 	}
+
+	// Another session gets its own container, without the first one's state
+	other := config.WithSessionID("other").WithCommand("sh", "-c", "cat /tmp/state || echo missing")
+	result4, err := driver.Run(ctx, other)
+	if err != nil {
+		t.Fatalf("other session's command failed: %v", err)
+	}
+	if result4.ContainerID == containerID1 || result4.Stdout != "missing\n" {
+		t.Errorf("expected a separate container for another session, got %s with %q", result4.ContainerID, result4.Stdout)
+	}
+
+	// Cleaning up that session leaves the first one's container running
+	if err := driver.Cleanup(ctx, "other"); err != nil {
+		t.Fatalf("cleanup of other session failed: %v", err)
+	}
+	result5, err := driver.Run(ctx, config.WithCommand("cat", "/tmp/state"))
+	if err != nil {
+		t.Fatalf("command after other session's cleanup failed: %v", err)
+	}
+	if result5.ContainerID != containerID1 || result5.Stdout != "test\n" {
+		t.Errorf("expected the first session's container to be kept, got %s with %q", result5.ContainerID, result5.Stdout)
+	}
 }
 
 func TestPodmanSecurityHardening(t *testing.T) {
@@ -229,7 +253,7 @@ func TestPodmanSecurityHardening(t *testing.T) {
 	defer func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		driver.Cleanup(ctx)
+		driver.CleanupAll(ctx)
 	}()
 
 	ctx := context.Background()
@@ -290,3 +314,66 @@ func TestPodmanSecurityHardening(t *testing.T) {
 		})
 	}
 }
+
+func TestPodmanReadOnlyAssetsMount(t *testing.T) {
+	// Skip if podman is not available
+	if err := IsAvailable(); err != nil {
+		t.Skipf("podman not available: %v", err)
+	}
+
+	driver, err := New()
+	if err != nil {
+		t.Fatalf("failed to create podman driver: %v", err)
+	}
+
+	// Cleanup after test
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		driver.CleanupAll(ctx)
+	}()
+
+	first, second := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(first, "config.json"), []byte("first\n"), 0644); err != nil {
+		t.Fatalf("failed to write asset: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(second, "config.json"), []byte("second\n"), 0644); err != nil {
+		t.Fatalf("failed to write asset: %v", err)
+	}
+
+	ctx := context.Background()
+	config := sandbox.NewExecutionConfig().
+		WithImage("alpine:latest").
+		WithCorrelationID("test-assets")
+
+	result, err := driver.Run(ctx, config.
+		WithMount(sandbox.Mount{Source: first, Target: sandbox.AssetsPath, ReadOnly: true}).
+		WithCommand("cat", "/assets/config.json"))
+	if err != nil {
+		t.Fatalf("reading asset failed: %v", err)
+	}
+	if result.Stdout != "first\n" {
+		t.Errorf("expected 'first\\n', got %q", result.Stdout)
+	}
+
+	result, err = driver.Run(ctx, config.
+		WithMount(sandbox.Mount{Source: first, Target: sandbox.AssetsPath, ReadOnly: true}).
+		WithCommand("touch", "/assets/new"))
+	if err != nil {
+		t.Fatalf("write attempt failed to run: %v", err)
+	}
+	if result.Success {
+		t.Error("expected /assets to be read-only")
+	}
+
+	// A different mount source must replace the session container
+	refreshed, err := driver.Run(ctx, config.
+		WithMount(sandbox.Mount{Source: second, Target: sandbox.AssetsPath, ReadOnly: true}).
+		WithCommand("cat", "/assets/config.json"))
+	if err != nil {
+		t.Fatalf("reading refreshed asset failed: %v", err)
+	}
+	if refreshed.Stdout != "second\n" || refreshed.ContainerID == result.ContainerID {
+		t.Errorf("expected a new container with refreshed assets, got %q in %s", refreshed.Stdout, refreshed.ContainerID)
+	}
+}
@@ -2,6 +2,7 @@ package sandbox
 
 import (
 	"context"
+	"io"
 	"time"
 )
 
@@ -10,8 +11,17 @@ type Sandbox interface {
 	// Run executes a command in a sandboxed environment
 	Run(ctx context.Context, config ExecutionConfig) (*ExecutionResult, error)
 
-	// Cleanup performs any necessary cleanup operations
-	Cleanup(ctx context.Context) error
+	// Shell runs config.Command as an interactive shell attached to term through a PTY
+	// It returns when the shell exits, with the terminal transcript as Stdout. The
+	// per-command timeout does not apply.
+	Shell(ctx context.Context, config ExecutionConfig, term Terminal) (*ExecutionResult, error)
+
+	// Cleanup removes the container of one session, so its next command starts fresh
+	// Other sessions' containers are left running.
+	Cleanup(ctx context.Context, sessionID string) error
+
+	// CleanupAll removes every session's container, e.g. when the process exits
+	CleanupAll(ctx context.Context) error
 
 	// Name returns the driver name for logging and identification
 	Name() string
@@ -36,3 +46,10 @@ type ExecutionResult struct {
 	ImageUsed     string
 	CorrelationID string
 }
+
+// Terminal is the learner's terminal that an interactive shell is attached to
+// In must be a TTY for the shell to get a PTY.
+type Terminal struct {
+	In  io.Reader
+	Out io.Writer
+}
@@ -2,6 +2,7 @@ package sandbox
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -131,6 +132,23 @@ func TestExecutionConfigValidate(t *testing.T) {
 			wantError: true,
 			errorMsg:  "timeout must be positive",
 		},
+		{
+			name: "valid mount",
+			config: NewExecutionConfig().
+				WithImage("alpine:latest").
+				WithCommand("ls", AssetsPath).
+				WithMount(Mount{Source: "/var/lib/ancli/assets", Target: AssetsPath, ReadOnly: true}),
+			wantError: false,
+		},
+		{
+			name: "relative mount source",
+			config: NewExecutionConfig().
+				WithImage("alpine:latest").
+				WithCommand("ls", AssetsPath).
+				WithMount(Mount{Source: "assets", Target: AssetsPath}),
+			wantError: true,
+			errorMsg:  "mount assets:/assets must use absolute paths",
+		},
 	}
 
 	for _, tt := range tests {
@@ -180,10 +198,54 @@ func (m *mockSandbox) Run(ctx context.Context, config ExecutionConfig) (*Executi
 	}, nil
 }
 
-func (m *mockSandbox) Cleanup(ctx context.Context) error {
+func (m *mockSandbox) Shell(ctx context.Context, config ExecutionConfig, term Terminal) (*ExecutionResult, error) {
+	return m.Run(ctx, config)
+}
+
+func (m *mockSandbox) Cleanup(ctx context.Context, sessionID string) error {
+	return nil
+}
+
+func (m *mockSandbox) CleanupAll(ctx context.Context) error {
 	return nil
 }
 
 func (m *mockSandbox) Name() string {
 	return "mock"
 }
+
+// TestTrackedWorkingDir verifies the reported directory is split off a script's output
+func TestTrackedWorkingDir(t *testing.T) {
+	stdout, dir := TrackedWorkingDir("created\n\n"+cwdMarker+"/workspace/my_project\n", "/workspace")
+	if stdout != "created\n" || dir != "/workspace/my_project" {
+		t.Errorf("expected output %q in /workspace/my_project, got %q in %s", "created\n", stdout, dir)
+	}
+
+	stdout, dir = TrackedWorkingDir("no marker\n", "/workspace")
+	if stdout != "no marker\n" || dir != "/workspace" {
+		t.Errorf("expected output and directory unchanged, got %q in %s", stdout, dir)
+	}
+}
+
+// TestShellCommand verifies commands are wrapped in the deck's shell
+func TestShellCommand(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  string
+		bash  bool
+	}{
+		{"", "[/bin/sh -c ls | wc -l]", false},
+		{"bash", "[bash -c ls | wc -l]", true},
+		{"/bin/bash -c", "[/bin/bash -c ls | wc -l]", true},
+		{"/bin/ash -e -c", "[/bin/ash -e -c ls | wc -l]", false},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprint(ShellCommand(tt.shell, "ls | wc -l")); got != tt.want {
+			t.Errorf("ShellCommand(%q) = %s, want %s", tt.shell, got, tt.want)
+		}
+		if got := IsBash(tt.shell); got != tt.bash {
+			t.Errorf("IsBash(%q) = %t, want %t", tt.shell, got, tt.bash)
+		}
+	}
+}
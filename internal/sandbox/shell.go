@@ -0,0 +1,58 @@
+package sandbox
+
+import (
+	"path"
+	"strings"
+)
+
+// cwdMarker prefixes the line a tracked script prints so later commands start where it left off
+const cwdMarker = "__ANCLI_CWD__="
+
+// DefaultShell runs card commands for decks that don't set container.shell
+const DefaultShell = "/bin/sh -c"
+
+// ShellArgs splits a deck's shell setting into the arguments a command is appended to
+// A bare program such as "bash" gets "-c" added; an empty setting is DefaultShell.
+func ShellArgs(shell string) []string {
+	args := strings.Fields(shell)
+	switch len(args) {
+	case 0:
+		return strings.Fields(DefaultShell)
+	case 1:
+		return append(args, "-c")
+	default:
+		return args
+	}
+}
+
+// IsBash reports whether a deck's shell setting runs commands with bash
+func IsBash(shell string) bool {
+	return path.Base(ShellArgs(shell)[0]) == "bash"
+}
+
+// ShellCommand wraps a card command in the deck's shell so pipes, redirection, and
+// quoting work
+func ShellCommand(shell, command string) []string {
+	return append(ShellArgs(shell), command)
+}
+
+// TrackWorkingDir appends a line to script that reports the directory it finished in
+// Each command runs in its own shell, so the only shell state carried forward is the
+// working directory: a setup ending in "cd my_project" makes later commands start
+// there. The script's exit status is preserved.
+func TrackWorkingDir(script string) string {
+	return script + "\n__ancli_status=$?\nprintf '\\n" + cwdMarker + "%s\\n' \"$PWD\"\nexit $__ancli_status"
+}
+
+// TrackedWorkingDir splits the directory reported by a TrackWorkingDir script off its
+// stdout. dir is returned unchanged when the script did not report one.
+func TrackedWorkingDir(stdout, dir string) (string, string) {
+	i := strings.LastIndex(stdout, "\n"+cwdMarker)
+	if i < 0 {
+		return stdout, dir
+	}
+	if reported := strings.TrimSpace(stdout[i+len(cwdMarker)+1:]); reported != "" {
+		dir = reported
+	}
+	return stdout[:i], dir
+}
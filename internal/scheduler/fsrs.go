@@ -1,13 +1,16 @@
 package scheduler
 
 import (
+	"sync"
 	"time"
 
 	"github.com/open-spaced-repetition/go-fsrs/v3"
 )
 
 // Scheduler wraps the FSRS algorithm for our CLI application
+// It is safe for concurrent use.
 type Scheduler struct {
+	mu   sync.Mutex // go-fsrs keeps per-call state in its parameters
 	fsrs *fsrs.FSRS
 }
 
@@ -34,6 +37,8 @@ func (s *Scheduler) NewCard() fsrs.Card {
 // rating should be one of: fsrs.Again, fsrs.Hard, fsrs.Good, fsrs.Easy
 func (s *Scheduler) ReviewCard(card fsrs.Card, rating fsrs.Rating) fsrs.SchedulingInfo {
 	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.fsrs.Next(card, now, rating)
 }
 
@@ -41,6 +46,8 @@ func (s *Scheduler) ReviewCard(card fsrs.Card, rating fsrs.Rating) fsrs.Scheduli
 // This allows the UI to show the user what will happen for each rating choice
 func (s *Scheduler) GetSchedulingOptions(card fsrs.Card) fsrs.RecordLog {
 	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.fsrs.Repeat(card, now)
 }
 
@@ -52,6 +59,8 @@ func (s *Scheduler) IsDue(card fsrs.Card) bool {
 // GetRetrievability returns the current retrievability of a card (0.0 to 1.0)
 func (s *Scheduler) GetRetrievability(card fsrs.Card) float64 {
 	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.fsrs.GetRetrievability(card, now)
 }
 
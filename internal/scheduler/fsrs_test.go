@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -239,3 +240,25 @@ func TestSchedulerWithDifferentRatings(t *testing.T) {
 		t.Errorf("Expected Again(%d) <= Hard(%d) <= Good(%d) <= Easy(%d) days until due", againDays, hardDays, goodDays, easyDays)
 	}
 }
+
+func TestSchedulerConcurrentReviews(t *testing.T) {
+	scheduler := NewScheduler()
+	card := scheduler.NewCard()
+
+	// Run with -race: go-fsrs writes to its parameters on every call
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				if info := scheduler.ReviewCard(card, fsrs.Good); info.Card.Reps != 1 {
+					t.Errorf("expected 1 rep after one review, got %d", info.Card.Reps)
+				}
+				scheduler.GetSchedulingOptions(card)
+				scheduler.GetRetrievability(card)
+			}
+		}()
+	}
+	wg.Wait()
+}
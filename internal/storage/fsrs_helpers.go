@@ -41,3 +41,31 @@ func (c *Card) UpdateFromFSRSCard(fsrsCard fsrs.Card) {
 	c.FSRSLastReview = &fsrsCard.LastReview
 	c.UpdatedAt = time.Now()
 }
+
+// HasPriorState reports whether the review recorded the card's full FSRS state before it
+// Reviews recorded before that state was kept cannot be undone.
+func (r *Review) HasPriorState() bool {
+	return r.FSRSElapsedDaysBefore != nil && r.FSRSScheduledDaysBefore != nil &&
+		r.FSRSRepsBefore != nil && r.FSRSLapsesBefore != nil
+}
+
+// RestoreFromReview puts back the FSRS state the card had before a review
+// This is how a review is undone; the review must be one of this card's. It returns
+// ErrNoPriorState, leaving the card unchanged, when the review did not record that state.
+func (c *Card) RestoreFromReview(review *Review) error {
+	if !review.HasPriorState() {
+		return ErrNoPriorState
+	}
+
+	c.FSRSDue = review.FSRSDueBefore
+	c.FSRSStability = review.FSRSStabilityBefore
+	c.FSRSDifficulty = review.FSRSDifficultyBefore
+	c.FSRSElapsedDays = *review.FSRSElapsedDaysBefore
+	c.FSRSScheduledDays = *review.FSRSScheduledDaysBefore
+	c.FSRSReps = *review.FSRSRepsBefore
+	c.FSRSLapses = *review.FSRSLapsesBefore
+	c.FSRSState = review.FSRSStateBefore
+	c.FSRSLastReview = review.FSRSLastReviewBefore
+	c.UpdatedAt = time.Now()
+	return nil
+}
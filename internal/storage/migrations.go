@@ -1,6 +1,106 @@
 package storage
 
-const createTablesSQL = `
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrSchemaTooNew is returned when a database was migrated by a newer ancli
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of ancli supports")
+
+// migration is one numbered, forward-only schema change
+// Migrations run in version order, each in its own transaction, and are recorded in
+// schema_migrations. Released migrations must never be edited; add a new one instead.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *DB) error
+}
+
+// migrations is the full schema history, in order
+var migrations = []migration{
+	{1, "initial_schema", execMigration(initialSchemaSQL)},
+	{2, "archive_cards", addColumnsMigration("cards",
+		"archived BOOLEAN NOT NULL DEFAULT FALSE",
+	)},
+	{3, "card_pedagogy", addColumnsMigration("cards",
+		"setup TEXT NOT NULL DEFAULT ''",
+		"cleanup TEXT NOT NULL DEFAULT ''",
+		"verify TEXT NOT NULL DEFAULT ''",
+		"hint TEXT NOT NULL DEFAULT ''",
+		"solution TEXT NOT NULL DEFAULT ''",
+		"explanation TEXT NOT NULL DEFAULT ''",
+	)},
+	{4, "review_outcome", addColumnsMigration("reviews",
+		"attempt_passed BOOLEAN",
+		"suggested_rating INTEGER",
+	)},
+	{5, "deck_settings", addColumnsMigration("decks",
+		"settings TEXT NOT NULL DEFAULT '{}'",
+	)},
+	{6, "sessions", execMigration(sessionsSQL)},
+	{7, "review_session", addColumnsMigration("reviews",
+		"session_id TEXT REFERENCES sessions(id) ON DELETE SET NULL",
+	)},
+	{8, "card_suspension", addColumnsMigration("cards",
+		"suspended BOOLEAN NOT NULL DEFAULT FALSE",
+		"buried_until DATETIME",
+	)},
+	{9, "session_learning", addColumnsMigration("sessions",
+		"learning TEXT NOT NULL DEFAULT '[]'",
+	)},
+	{10, "review_state", addColumnsMigration("reviews",
+		"fsrs_state_before INTEGER NOT NULL DEFAULT 0",
+		"fsrs_state_after INTEGER NOT NULL DEFAULT 0",
+	)},
+	{11, "deck_shell", addColumnsMigration("decks",
+		"default_shell TEXT NOT NULL DEFAULT ''",
+	)},
+	// NULL in reviews recorded before this migration, which therefore cannot be undone
+	{12, "review_prior_state", addColumnsMigration("reviews",
+		"fsrs_elapsed_days_before INTEGER",
+		"fsrs_scheduled_days_before INTEGER",
+		"fsrs_reps_before INTEGER",
+		"fsrs_lapses_before INTEGER",
+		"fsrs_last_review_before DATETIME",
+	)},
+}
+
+// sessionsSQL creates the table of review sessions, which outlive the process so
+// an interrupted session can be resumed
+const sessionsSQL = `
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT PRIMARY KEY,
+    deck_id INTEGER, -- NULL when reviewing all decks
+    options TEXT NOT NULL DEFAULT '{}', -- JSON blob of session options
+    queue TEXT NOT NULL DEFAULT '[]', -- JSON array of card IDs still to review, in order
+    current_card_id INTEGER, -- card shown but not yet rated
+    cards_reviewed INTEGER NOT NULL DEFAULT 0,
+    active_ms INTEGER NOT NULL DEFAULT 0, -- time spent reviewing, excluding pauses
+    started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    paused_at DATETIME,
+    ended_at DATETIME,
+    FOREIGN KEY (deck_id) REFERENCES decks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_unfinished ON sessions(updated_at) WHERE ended_at IS NULL;
+`
+
+const createMigrationsTableSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// initialSchemaSQL is the schema as first released
+// It is frozen: later changes go in new migrations, never here.
+const initialSchemaSQL = `
 -- Deck metadata and configuration
 CREATE TABLE IF NOT EXISTS decks (
     id INTEGER PRIMARY KEY,
@@ -119,13 +219,193 @@ CREATE INDEX IF NOT EXISTS idx_reviews_date ON reviews(reviewed_at);
 CREATE INDEX IF NOT EXISTS idx_assets_deck ON card_assets(deck_id);
 `
 
-// MigrateDatabase creates all tables and indexes
-// This is called automatically on every database connection
-// Safe to run multiple times due to IF NOT EXISTS clauses
+// MigrationStatus reports whether one migration has been applied to a database
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// LatestSchemaVersion returns the schema version this binary migrates to
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// MigrateDatabase applies every pending migration
+// This is called automatically on every database connection. Databases created before
+// migrations were versioned are adopted in place: the initial schema uses
+// IF NOT EXISTS and added columns are skipped when already present.
+// A database whose schema is newer than this binary is refused rather than touched.
 func MigrateDatabase(db *DB) error {
-	_, err := db.conn.Exec(createTablesSQL)
+	if _, err := db.conn.Exec(createMigrationsTableSQL); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	applied, err := db.appliedMigrations()
 	if err != nil {
 		return err
 	}
+	if err := checkSchemaVersion(applied); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if _, ok := applied[m.version]; ok {
+			continue
+		}
+
+		err := db.WithTx(func(tx *DB) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			_, err := tx.q().Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.version, m.name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+// MigrationStatus lists every known migration and whether it has been applied
+// It only reads the database, so it is safe to call on a connection from OpenDB.
+func (db *DB) MigrationStatus() ([]MigrationStatus, error) {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Version: m.version, Name: m.name}
+		if at, ok := applied[m.version]; ok {
+			s.Applied = true
+			s.AppliedAt = at
+		}
+		status = append(status, s)
+	}
+
+	return status, checkSchemaVersion(applied)
+}
+
+// SchemaVersion returns the highest migration version applied to the database
+func (db *DB) SchemaVersion() (int, error) {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+// appliedMigrations returns the applied migration versions and when they ran
+// A database without a schema_migrations table has no applied migrations.
+func (db *DB) appliedMigrations() (map[int]*time.Time, error) {
+	var count int
+	err := db.q().QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+
+	applied := make(map[int]*time.Time)
+	if count == 0 {
+		return applied, nil
+	}
+
+	rows, err := db.q().Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt sql.NullTime
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		if appliedAt.Valid {
+			applied[version] = &appliedAt.Time
+		} else {
+			applied[version] = nil
+		}
+	}
+
+	return applied, rows.Err()
+}
+
+// checkSchemaVersion refuses databases migrated past the latest known version
+func checkSchemaVersion(applied map[int]*time.Time) error {
+	latest := LatestSchemaVersion()
+	for version := range applied {
+		if version > latest {
+			return fmt.Errorf("%w: database is at version %d, this binary knows up to %d; upgrade ancli",
+				ErrSchemaTooNew, version, latest)
+		}
+	}
+	return nil
+}
+
+// execMigration returns a migration step that runs a SQL script
+func execMigration(script string) func(tx *DB) error {
+	return func(tx *DB) error {
+		_, err := tx.q().Exec(script)
+		return err
+	}
+}
+
+// addColumnsMigration returns a migration step that adds columns to table
+// Each column is "name definition". Columns that already exist are skipped, because
+// databases created before versioned migrations may have them already.
+func addColumnsMigration(table string, columns ...string) func(tx *DB) error {
+	return func(tx *DB) error {
+		for _, column := range columns {
+			name, definition, _ := strings.Cut(column, " ")
+			if err := addColumnIfMissing(tx, table, name, definition); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// addColumnIfMissing adds column to table unless it already exists
+func addColumnIfMissing(db *DB, table, column, definition string) error {
+	rows, err := db.q().Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", table, err)
+	}
+	rows.Close()
+
+	if _, err := db.q().Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
 	return nil
 }
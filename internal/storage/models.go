@@ -1,9 +1,18 @@
 package storage
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
+// Sandbox defaults for decks that do not configure their own container
+// These mirror the column defaults of the decks table
+const (
+	DefaultDeckImage   = "alpine:latest"
+	DefaultDeckTimeout = 5 // seconds
+)
+
 // Deck represents a collection of flashcards with shared configuration
 type Deck struct {
 	ID          int       `json:"id" db:"id"`
@@ -19,9 +28,34 @@ type Deck struct {
 	DefaultTimeout        int    `json:"default_timeout" db:"default_timeout"`
 	DefaultNetworkEnabled bool   `json:"default_network_enabled" db:"default_network_enabled"`
 	DefaultCapabilities   string `json:"default_capabilities" db:"default_capabilities"` // JSON array
+	DefaultShell          string `json:"default_shell" db:"default_shell"`               // Runs card commands, empty for /bin/sh -c
 
 	// FSRS parameters for this deck
 	FSRSParameters string `json:"fsrs_parameters" db:"fsrs_parameters"` // JSON blob
+
+	// Learning settings from deck.yaml
+	Settings string `json:"settings" db:"settings"` // JSON blob of DeckSettings
+}
+
+// DeckSettings are the learning settings a deck's author chose
+// Nil fields were not set and use their defaults.
+type DeckSettings struct {
+	ShowSolutions    *bool `json:"show_solutions,omitempty"`
+	ShowExplanations *bool `json:"show_explanations,omitempty"`
+	NewCardsPerDay   *int  `json:"new_cards_per_day,omitempty"` // Overrides the configured daily limit, 0 = unlimited
+	ReviewsPerDay    *int  `json:"reviews_per_day,omitempty"`   // Overrides the configured daily limit, 0 = unlimited
+}
+
+// ParseSettings decodes the deck's learning settings
+func (d *Deck) ParseSettings() (DeckSettings, error) {
+	var settings DeckSettings
+	if d.Settings == "" {
+		return settings, nil
+	}
+	if err := json.Unmarshal([]byte(d.Settings), &settings); err != nil {
+		return settings, fmt.Errorf("invalid settings for deck %q: %w", d.Name, err)
+	}
+	return settings, nil
 }
 
 // Card represents an individual flashcard with command execution details
@@ -53,6 +87,14 @@ type Card struct {
 	Prerequisites    string `json:"prerequisites" db:"prerequisites"`         // JSON array of card_keys
 	PrerequisiteMode string `json:"prerequisite_mode" db:"prerequisite_mode"` // 'enforce' or 'link'
 
+	// Pedagogy
+	Setup       string `json:"setup" db:"setup"`     // Run before the learner's attempt
+	Cleanup     string `json:"cleanup" db:"cleanup"` // Run after rating
+	Verify      string `json:"verify" db:"verify"`   // Checks the learner's work
+	Hint        string `json:"hint" db:"hint"`
+	Solution    string `json:"solution" db:"solution"` // Pipe-separated alternatives
+	Explanation string `json:"explanation" db:"explanation"`
+
 	// FSRS state - embedded for performance
 	FSRSDue           time.Time  `json:"fsrs_due" db:"fsrs_due"`
 	FSRSStability     float64    `json:"fsrs_stability" db:"fsrs_stability"`
@@ -64,6 +106,15 @@ type Card struct {
 	FSRSState         int        `json:"fsrs_state" db:"fsrs_state"` // 0=New, 1=Learning, 2=Review, 3=Relearning
 	FSRSLastReview    *time.Time `json:"fsrs_last_review" db:"fsrs_last_review"`
 
+	// Archived cards were removed from their deck by an upgrade; they keep their
+	// review history but are no longer scheduled
+	Archived bool `json:"archived" db:"archived"`
+
+	// Suspended cards are kept out of review sessions until unsuspended; buried cards
+	// until BuriedUntil passes
+	Suspended   bool       `json:"suspended" db:"suspended"`
+	BuriedUntil *time.Time `json:"buried_until" db:"buried_until"`
+
 	// Timestamps
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
@@ -81,7 +132,7 @@ type Review struct {
 	// Execution results
 	ExecutionSuccess bool   `json:"execution_success" db:"execution_success"`
 	ExitCode         *int   `json:"exit_code" db:"exit_code"`
-	Stdout           string `json:"stdout" db:"stdout"`
+	Stdout           string `json:"stdout" db:"stdout"` // The full terminal transcript for interactive shell attempts
 	Stderr           string `json:"stderr" db:"stderr"`
 
 	// Enhanced timing metrics
@@ -93,6 +144,13 @@ type Review struct {
 	Attempts     int  `json:"attempts" db:"attempts"`
 	HelpAccessed bool `json:"help_accessed" db:"help_accessed"`
 
+	// Session the review was made in, NULL for reviews recorded outside one
+	SessionID *string `json:"session_id" db:"session_id"`
+
+	// Objective outcome, for comparing self-grades with actual results
+	AttemptPassed   *bool `json:"attempt_passed" db:"attempt_passed"`     // NULL when the attempt was not judged
+	SuggestedRating *int  `json:"suggested_rating" db:"suggested_rating"` // Rating proposed from the outcome, NULL when none was offered
+
 	// FSRS state transitions
 	FSRSDueBefore        time.Time `json:"fsrs_due_before" db:"fsrs_due_before"`
 	FSRSDueAfter         time.Time `json:"fsrs_due_after" db:"fsrs_due_after"`
@@ -100,6 +158,64 @@ type Review struct {
 	FSRSStabilityAfter   float64   `json:"fsrs_stability_after" db:"fsrs_stability_after"`
 	FSRSDifficultyBefore float64   `json:"fsrs_difficulty_before" db:"fsrs_difficulty_before"`
 	FSRSDifficultyAfter  float64   `json:"fsrs_difficulty_after" db:"fsrs_difficulty_after"`
+	FSRSStateBefore      int       `json:"fsrs_state_before" db:"fsrs_state_before"` // 0=New, 1=Learning, 2=Review, 3=Relearning
+	FSRSStateAfter       int       `json:"fsrs_state_after" db:"fsrs_state_after"`
+
+	// The rest of the card's FSRS state before the review, so it can be undone exactly
+	// These are NULL for reviews recorded before they were kept; see HasPriorState.
+	FSRSElapsedDaysBefore   *int       `json:"fsrs_elapsed_days_before" db:"fsrs_elapsed_days_before"`
+	FSRSScheduledDaysBefore *int       `json:"fsrs_scheduled_days_before" db:"fsrs_scheduled_days_before"`
+	FSRSRepsBefore          *int       `json:"fsrs_reps_before" db:"fsrs_reps_before"`
+	FSRSLapsesBefore        *int       `json:"fsrs_lapses_before" db:"fsrs_lapses_before"`
+	FSRSLastReviewBefore    *time.Time `json:"fsrs_last_review_before" db:"fsrs_last_review_before"` // NULL for a card never reviewed
+}
+
+// ReviewCounts is how many new cards a deck introduced and due reviews it showed
+type ReviewCounts struct {
+	NewCards int `json:"new_cards"`
+	Reviews  int `json:"reviews"`
+}
+
+// Session is a persisted review session
+// Sessions are saved as they progress, so one interrupted by quitting or a crash can
+// be resumed with its remaining queue.
+type Session struct {
+	ID            string `json:"id" db:"id"`
+	DeckID        *int   `json:"deck_id" db:"deck_id"`
+	Options       string `json:"options" db:"options"`   // JSON blob of session options
+	Queue         string `json:"queue" db:"queue"`       // JSON array of card IDs still to review
+	Learning      string `json:"learning" db:"learning"` // JSON array of IDs of cards due again this session, by due time
+	CurrentCardID *int   `json:"current_card_id" db:"current_card_id"`
+	CardsReviewed int    `json:"cards_reviewed" db:"cards_reviewed"`
+	ActiveMs      int64  `json:"active_ms" db:"active_ms"` // Time spent reviewing, excluding pauses
+
+	StartedAt time.Time  `json:"started_at" db:"started_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	PausedAt  *time.Time `json:"paused_at" db:"paused_at"` // Set while the learner has parked the session
+	EndedAt   *time.Time `json:"ended_at" db:"ended_at"`
+}
+
+// ParseQueue decodes the IDs of the cards the session has yet to review
+func (s *Session) ParseQueue() ([]int, error) {
+	return s.parseCardIDs(s.Queue, "queue")
+}
+
+// ParseLearning decodes the IDs of the cards in learning steps that are due again
+// before the session ends
+func (s *Session) ParseLearning() ([]int, error) {
+	return s.parseCardIDs(s.Learning, "learning cards")
+}
+
+// parseCardIDs decodes a JSON array of card IDs; field names it in errors
+func (s *Session) parseCardIDs(value, field string) ([]int, error) {
+	var ids []int
+	if value == "" {
+		return ids, nil
+	}
+	if err := json.Unmarshal([]byte(value), &ids); err != nil {
+		return nil, fmt.Errorf("invalid %s for session %s: %w", field, s.ID, err)
+	}
+	return ids, nil
 }
 
 // DeckAsset represents a supporting file that cards within a deck can reference
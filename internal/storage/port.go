@@ -1,5 +1,7 @@
 package storage
 
+import "time"
+
 // Storage defines the interface for persistent data operations
 type Storage interface {
 	// Deck operations
@@ -12,7 +14,19 @@ type Storage interface {
 	GetAllCards() ([]*Card, error)
 
 	// Review operations
-	CreateReview(review *Review) error
+	RecordReview(card *Card, review *Review) error
+	GetReviewsBySession(sessionID string) ([]*Review, error)
+	CountReviewsSince(since time.Time) (map[int]ReviewCounts, error)
+	RevertReview(review *Review) error
+
+	// Session operations
+	CreateSession(session *Session) error
+	UpdateSession(session *Session) error
+	GetSession(id string) (*Session, error)
+	GetUnfinishedSession() (*Session, error)
+
+	// Asset operations
+	ListDeckAssets(deckID int) ([]*DeckAsset, error)
 
 	// Lifecycle
 	Close() error
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,14 +12,46 @@ import (
 	_ "modernc.org/sqlite"
 )
 
+// ErrNotFound is wrapped by lookups that match no row
+var ErrNotFound = errors.New("not found")
+
+// ErrNoPriorState is returned when reverting a review that did not record the card's
+// FSRS state before it
+var ErrNoPriorState = errors.New("review predates recording the card's prior state and cannot be undone")
+
 // DB wraps the SQLite database connection
 type DB struct {
 	conn *sql.DB
+	tx   *sql.Tx // Set on the handle passed to WithTx callbacks
 	path string
 }
 
+// querier is the subset of *sql.DB and *sql.Tx used by the CRUD operations
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 // NewDB creates a new database connection and runs migrations
 func NewDB(dbPath string) (*DB, error) {
+	db, err := OpenDB(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Auto-migrate database schema
+	if err := MigrateDatabase(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migration failed: %w", err)
+	}
+
+	return db, nil
+}
+
+// OpenDB creates a new database connection without running migrations
+// Most callers want NewDB; this exists for inspecting the schema state.
+func OpenDB(dbPath string) (*DB, error) {
 	// Ensure the directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -52,12 +85,6 @@ func NewDB(dbPath string) (*DB, error) {
 		path: dbPath,
 	}
 
-	// Auto-migrate database schema
-	if err := MigrateDatabase(db); err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("migration failed: %w", err)
-	}
-
 	return db, nil
 }
 
@@ -69,18 +96,54 @@ func (db *DB) Close() error {
 	return nil
 }
 
+// q returns the active transaction when inside WithTx, otherwise the connection
+func (db *DB) q() querier {
+	if db.tx != nil {
+		return db.tx
+	}
+	return db.conn
+}
+
+// WithTx runs fn inside a single transaction
+// The handle passed to fn routes every operation through the transaction, which is
+// committed when fn returns nil and rolled back otherwise. Nested calls join the
+// outer transaction.
+func (db *DB) WithTx(fn func(tx *DB) error) error {
+	if db.tx != nil {
+		return fn(db)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(&DB{conn: db.conn, tx: tx, path: db.path}); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
 // CreateDeck creates a new deck
 func (db *DB) CreateDeck(deck *Deck) error {
 	query := `
 		INSERT INTO decks (name, description, version, author, default_image, default_timeout, 
-			default_network_enabled, default_capabilities, fsrs_parameters)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			default_network_enabled, default_capabilities, default_shell, fsrs_parameters, settings)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := db.conn.Exec(query,
+	result, err := db.q().Exec(query,
 		deck.Name, deck.Description, deck.Version, deck.Author,
 		deck.DefaultImage, deck.DefaultTimeout, deck.DefaultNetworkEnabled,
-		deck.DefaultCapabilities, deck.FSRSParameters,
+		deck.DefaultCapabilities, deck.DefaultShell, deck.FSRSParameters, deckSettings(deck),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create deck: %w", err)
@@ -103,19 +166,19 @@ func (db *DB) GetDeck(id int) (*Deck, error) {
 	query := `
 		SELECT id, name, description, version, author, created_at, updated_at,
 			default_image, default_timeout, default_network_enabled, 
-			default_capabilities, fsrs_parameters
+			default_capabilities, default_shell, fsrs_parameters, settings
 		FROM decks WHERE id = ?
 	`
 
 	deck := &Deck{}
-	err := db.conn.QueryRow(query, id).Scan(
+	err := db.q().QueryRow(query, id).Scan(
 		&deck.ID, &deck.Name, &deck.Description, &deck.Version, &deck.Author,
 		&deck.CreatedAt, &deck.UpdatedAt, &deck.DefaultImage, &deck.DefaultTimeout,
-		&deck.DefaultNetworkEnabled, &deck.DefaultCapabilities, &deck.FSRSParameters,
+		&deck.DefaultNetworkEnabled, &deck.DefaultCapabilities, &deck.DefaultShell, &deck.FSRSParameters, &deck.Settings,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("deck not found")
+			return nil, fmt.Errorf("deck %w", ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get deck: %w", err)
 	}
@@ -123,16 +186,73 @@ func (db *DB) GetDeck(id int) (*Deck, error) {
 	return deck, nil
 }
 
+// GetDeckByName retrieves a deck by its unique name
+func (db *DB) GetDeckByName(name string) (*Deck, error) {
+	query := `
+		SELECT id, name, description, version, author, created_at, updated_at,
+			default_image, default_timeout, default_network_enabled, 
+			default_capabilities, default_shell, fsrs_parameters, settings
+		FROM decks WHERE name = ?
+	`
+
+	deck := &Deck{}
+	err := db.q().QueryRow(query, name).Scan(
+		&deck.ID, &deck.Name, &deck.Description, &deck.Version, &deck.Author,
+		&deck.CreatedAt, &deck.UpdatedAt, &deck.DefaultImage, &deck.DefaultTimeout,
+		&deck.DefaultNetworkEnabled, &deck.DefaultCapabilities, &deck.DefaultShell, &deck.FSRSParameters, &deck.Settings,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("deck %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get deck: %w", err)
+	}
+
+	return deck, nil
+}
+
+// UpdateDeck updates a deck's metadata and sandbox defaults
+func (db *DB) UpdateDeck(deck *Deck) error {
+	query := `
+		UPDATE decks SET
+			description = ?, version = ?, author = ?, default_image = ?,
+			default_timeout = ?, default_network_enabled = ?, default_capabilities = ?,
+			default_shell = ?, fsrs_parameters = ?, settings = ?, updated_at = datetime('now')
+		WHERE id = ?
+	`
+
+	_, err := db.q().Exec(query,
+		deck.Description, deck.Version, deck.Author, deck.DefaultImage,
+		deck.DefaultTimeout, deck.DefaultNetworkEnabled, deck.DefaultCapabilities,
+		deck.DefaultShell, deck.FSRSParameters, deckSettings(deck), deck.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update deck: %w", err)
+	}
+
+	deck.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// deckSettings returns the settings blob to store, "{}" when none were set
+func deckSettings(deck *Deck) string {
+	if deck.Settings == "" {
+		return "{}"
+	}
+	return deck.Settings
+}
+
 // ListDecks retrieves all decks
 func (db *DB) ListDecks() ([]*Deck, error) {
 	query := `
 		SELECT id, name, description, version, author, created_at, updated_at,
 			default_image, default_timeout, default_network_enabled, 
-			default_capabilities, fsrs_parameters
+			default_capabilities, default_shell, fsrs_parameters, settings
 		FROM decks ORDER BY name
 	`
 
-	rows, err := db.conn.Query(query)
+	rows, err := db.q().Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list decks: %w", err)
 	}
@@ -144,7 +264,7 @@ func (db *DB) ListDecks() ([]*Deck, error) {
 		err := rows.Scan(
 			&deck.ID, &deck.Name, &deck.Description, &deck.Version, &deck.Author,
 			&deck.CreatedAt, &deck.UpdatedAt, &deck.DefaultImage, &deck.DefaultTimeout,
-			&deck.DefaultNetworkEnabled, &deck.DefaultCapabilities, &deck.FSRSParameters,
+			&deck.DefaultNetworkEnabled, &deck.DefaultCapabilities, &deck.DefaultShell, &deck.FSRSParameters, &deck.Settings,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan deck: %w", err)
@@ -155,6 +275,24 @@ func (db *DB) ListDecks() ([]*Deck, error) {
 	return decks, nil
 }
 
+// DeleteDeck removes a deck along with its cards, reviews, assets, and versions
+func (db *DB) DeleteDeck(id int) error {
+	result, err := db.q().Exec(`DELETE FROM decks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete deck: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete deck: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("deck %w", ErrNotFound)
+	}
+
+	return nil
+}
+
 // CreateCard creates a new card with initial FSRS state
 func (db *DB) CreateCard(card *Card) error {
 	// Initialize FSRS state for new card
@@ -173,16 +311,19 @@ func (db *DB) CreateCard(card *Card) error {
 		INSERT INTO cards (deck_id, card_key, title, description, command, working_dir,
 			environment_vars, image, timeout, network_enabled, capabilities,
 			difficulty_level, tags, prerequisites, prerequisite_mode,
+			setup, cleanup, verify, hint, solution, explanation,
 			fsrs_due, fsrs_stability, fsrs_difficulty, fsrs_elapsed_days,
 			fsrs_scheduled_days, fsrs_reps, fsrs_lapses, fsrs_state, fsrs_last_review)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := db.conn.Exec(query,
+	result, err := db.q().Exec(query,
 		card.DeckID, card.CardKey, card.Title, card.Description, card.Command,
 		card.WorkingDir, card.EnvironmentVars, card.Image, card.Timeout,
 		card.NetworkEnabled, card.Capabilities, card.DifficultyLevel, card.Tags,
-		card.Prerequisites, card.PrerequisiteMode, card.FSRSDue, card.FSRSStability,
+		card.Prerequisites, card.PrerequisiteMode,
+		card.Setup, card.Cleanup, card.Verify, card.Hint, card.Solution, card.Explanation,
+		card.FSRSDue, card.FSRSStability,
 		card.FSRSDifficulty, card.FSRSElapsedDays, card.FSRSScheduledDays,
 		card.FSRSReps, card.FSRSLapses, card.FSRSState, card.FSRSLastReview,
 	)
@@ -205,28 +346,14 @@ func (db *DB) CreateCard(card *Card) error {
 // GetCard retrieves a card by ID
 func (db *DB) GetCard(id int) (*Card, error) {
 	query := `
-		SELECT id, deck_id, card_key, title, description, command, working_dir,
-			environment_vars, image, timeout, network_enabled, capabilities,
-			difficulty_level, tags, prerequisites, prerequisite_mode,
-			fsrs_due, fsrs_stability, fsrs_difficulty, fsrs_elapsed_days,
-			fsrs_scheduled_days, fsrs_reps, fsrs_lapses, fsrs_state, fsrs_last_review,
-			created_at, updated_at
+		SELECT ` + cardColumns + `
 		FROM cards WHERE id = ?
 	`
 
-	card := &Card{}
-	err := db.conn.QueryRow(query, id).Scan(
-		&card.ID, &card.DeckID, &card.CardKey, &card.Title, &card.Description,
-		&card.Command, &card.WorkingDir, &card.EnvironmentVars, &card.Image,
-		&card.Timeout, &card.NetworkEnabled, &card.Capabilities, &card.DifficultyLevel,
-		&card.Tags, &card.Prerequisites, &card.PrerequisiteMode, &card.FSRSDue,
-		&card.FSRSStability, &card.FSRSDifficulty, &card.FSRSElapsedDays,
-		&card.FSRSScheduledDays, &card.FSRSReps, &card.FSRSLapses, &card.FSRSState,
-		&card.FSRSLastReview, &card.CreatedAt, &card.UpdatedAt,
-	)
+	card, err := scanCard(db.q().QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("card not found")
+			return nil, fmt.Errorf("card %w", ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get card: %w", err)
 	}
@@ -234,45 +361,24 @@ func (db *DB) GetCard(id int) (*Card, error) {
 	return card, nil
 }
 
-// GetDueCards retrieves all cards that are due for review
+// GetDueCards retrieves all active cards that are due for review
+// Suspended cards, and buried cards until their burial ends, are left out.
 func (db *DB) GetDueCards() ([]*Card, error) {
 	query := `
-		SELECT id, deck_id, card_key, title, description, command, working_dir,
-			environment_vars, image, timeout, network_enabled, capabilities,
-			difficulty_level, tags, prerequisites, prerequisite_mode,
-			fsrs_due, fsrs_stability, fsrs_difficulty, fsrs_elapsed_days,
-			fsrs_scheduled_days, fsrs_reps, fsrs_lapses, fsrs_state, fsrs_last_review,
-			created_at, updated_at
+		SELECT ` + cardColumns + `
 		FROM cards 
-		WHERE fsrs_due <= datetime('now')
+		WHERE fsrs_due <= datetime('now') AND archived = FALSE AND suspended = FALSE
+			AND (buried_until IS NULL OR buried_until <= datetime('now'))
 		ORDER BY fsrs_due ASC
 	`
 
-	rows, err := db.conn.Query(query)
+	rows, err := db.q().Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get due cards: %w", err)
 	}
 	defer rows.Close()
 
-	var cards []*Card
-	for rows.Next() {
-		card := &Card{}
-		err := rows.Scan(
-			&card.ID, &card.DeckID, &card.CardKey, &card.Title, &card.Description,
-			&card.Command, &card.WorkingDir, &card.EnvironmentVars, &card.Image,
-			&card.Timeout, &card.NetworkEnabled, &card.Capabilities, &card.DifficultyLevel,
-			&card.Tags, &card.Prerequisites, &card.PrerequisiteMode, &card.FSRSDue,
-			&card.FSRSStability, &card.FSRSDifficulty, &card.FSRSElapsedDays,
-			&card.FSRSScheduledDays, &card.FSRSReps, &card.FSRSLapses, &card.FSRSState,
-			&card.FSRSLastReview, &card.CreatedAt, &card.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan card: %w", err)
-		}
-		cards = append(cards, card)
-	}
-
-	return cards, nil
+	return scanCards(rows)
 }
 
 // UpdateCard updates a card's full state
@@ -282,20 +388,23 @@ func (db *DB) UpdateCard(card *Card) error {
 			title = ?, description = ?, command = ?, working_dir = ?,
 			environment_vars = ?, image = ?, timeout = ?, network_enabled = ?,
 			capabilities = ?, difficulty_level = ?, tags = ?, prerequisites = ?,
-			prerequisite_mode = ?, fsrs_due = ?, fsrs_stability = ?, fsrs_difficulty = ?,
+			prerequisite_mode = ?, setup = ?, cleanup = ?, verify = ?, hint = ?,
+			solution = ?, explanation = ?, fsrs_due = ?, fsrs_stability = ?, fsrs_difficulty = ?,
 			fsrs_elapsed_days = ?, fsrs_scheduled_days = ?, fsrs_reps = ?,
-			fsrs_lapses = ?, fsrs_state = ?, fsrs_last_review = ?,
-			updated_at = datetime('now')
+			fsrs_lapses = ?, fsrs_state = ?, fsrs_last_review = ?, archived = ?,
+			suspended = ?, buried_until = ?, updated_at = datetime('now')
 		WHERE id = ?
 	`
 
-	_, err := db.conn.Exec(query,
+	_, err := db.q().Exec(query,
 		card.Title, card.Description, card.Command, card.WorkingDir,
 		card.EnvironmentVars, card.Image, card.Timeout, card.NetworkEnabled,
 		card.Capabilities, card.DifficultyLevel, card.Tags, card.Prerequisites,
-		card.PrerequisiteMode, card.FSRSDue, card.FSRSStability, card.FSRSDifficulty,
+		card.PrerequisiteMode, card.Setup, card.Cleanup, card.Verify, card.Hint,
+		card.Solution, card.Explanation, card.FSRSDue, card.FSRSStability, card.FSRSDifficulty,
 		card.FSRSElapsedDays, card.FSRSScheduledDays, card.FSRSReps,
-		card.FSRSLapses, card.FSRSState, card.FSRSLastReview, card.ID,
+		card.FSRSLapses, card.FSRSState, card.FSRSLastReview, card.Archived,
+		card.Suspended, card.BuriedUntil, card.ID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update card: %w", err)
@@ -315,7 +424,7 @@ func (db *DB) UpdateCardFSRS(card *Card) error {
 		WHERE id = ?
 	`
 
-	_, err := db.conn.Exec(query,
+	_, err := db.q().Exec(query,
 		card.FSRSDue, card.FSRSStability, card.FSRSDifficulty,
 		card.FSRSElapsedDays, card.FSRSScheduledDays, card.FSRSReps,
 		card.FSRSLapses, card.FSRSState, card.FSRSLastReview, card.ID,
@@ -327,82 +436,122 @@ func (db *DB) UpdateCardFSRS(card *Card) error {
 	return nil
 }
 
-// GetCardsByDeck retrieves all cards for a specific deck
+// GetCardsByDeck retrieves the active cards for a specific deck
 func (db *DB) GetCardsByDeck(deckID int) ([]*Card, error) {
 	query := `
-		SELECT id, deck_id, card_key, title, description, command, working_dir,
-			environment_vars, image, timeout, network_enabled, capabilities,
-			difficulty_level, tags, prerequisites, prerequisite_mode,
-			fsrs_due, fsrs_stability, fsrs_difficulty, fsrs_elapsed_days,
-			fsrs_scheduled_days, fsrs_reps, fsrs_lapses, fsrs_state, fsrs_last_review,
-			created_at, updated_at
-		FROM cards WHERE deck_id = ?
+		SELECT ` + cardColumns + `
+		FROM cards WHERE deck_id = ? AND archived = FALSE
 		ORDER BY card_key
 	`
 
-	rows, err := db.conn.Query(query, deckID)
+	rows, err := db.q().Query(query, deckID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get cards by deck: %w", err)
 	}
 	defer rows.Close()
 
-	var cards []*Card
-	for rows.Next() {
-		card := &Card{}
-		err := rows.Scan(
-			&card.ID, &card.DeckID, &card.CardKey, &card.Title, &card.Description,
-			&card.Command, &card.WorkingDir, &card.EnvironmentVars, &card.Image,
-			&card.Timeout, &card.NetworkEnabled, &card.Capabilities, &card.DifficultyLevel,
-			&card.Tags, &card.Prerequisites, &card.PrerequisiteMode, &card.FSRSDue,
-			&card.FSRSStability, &card.FSRSDifficulty, &card.FSRSElapsedDays,
-			&card.FSRSScheduledDays, &card.FSRSReps, &card.FSRSLapses, &card.FSRSState,
-			&card.FSRSLastReview, &card.CreatedAt, &card.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan card: %w", err)
+	return scanCards(rows)
+}
+
+// GetCardByKey retrieves a card by its key within a deck
+func (db *DB) GetCardByKey(deckID int, cardKey string) (*Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM cards WHERE deck_id = ? AND card_key = ?
+	`
+
+	card, err := scanCard(db.q().QueryRow(query, deckID, cardKey))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("card %w", ErrNotFound)
 		}
-		cards = append(cards, card)
+		return nil, fmt.Errorf("failed to get card: %w", err)
 	}
 
-	return cards, nil
+	return card, nil
+}
+
+// GetCardsByDeckWithArchived retrieves all cards for a deck, including archived ones
+func (db *DB) GetCardsByDeckWithArchived(deckID int) ([]*Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM cards WHERE deck_id = ?
+		ORDER BY card_key
+	`
+
+	rows, err := db.q().Query(query, deckID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cards by deck: %w", err)
+	}
+	defer rows.Close()
+
+	return scanCards(rows)
 }
 
-// GetAllCards retrieves all cards
+// GetAllCards retrieves all cards that have not been archived
 func (db *DB) GetAllCards() ([]*Card, error) {
 	query := `
-		SELECT id, deck_id, card_key, title, description, command, working_dir,
-			environment_vars, image, timeout, network_enabled, capabilities,
-			difficulty_level, tags, prerequisites, prerequisite_mode,
-			fsrs_due, fsrs_stability, fsrs_difficulty, fsrs_elapsed_days,
-			fsrs_scheduled_days, fsrs_reps, fsrs_lapses, fsrs_state, fsrs_last_review,
-			created_at, updated_at
-		FROM cards
+		SELECT ` + cardColumns + `
+		FROM cards WHERE archived = FALSE
 		ORDER BY deck_id, card_key
 	`
 
-	rows, err := db.conn.Query(query)
+	rows, err := db.q().Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all cards: %w", err)
 	}
 	defer rows.Close()
 
+	return scanCards(rows)
+}
+
+// cardColumns lists the cards columns in the order scanCard expects
+const cardColumns = `id, deck_id, card_key, title, description, command, working_dir,
+			environment_vars, image, timeout, network_enabled, capabilities,
+			difficulty_level, tags, prerequisites, prerequisite_mode,
+			setup, cleanup, verify, hint, solution, explanation,
+			fsrs_due, fsrs_stability, fsrs_difficulty, fsrs_elapsed_days,
+			fsrs_scheduled_days, fsrs_reps, fsrs_lapses, fsrs_state, fsrs_last_review,
+			archived, suspended, buried_until, created_at, updated_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanCard reads one card selected with cardColumns
+func scanCard(row rowScanner) (*Card, error) {
+	card := &Card{}
+	err := row.Scan(
+		&card.ID, &card.DeckID, &card.CardKey, &card.Title, &card.Description,
+		&card.Command, &card.WorkingDir, &card.EnvironmentVars, &card.Image,
+		&card.Timeout, &card.NetworkEnabled, &card.Capabilities, &card.DifficultyLevel,
+		&card.Tags, &card.Prerequisites, &card.PrerequisiteMode,
+		&card.Setup, &card.Cleanup, &card.Verify, &card.Hint, &card.Solution, &card.Explanation,
+		&card.FSRSDue, &card.FSRSStability, &card.FSRSDifficulty, &card.FSRSElapsedDays,
+		&card.FSRSScheduledDays, &card.FSRSReps, &card.FSRSLapses, &card.FSRSState,
+		&card.FSRSLastReview, &card.Archived, &card.Suspended, &card.BuriedUntil,
+		&card.CreatedAt, &card.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// scanCards reads every remaining row selected with cardColumns
+func scanCards(rows *sql.Rows) ([]*Card, error) {
 	var cards []*Card
 	for rows.Next() {
-		card := &Card{}
-		err := rows.Scan(
-			&card.ID, &card.DeckID, &card.CardKey, &card.Title, &card.Description,
-			&card.Command, &card.WorkingDir, &card.EnvironmentVars, &card.Image,
-			&card.Timeout, &card.NetworkEnabled, &card.Capabilities, &card.DifficultyLevel,
-			&card.Tags, &card.Prerequisites, &card.PrerequisiteMode, &card.FSRSDue,
-			&card.FSRSStability, &card.FSRSDifficulty, &card.FSRSElapsedDays,
-			&card.FSRSScheduledDays, &card.FSRSReps, &card.FSRSLapses, &card.FSRSState,
-			&card.FSRSLastReview, &card.CreatedAt, &card.UpdatedAt,
-		)
+		card, err := scanCard(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan card: %w", err)
 		}
 		cards = append(cards, card)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cards: %w", err)
+	}
 
 	return cards, nil
 }
@@ -412,17 +561,24 @@ func (db *DB) CreateReview(review *Review) error {
 	query := `
 		INSERT INTO reviews (card_id, rating, execution_success, exit_code, stdout, stderr,
 			thinking_time_ms, execution_time_ms, total_time_ms, attempts, help_accessed,
+			session_id, attempt_passed, suggested_rating,
 			fsrs_due_before, fsrs_due_after, fsrs_stability_before, fsrs_stability_after,
-			fsrs_difficulty_before, fsrs_difficulty_after)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			fsrs_difficulty_before, fsrs_difficulty_after, fsrs_state_before, fsrs_state_after,
+			fsrs_elapsed_days_before, fsrs_scheduled_days_before, fsrs_reps_before,
+			fsrs_lapses_before, fsrs_last_review_before)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := db.conn.Exec(query,
+	result, err := db.q().Exec(query,
 		review.CardID, review.Rating, review.ExecutionSuccess, review.ExitCode,
 		review.Stdout, review.Stderr, review.ThinkingTimeMs, review.ExecutionTimeMs,
 		review.TotalTimeMs, review.Attempts, review.HelpAccessed,
+		review.SessionID, review.AttemptPassed, review.SuggestedRating,
 		review.FSRSDueBefore, review.FSRSDueAfter, review.FSRSStabilityBefore,
 		review.FSRSStabilityAfter, review.FSRSDifficultyBefore, review.FSRSDifficultyAfter,
+		review.FSRSStateBefore, review.FSRSStateAfter,
+		review.FSRSElapsedDaysBefore, review.FSRSScheduledDaysBefore, review.FSRSRepsBefore,
+		review.FSRSLapsesBefore, review.FSRSLastReviewBefore,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create review: %w", err)
@@ -439,6 +595,285 @@ func (db *DB) CreateReview(review *Review) error {
 	return nil
 }
 
+// CountReviewsByDeck returns how many reviews were recorded for a deck's cards
+func (db *DB) CountReviewsByDeck(deckID int) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM reviews
+		JOIN cards ON cards.id = reviews.card_id
+		WHERE cards.deck_id = ?
+	`
+
+	var count int
+	if err := db.q().QueryRow(query, deckID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count reviews: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountReviewsSince counts, per deck, the new cards introduced and the due reviews
+// made since a time, for daily limits
+// Learning and relearning steps are not counted.
+func (db *DB) CountReviewsSince(since time.Time) (map[int]ReviewCounts, error) {
+	query := `
+		SELECT cards.deck_id,
+			COUNT(DISTINCT CASE WHEN reviews.fsrs_state_before = 0 THEN reviews.card_id END),
+			COUNT(CASE WHEN reviews.fsrs_state_before = 2 THEN 1 END)
+		FROM reviews
+		JOIN cards ON cards.id = reviews.card_id
+		WHERE reviews.reviewed_at >= ?
+		GROUP BY cards.deck_id
+	`
+
+	// reviewed_at defaults to CURRENT_TIMESTAMP, which is UTC
+	rows, err := db.q().Query(query, since.UTC().Format(time.DateTime))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reviews: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int]ReviewCounts)
+	for rows.Next() {
+		var deckID int
+		var count ReviewCounts
+		if err := rows.Scan(&deckID, &count.NewCards, &count.Reviews); err != nil {
+			return nil, fmt.Errorf("failed to scan review counts: %w", err)
+		}
+		counts[deckID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read review counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// reviewColumns lists the columns scanReviews reads, in order
+const reviewColumns = `id, card_id, reviewed_at, rating, execution_success, exit_code, stdout, stderr,
+	thinking_time_ms, execution_time_ms, total_time_ms, attempts, help_accessed,
+	session_id, attempt_passed, suggested_rating,
+	fsrs_due_before, fsrs_due_after, fsrs_stability_before, fsrs_stability_after,
+	fsrs_difficulty_before, fsrs_difficulty_after, fsrs_state_before, fsrs_state_after,
+	fsrs_elapsed_days_before, fsrs_scheduled_days_before, fsrs_reps_before,
+	fsrs_lapses_before, fsrs_last_review_before`
+
+// GetReviewsByCard returns a card's review history, oldest first
+func (db *DB) GetReviewsByCard(cardID int) ([]*Review, error) {
+	query := `SELECT ` + reviewColumns + ` FROM reviews WHERE card_id = ? ORDER BY id`
+
+	rows, err := db.q().Query(query, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %w", err)
+	}
+	defer rows.Close()
+
+	return scanReviews(rows)
+}
+
+// GetReviewsBySession returns the reviews made in a session, oldest first
+func (db *DB) GetReviewsBySession(sessionID string) ([]*Review, error) {
+	query := `SELECT ` + reviewColumns + ` FROM reviews WHERE session_id = ? ORDER BY id`
+
+	rows, err := db.q().Query(query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session reviews: %w", err)
+	}
+	defer rows.Close()
+
+	return scanReviews(rows)
+}
+
+// RecordReview saves a card's new FSRS state and the review that produced it, in one
+// transaction
+func (db *DB) RecordReview(card *Card, review *Review) error {
+	return db.WithTx(func(tx *DB) error {
+		if err := tx.UpdateCard(card); err != nil {
+			return err
+		}
+		return tx.CreateReview(review)
+	})
+}
+
+// RevertReview undoes a review: the card gets back the FSRS state it had before the
+// review and the review is deleted, in one transaction
+// A review without its prior state is refused with ErrNoPriorState.
+func (db *DB) RevertReview(review *Review) error {
+	return db.WithTx(func(tx *DB) error {
+		card, err := tx.GetCard(review.CardID)
+		if err != nil {
+			return err
+		}
+		if err := card.RestoreFromReview(review); err != nil {
+			return fmt.Errorf("review %d: %w", review.ID, err)
+		}
+		if err := tx.UpdateCardFSRS(card); err != nil {
+			return err
+		}
+
+		result, err := tx.q().Exec(`DELETE FROM reviews WHERE id = ?`, review.ID)
+		if err != nil {
+			return fmt.Errorf("failed to delete review: %w", err)
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to delete review: %w", err)
+		}
+		if deleted == 0 {
+			return fmt.Errorf("review %w", ErrNotFound)
+		}
+		return nil
+	})
+}
+
+// scanReviews reads reviews selected with reviewColumns
+func scanReviews(rows *sql.Rows) ([]*Review, error) {
+	var reviews []*Review
+	for rows.Next() {
+		review := &Review{}
+		err := rows.Scan(
+			&review.ID, &review.CardID, &review.ReviewedAt, &review.Rating,
+			&review.ExecutionSuccess, &review.ExitCode, &review.Stdout, &review.Stderr,
+			&review.ThinkingTimeMs, &review.ExecutionTimeMs, &review.TotalTimeMs,
+			&review.Attempts, &review.HelpAccessed, &review.SessionID,
+			&review.AttemptPassed, &review.SuggestedRating,
+			&review.FSRSDueBefore, &review.FSRSDueAfter, &review.FSRSStabilityBefore,
+			&review.FSRSStabilityAfter, &review.FSRSDifficultyBefore, &review.FSRSDifficultyAfter,
+			&review.FSRSStateBefore, &review.FSRSStateAfter,
+			&review.FSRSElapsedDaysBefore, &review.FSRSScheduledDaysBefore, &review.FSRSRepsBefore,
+			&review.FSRSLapsesBefore, &review.FSRSLastReviewBefore,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan review: %w", err)
+		}
+		reviews = append(reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reviews: %w", err)
+	}
+
+	return reviews, nil
+}
+
+// CreateSession records a new review session
+func (db *DB) CreateSession(session *Session) error {
+	query := `
+		INSERT INTO sessions (id, deck_id, options, queue, learning, current_card_id,
+			cards_reviewed, active_ms, started_at, updated_at, paused_at, ended_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	session.UpdatedAt = time.Now()
+	_, err := db.q().Exec(query,
+		session.ID, session.DeckID, sessionOptions(session), emptyIfUnset(session.Queue, "[]"),
+		emptyIfUnset(session.Learning, "[]"), session.CurrentCardID, session.CardsReviewed, session.ActiveMs,
+		session.StartedAt, session.UpdatedAt, session.PausedAt, session.EndedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSession saves a review session's progress
+func (db *DB) UpdateSession(session *Session) error {
+	query := `
+		UPDATE sessions SET options = ?, queue = ?, learning = ?, current_card_id = ?,
+			cards_reviewed = ?, active_ms = ?, updated_at = ?, paused_at = ?, ended_at = ?
+		WHERE id = ?
+	`
+
+	session.UpdatedAt = time.Now()
+	result, err := db.q().Exec(query,
+		sessionOptions(session), emptyIfUnset(session.Queue, "[]"),
+		emptyIfUnset(session.Learning, "[]"), session.CurrentCardID,
+		session.CardsReviewed, session.ActiveMs, session.UpdatedAt, session.PausedAt,
+		session.EndedAt, session.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("session %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// sessionOptions returns the session's options JSON, "{}" when unset
+func sessionOptions(session *Session) string {
+	return emptyIfUnset(session.Options, "{}")
+}
+
+// emptyIfUnset returns a JSON column's value, or the empty JSON value when unset
+func emptyIfUnset(value, empty string) string {
+	if value == "" {
+		return empty
+	}
+	return value
+}
+
+// GetSession retrieves a review session by ID
+func (db *DB) GetSession(id string) (*Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM sessions WHERE id = ?
+	`
+
+	session, err := scanSession(db.q().QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("session %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+// GetUnfinishedSession retrieves the most recently active session that has not ended
+func (db *DB) GetUnfinishedSession() (*Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM sessions WHERE ended_at IS NULL
+		ORDER BY updated_at DESC, started_at DESC
+		LIMIT 1
+	`
+
+	session, err := scanSession(db.q().QueryRow(query))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("unfinished session %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get unfinished session: %w", err)
+	}
+
+	return session, nil
+}
+
+// sessionColumns lists the sessions columns in the order scanSession expects
+const sessionColumns = `id, deck_id, options, queue, learning, current_card_id, cards_reviewed,
+			active_ms, started_at, updated_at, paused_at, ended_at`
+
+// scanSession reads one session selected with sessionColumns
+func scanSession(row rowScanner) (*Session, error) {
+	session := &Session{}
+	err := row.Scan(
+		&session.ID, &session.DeckID, &session.Options, &session.Queue, &session.Learning,
+		&session.CurrentCardID, &session.CardsReviewed, &session.ActiveMs,
+		&session.StartedAt, &session.UpdatedAt, &session.PausedAt, &session.EndedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
 // StoreAsset stores a deck asset
 func (db *DB) StoreAsset(asset *DeckAsset) error {
 	query := `
@@ -446,7 +881,7 @@ func (db *DB) StoreAsset(asset *DeckAsset) error {
 		VALUES (?, ?, ?, ?)
 	`
 
-	result, err := db.conn.Exec(query,
+	result, err := db.q().Exec(query,
 		asset.DeckID, asset.Filename, asset.Content, asset.ContentType,
 	)
 	if err != nil {
@@ -472,13 +907,13 @@ func (db *DB) GetAsset(deckID int, filename string) (*DeckAsset, error) {
 	`
 
 	asset := &DeckAsset{}
-	err := db.conn.QueryRow(query, deckID, filename).Scan(
+	err := db.q().QueryRow(query, deckID, filename).Scan(
 		&asset.ID, &asset.DeckID, &asset.Filename, &asset.Content,
 		&asset.ContentType, &asset.CreatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("asset not found")
+			return nil, fmt.Errorf("asset %w", ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get asset: %w", err)
 	}
@@ -493,7 +928,7 @@ func (db *DB) ListDeckAssets(deckID int) ([]*DeckAsset, error) {
 		FROM card_assets WHERE deck_id = ? ORDER BY filename
 	`
 
-	rows, err := db.conn.Query(query, deckID)
+	rows, err := db.q().Query(query, deckID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list deck assets: %w", err)
 	}
@@ -514,3 +949,65 @@ func (db *DB) ListDeckAssets(deckID int) ([]*DeckAsset, error) {
 
 	return assets, nil
 }
+
+// DeleteDeckAssets removes every asset stored for a deck
+func (db *DB) DeleteDeckAssets(deckID int) error {
+	if _, err := db.q().Exec(`DELETE FROM card_assets WHERE deck_id = ?`, deckID); err != nil {
+		return fmt.Errorf("failed to delete deck assets: %w", err)
+	}
+	return nil
+}
+
+// CreateDeckVersion records a deck upgrade
+func (db *DB) CreateDeckVersion(version *DeckVersion) error {
+	query := `
+		INSERT INTO deck_versions (deck_id, version, changes)
+		VALUES (?, ?, ?)
+	`
+
+	result, err := db.q().Exec(query, version.DeckID, version.Version, version.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to create deck version: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get deck version ID: %w", err)
+	}
+
+	version.ID = int(id)
+	version.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// ListDeckVersions retrieves a deck's upgrade history, oldest first
+func (db *DB) ListDeckVersions(deckID int) ([]*DeckVersion, error) {
+	query := `
+		SELECT id, deck_id, version, changes, updated_at
+		FROM deck_versions WHERE deck_id = ? ORDER BY id
+	`
+
+	rows, err := db.q().Query(query, deckID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deck versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*DeckVersion
+	for rows.Next() {
+		version := &DeckVersion{}
+		err := rows.Scan(
+			&version.ID, &version.DeckID, &version.Version, &version.Changes, &version.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan deck version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list deck versions: %w", err)
+	}
+
+	return versions, nil
+}
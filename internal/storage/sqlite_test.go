@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -41,7 +44,9 @@ func TestDeckOperations(t *testing.T) {
 		DefaultTimeout:        10,
 		DefaultNetworkEnabled: false,
 		DefaultCapabilities:   `["NET_ADMIN"]`,
+		DefaultShell:          "bash",
 		FSRSParameters:        `{"w":[1,2,3,4]}`,
+		Settings:              `{"show_solutions":false}`,
 	}
 
 	err := db.CreateDeck(deck)
@@ -62,6 +67,17 @@ func TestDeckOperations(t *testing.T) {
 	if retrieved.Name != deck.Name {
 		t.Errorf("Expected name %s, got %s", deck.Name, retrieved.Name)
 	}
+	if retrieved.DefaultShell != "bash" {
+		t.Errorf("Expected shell bash, got %q", retrieved.DefaultShell)
+	}
+
+	settings, err := retrieved.ParseSettings()
+	if err != nil {
+		t.Fatalf("Failed to parse settings: %v", err)
+	}
+	if settings.ShowSolutions == nil || *settings.ShowSolutions || settings.ShowExplanations != nil {
+		t.Errorf("Expected only show_solutions=false to be set, got %+v", settings)
+	}
 
 	// Test ListDecks
 	decks, err := db.ListDecks()
@@ -136,6 +152,51 @@ func TestCardOperations(t *testing.T) {
 	if len(dueCards) != 1 {
 		t.Errorf("Expected 1 due card, got %d", len(dueCards))
 	}
+
+	// Test GetCardByKey
+	byKey, err := db.GetCardByKey(deck.ID, "test-card-1")
+	if err != nil {
+		t.Fatalf("Failed to get card by key: %v", err)
+	}
+	if byKey.ID != card.ID {
+		t.Errorf("Expected card %d, got %d", card.ID, byKey.ID)
+	}
+	if _, err := db.GetCardByKey(deck.ID, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing key, got %v", err)
+	}
+
+	// Buried and suspended cards are no longer due
+	buriedUntil := time.Now().Add(24 * time.Hour).UTC()
+	retrieved.BuriedUntil = &buriedUntil
+	if err := db.UpdateCard(retrieved); err != nil {
+		t.Fatalf("Failed to bury card: %v", err)
+	}
+	buried, err := db.GetCard(card.ID)
+	if err != nil {
+		t.Fatalf("Failed to get card: %v", err)
+	}
+	if buried.BuriedUntil == nil || !buried.BuriedUntil.Equal(buriedUntil) {
+		t.Errorf("Expected card buried until %v, got %v", buriedUntil, buried.BuriedUntil)
+	}
+	if dueCards, _ := db.GetDueCards(); len(dueCards) != 0 {
+		t.Errorf("Expected no due cards while buried, got %d", len(dueCards))
+	}
+
+	buried.BuriedUntil = nil
+	buried.Suspended = true
+	if err := db.UpdateCard(buried); err != nil {
+		t.Fatalf("Failed to suspend card: %v", err)
+	}
+	suspended, err := db.GetCard(card.ID)
+	if err != nil {
+		t.Fatalf("Failed to get card: %v", err)
+	}
+	if !suspended.Suspended || suspended.BuriedUntil != nil {
+		t.Errorf("Expected card suspended and not buried, got suspended=%t buried_until=%v", suspended.Suspended, suspended.BuriedUntil)
+	}
+	if dueCards, _ := db.GetDueCards(); len(dueCards) != 0 {
+		t.Errorf("Expected no due cards while suspended, got %d", len(dueCards))
+	}
 }
 
 func TestFSRSIntegration(t *testing.T) {
@@ -235,12 +296,16 @@ func TestReviewOperations(t *testing.T) {
 		TotalTimeMs:          func() *int { ms := 5100; return &ms }(),
 		Attempts:             1,
 		HelpAccessed:         false,
+		AttemptPassed:        func() *bool { passed := true; return &passed }(),
+		SuggestedRating:      func() *int { rating := int(fsrs.Easy); return &rating }(),
 		FSRSDueBefore:        now,
 		FSRSDueAfter:         now.Add(24 * time.Hour),
 		FSRSStabilityBefore:  1.0,
 		FSRSStabilityAfter:   2.5,
 		FSRSDifficultyBefore: 5.0,
 		FSRSDifficultyAfter:  4.8,
+		FSRSStateBefore:      int(fsrs.New),
+		FSRSStateAfter:       int(fsrs.Learning),
 	}
 
 	err = db.CreateReview(review)
@@ -251,6 +316,317 @@ func TestReviewOperations(t *testing.T) {
 	if review.ID == 0 {
 		t.Error("Expected review ID to be set after creation")
 	}
+
+	// Reviews without an objective outcome store NULLs
+	if err := db.CreateReview(&Review{
+		CardID: card.ID, Rating: int(fsrs.Again), Attempts: 1,
+		FSRSDueBefore: now, FSRSDueAfter: now,
+	}); err != nil {
+		t.Fatalf("Failed to create review: %v", err)
+	}
+
+	reviews, err := db.GetReviewsByCard(card.ID)
+	if err != nil {
+		t.Fatalf("Failed to get reviews: %v", err)
+	}
+	if len(reviews) != 2 {
+		t.Fatalf("Expected 2 reviews, got %d", len(reviews))
+	}
+	first := reviews[0]
+	if first.AttemptPassed == nil || !*first.AttemptPassed || first.SuggestedRating == nil || *first.SuggestedRating != int(fsrs.Easy) {
+		t.Errorf("Expected objective outcome to round-trip, got passed=%v suggested=%v", first.AttemptPassed, first.SuggestedRating)
+	}
+	if first.FSRSStateBefore != int(fsrs.New) || first.FSRSStateAfter != int(fsrs.Learning) {
+		t.Errorf("Expected state transition New to Learning, got %d to %d", first.FSRSStateBefore, first.FSRSStateAfter)
+	}
+	if reviews[1].AttemptPassed != nil || reviews[1].SuggestedRating != nil {
+		t.Errorf("Expected NULL outcome, got passed=%v suggested=%v", reviews[1].AttemptPassed, reviews[1].SuggestedRating)
+	}
+}
+
+func TestRecordReview(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	deck := &Deck{Name: "Record Test Deck"}
+	if err := db.CreateDeck(deck); err != nil {
+		t.Fatalf("Failed to create deck: %v", err)
+	}
+	card := &Card{DeckID: deck.ID, CardKey: "record-card", Title: "Record Card", Command: "true"}
+	if err := db.CreateCard(card); err != nil {
+		t.Fatalf("Failed to create card: %v", err)
+	}
+
+	// A review that can't be saved leaves the card as it was
+	reviewed := *card
+	reviewed.FSRSReps, reviewed.FSRSState = 1, int(fsrs.Learning)
+	if err := db.RecordReview(&reviewed, &Review{CardID: card.ID + 100, Rating: int(fsrs.Good), Attempts: 1}); err == nil {
+		t.Fatal("Expected an error recording a review of a missing card")
+	}
+	stored, err := db.GetCard(card.ID)
+	if err != nil {
+		t.Fatalf("Failed to get card: %v", err)
+	}
+	if stored.FSRSReps != 0 || stored.FSRSState != int(fsrs.New) {
+		t.Errorf("Expected the card update rolled back, got reps %d state %d", stored.FSRSReps, stored.FSRSState)
+	}
+
+	if err := db.RecordReview(&reviewed, &Review{CardID: card.ID, Rating: int(fsrs.Good), Attempts: 1}); err != nil {
+		t.Fatalf("Failed to record review: %v", err)
+	}
+	stored, err = db.GetCard(card.ID)
+	if err != nil {
+		t.Fatalf("Failed to get card: %v", err)
+	}
+	reviews, err := db.GetReviewsByCard(card.ID)
+	if err != nil {
+		t.Fatalf("Failed to get reviews: %v", err)
+	}
+	if stored.FSRSReps != 1 || len(reviews) != 1 {
+		t.Errorf("Expected the card and its review saved, got reps %d and %d reviews", stored.FSRSReps, len(reviews))
+	}
+}
+
+func TestRevertReview(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	deck := &Deck{Name: "Revert Test Deck"}
+	if err := db.CreateDeck(deck); err != nil {
+		t.Fatalf("Failed to create deck: %v", err)
+	}
+	card := &Card{DeckID: deck.ID, CardKey: "revert-card", Title: "Revert Card", Command: "true"}
+	if err := db.CreateCard(card); err != nil {
+		t.Fatalf("Failed to create card: %v", err)
+	}
+
+	// The card lapses from Review into Relearning
+	due := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	lastReview := due.Add(-7 * 24 * time.Hour)
+	elapsedDays, scheduledDays, reps, lapses := 7, 7, 4, 1
+	review := &Review{
+		CardID: card.ID, Rating: int(fsrs.Again), Attempts: 1,
+		FSRSDueBefore: due, FSRSDueAfter: due.Add(2 * time.Hour),
+		FSRSStabilityBefore: 9.5, FSRSStabilityAfter: 1.2, FSRSDifficultyBefore: 5, FSRSDifficultyAfter: 6.5,
+		FSRSStateBefore: int(fsrs.Review), FSRSStateAfter: int(fsrs.Relearning),
+		FSRSElapsedDaysBefore: &elapsedDays, FSRSScheduledDaysBefore: &scheduledDays,
+		FSRSRepsBefore: &reps, FSRSLapsesBefore: &lapses,
+		FSRSLastReviewBefore: &lastReview,
+	}
+	if err := db.CreateReview(review); err != nil {
+		t.Fatalf("Failed to create review: %v", err)
+	}
+	now := time.Now()
+	card.FSRSDue, card.FSRSStability, card.FSRSDifficulty = review.FSRSDueAfter, 1.2, 6.5
+	card.FSRSReps, card.FSRSLapses, card.FSRSState, card.FSRSLastReview = 5, 2, int(fsrs.Relearning), &now
+	if err := db.UpdateCardFSRS(card); err != nil {
+		t.Fatalf("Failed to update card: %v", err)
+	}
+
+	if err := db.RevertReview(review); err != nil {
+		t.Fatalf("Failed to revert review: %v", err)
+	}
+
+	restored, err := db.GetCard(card.ID)
+	if err != nil {
+		t.Fatalf("Failed to get card: %v", err)
+	}
+	if !restored.FSRSDue.Equal(due) || restored.FSRSStability != 9.5 || restored.FSRSDifficulty != 5 ||
+		restored.FSRSElapsedDays != 7 || restored.FSRSScheduledDays != 7 || restored.FSRSReps != 4 ||
+		restored.FSRSLapses != 1 || restored.FSRSState != int(fsrs.Review) ||
+		restored.FSRSLastReview == nil || !restored.FSRSLastReview.Equal(lastReview) {
+		t.Errorf("Expected the card's state before the review, got %+v", restored)
+	}
+
+	reviews, err := db.GetReviewsByCard(card.ID)
+	if err != nil {
+		t.Fatalf("Failed to get reviews: %v", err)
+	}
+	if len(reviews) != 0 {
+		t.Errorf("Expected the review deleted, got %d reviews", len(reviews))
+	}
+	if err := db.RevertReview(review); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound reverting twice, got %v", err)
+	}
+
+	// A review recorded before the prior state was kept is refused, leaving both as they are
+	legacy := &Review{
+		CardID: card.ID, Rating: int(fsrs.Good), Attempts: 1,
+		FSRSDueBefore: due, FSRSDueAfter: due.Add(24 * time.Hour),
+		FSRSStateBefore: int(fsrs.Review), FSRSStateAfter: int(fsrs.Review),
+	}
+	if err := db.CreateReview(legacy); err != nil {
+		t.Fatalf("Failed to create review: %v", err)
+	}
+	reviews, err = db.GetReviewsByCard(card.ID)
+	if err != nil {
+		t.Fatalf("Failed to get reviews: %v", err)
+	}
+	if len(reviews) != 1 || reviews[0].HasPriorState() {
+		t.Fatalf("Expected one review without its prior state, got %+v", reviews)
+	}
+	if err := db.RevertReview(reviews[0]); !errors.Is(err, ErrNoPriorState) {
+		t.Errorf("Expected ErrNoPriorState, got %v", err)
+	}
+	unchanged, err := db.GetCard(card.ID)
+	if err != nil {
+		t.Fatalf("Failed to get card: %v", err)
+	}
+	if unchanged.FSRSReps != restored.FSRSReps || unchanged.FSRSStability != restored.FSRSStability {
+		t.Errorf("Expected the card unchanged, got %+v", unchanged)
+	}
+	if reviews, _ := db.GetReviewsByCard(card.ID); len(reviews) != 1 {
+		t.Errorf("Expected the review kept, got %d reviews", len(reviews))
+	}
+}
+
+func TestCountReviewsSince(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	deck := &Deck{Name: "Count Test Deck"}
+	if err := db.CreateDeck(deck); err != nil {
+		t.Fatalf("Failed to create deck: %v", err)
+	}
+	card := &Card{DeckID: deck.ID, CardKey: "count-card", Title: "Count Card", Command: "true"}
+	other := &Card{DeckID: deck.ID, CardKey: "count-other", Title: "Other Card", Command: "true"}
+	for _, c := range []*Card{card, other} {
+		if err := db.CreateCard(c); err != nil {
+			t.Fatalf("Failed to create card: %v", err)
+		}
+	}
+
+	// A new card rated twice counts once; learning steps aren't counted
+	now := time.Now()
+	for _, review := range []*Review{
+		{CardID: card.ID, Rating: int(fsrs.Again), FSRSStateBefore: int(fsrs.New), FSRSStateAfter: int(fsrs.Learning)},
+		{CardID: card.ID, Rating: int(fsrs.Good), FSRSStateBefore: int(fsrs.New), FSRSStateAfter: int(fsrs.Learning)},
+		{CardID: card.ID, Rating: int(fsrs.Good), FSRSStateBefore: int(fsrs.Learning), FSRSStateAfter: int(fsrs.Review)},
+		{CardID: other.ID, Rating: int(fsrs.Good), FSRSStateBefore: int(fsrs.Review), FSRSStateAfter: int(fsrs.Review)},
+	} {
+		review.Attempts = 1
+		review.FSRSDueBefore, review.FSRSDueAfter = now, now
+		if err := db.CreateReview(review); err != nil {
+			t.Fatalf("Failed to create review: %v", err)
+		}
+	}
+
+	counts, err := db.CountReviewsSince(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to count reviews: %v", err)
+	}
+	if got := counts[deck.ID]; got.NewCards != 1 || got.Reviews != 1 {
+		t.Errorf("Expected 1 new card and 1 review, got %+v", got)
+	}
+
+	counts, err = db.CountReviewsSince(now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to count reviews: %v", err)
+	}
+	if len(counts) != 0 {
+		t.Errorf("Expected no reviews in the future, got %+v", counts)
+	}
+}
+
+func TestSessionOperations(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	deck := &Deck{Name: "Session Test Deck"}
+	if err := db.CreateDeck(deck); err != nil {
+		t.Fatalf("Failed to create deck: %v", err)
+	}
+	card := &Card{DeckID: deck.ID, CardKey: "session-card", Title: "Session Card", Command: "true"}
+	if err := db.CreateCard(card); err != nil {
+		t.Fatalf("Failed to create card: %v", err)
+	}
+
+	if _, err := db.GetUnfinishedSession(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound with no sessions, got %v", err)
+	}
+
+	session := &Session{
+		ID:        "session-1",
+		DeckID:    &deck.ID,
+		Options:   `{"max_cards":20}`,
+		Queue:     fmt.Sprintf("[%d]", card.ID),
+		StartedAt: time.Now(),
+	}
+	if err := db.CreateSession(session); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	// Reviews made in the session are linked to it
+	now := time.Now()
+	if err := db.CreateReview(&Review{
+		CardID: card.ID, SessionID: &session.ID, Rating: int(fsrs.Good), Attempts: 1,
+		FSRSDueBefore: now, FSRSDueAfter: now,
+	}); err != nil {
+		t.Fatalf("Failed to create review: %v", err)
+	}
+	reviews, err := db.GetReviewsByCard(card.ID)
+	if err != nil {
+		t.Fatalf("Failed to get reviews: %v", err)
+	}
+	if reviews[0].SessionID == nil || *reviews[0].SessionID != session.ID {
+		t.Errorf("Expected review linked to %s, got %v", session.ID, reviews[0].SessionID)
+	}
+	if err := db.CreateReview(&Review{
+		CardID: card.ID, Rating: int(fsrs.Again), Attempts: 1, FSRSDueBefore: now, FSRSDueAfter: now,
+	}); err != nil {
+		t.Fatalf("Failed to create review: %v", err)
+	}
+	sessionReviews, err := db.GetReviewsBySession(session.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session reviews: %v", err)
+	}
+	if len(sessionReviews) != 1 || sessionReviews[0].Rating != int(fsrs.Good) {
+		t.Errorf("Expected only the session's review, got %d reviews", len(sessionReviews))
+	}
+
+	// Pausing keeps the session resumable
+	pausedAt := time.Now()
+	session.CurrentCardID = &card.ID
+	session.ActiveMs = 1500
+	session.Learning = fmt.Sprintf("[%d]", card.ID)
+	session.PausedAt = &pausedAt
+	if err := db.UpdateSession(session); err != nil {
+		t.Fatalf("Failed to update session: %v", err)
+	}
+
+	unfinished, err := db.GetUnfinishedSession()
+	if err != nil {
+		t.Fatalf("Failed to get unfinished session: %v", err)
+	}
+	if unfinished.ID != session.ID || unfinished.CurrentCardID == nil || *unfinished.CurrentCardID != card.ID ||
+		unfinished.ActiveMs != 1500 || unfinished.PausedAt == nil {
+		t.Errorf("Expected paused session to round-trip, got %+v", unfinished)
+	}
+	queue, err := unfinished.ParseQueue()
+	if err != nil || len(queue) != 1 || queue[0] != card.ID {
+		t.Errorf("Expected queue [%d], got %v (%v)", card.ID, queue, err)
+	}
+	learning, err := unfinished.ParseLearning()
+	if err != nil || len(learning) != 1 || learning[0] != card.ID {
+		t.Errorf("Expected learning cards [%d], got %v (%v)", card.ID, learning, err)
+	}
+
+	// Ended sessions are no longer resumable
+	endedAt := time.Now()
+	session.EndedAt = &endedAt
+	if err := db.UpdateSession(session); err != nil {
+		t.Fatalf("Failed to update session: %v", err)
+	}
+	if _, err := db.GetUnfinishedSession(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound once the session ended, got %v", err)
+	}
+	if _, err := db.GetSession(session.ID); err != nil {
+		t.Errorf("Expected ended session to remain, got %v", err)
+	}
+
+	if err := db.UpdateSession(&Session{ID: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound updating a missing session, got %v", err)
+	}
 }
 
 func TestAssetOperations(t *testing.T) {
@@ -298,6 +674,49 @@ func TestAssetOperations(t *testing.T) {
 	}
 }
 
+func TestDeleteDeck_CascadesToCardsAndReviews(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	deck := &Deck{Name: "Doomed Deck"}
+	if err := db.CreateDeck(deck); err != nil {
+		t.Fatalf("Failed to create deck: %v", err)
+	}
+	card := &Card{DeckID: deck.ID, CardKey: "doomed", Title: "Doomed", Command: "true"}
+	if err := db.CreateCard(card); err != nil {
+		t.Fatalf("Failed to create card: %v", err)
+	}
+	now := time.Now()
+	if err := db.CreateReview(&Review{
+		CardID: card.ID, Rating: int(fsrs.Good), ExecutionSuccess: true, Attempts: 1,
+		FSRSDueBefore: now, FSRSDueAfter: now,
+	}); err != nil {
+		t.Fatalf("Failed to create review: %v", err)
+	}
+
+	reviews, err := db.CountReviewsByDeck(deck.ID)
+	if err != nil {
+		t.Fatalf("Failed to count reviews: %v", err)
+	}
+	if reviews != 1 {
+		t.Errorf("Expected 1 review, got %d", reviews)
+	}
+
+	if err := db.DeleteDeck(deck.ID); err != nil {
+		t.Fatalf("Failed to delete deck: %v", err)
+	}
+
+	if _, err := db.GetCard(card.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected card to be deleted with its deck, got %v", err)
+	}
+	if reviews, _ := db.CountReviewsByDeck(deck.ID); reviews != 0 {
+		t.Errorf("Expected reviews to be deleted with the deck, got %d", reviews)
+	}
+	if err := db.DeleteDeck(deck.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting a missing deck, got %v", err)
+	}
+}
+
 func TestDatabaseMigration(t *testing.T) {
 	// Test that migration runs successfully on a fresh database
 	tmpDir := t.TempDir()
@@ -316,3 +735,141 @@ func TestDatabaseMigration(t *testing.T) {
 		t.Fatalf("Failed to create deck after migration: %v", err)
 	}
 }
+
+func TestDatabaseMigration_AddsColumnsToExistingTables(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "legacy.db")
+
+	// Create the schema as it looked before migrations were versioned
+	conn, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open legacy database: %v", err)
+	}
+	if _, err := conn.Exec(initialSchemaSQL); err != nil {
+		t.Fatalf("Failed to create legacy schema: %v", err)
+	}
+	_, err = conn.Exec(`
+		INSERT INTO decks (id, name) VALUES (1, 'Old Deck');
+		INSERT INTO cards (deck_id, card_key, title, description, command, environment_vars,
+			tags, prerequisites, fsrs_due, fsrs_stability, fsrs_difficulty)
+		VALUES (1, 'old', 'Old', '', 'ls', '{}', '[]', '[]', datetime('now'), 0, 0);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert legacy rows: %v", err)
+	}
+	conn.Close()
+
+	db, err := NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to migrate legacy database: %v", err)
+	}
+	defer db.Close()
+
+	old, err := db.GetCardsByDeck(1)
+	if err != nil {
+		t.Fatalf("Failed to read legacy card: %v", err)
+	}
+	if len(old) != 1 || old[0].Archived || old[0].Setup != "" {
+		t.Errorf("Expected legacy card with default column values, got %+v", old)
+	}
+
+	deck := &Deck{Name: "Legacy Deck"}
+	if err := db.CreateDeck(deck); err != nil {
+		t.Fatalf("Failed to create deck: %v", err)
+	}
+	card := &Card{DeckID: deck.ID, CardKey: "legacy", Title: "Legacy", Command: "true", Hint: "Try true"}
+	if err := db.CreateCard(card); err != nil {
+		t.Fatalf("Failed to create card: %v", err)
+	}
+
+	card.Archived = true
+	if err := db.UpdateCard(card); err != nil {
+		t.Fatalf("Failed to archive card: %v", err)
+	}
+
+	active, err := db.GetCardsByDeck(deck.ID)
+	if err != nil {
+		t.Fatalf("Failed to get cards: %v", err)
+	}
+	if len(active) != 0 {
+		t.Errorf("Expected archived card to be hidden, got %d cards", len(active))
+	}
+
+	all, err := db.GetCardsByDeckWithArchived(deck.ID)
+	if err != nil {
+		t.Fatalf("Failed to get cards with archived: %v", err)
+	}
+	if len(all) != 1 || !all[0].Archived || all[0].Hint != "Try true" {
+		t.Errorf("Expected archived card to be returned, got %+v", all)
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		t.Fatalf("Failed to get schema version: %v", err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Errorf("Expected schema version %d, got %d", LatestSchemaVersion(), version)
+	}
+
+	// Running the migration again must be a no-op
+	if err := MigrateDatabase(db); err != nil {
+		t.Errorf("Second migration failed: %v", err)
+	}
+}
+
+func TestMigrationStatus(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "status.db")
+
+	db, err := OpenDB(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	status, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatalf("Failed to get status of empty database: %v", err)
+	}
+	if len(status) != len(migrations) {
+		t.Fatalf("Expected %d migrations, got %d", len(migrations), len(status))
+	}
+	for _, s := range status {
+		if s.Applied {
+			t.Errorf("Expected migration %d to be pending on an empty database", s.Version)
+		}
+	}
+
+	if err := MigrateDatabase(db); err != nil {
+		t.Fatalf("Migration failed: %v", err)
+	}
+
+	status, err = db.MigrationStatus()
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	for i, s := range status {
+		if s.Version != i+1 {
+			t.Errorf("Expected migrations to be numbered in order, got %d at position %d", s.Version, i)
+		}
+		if !s.Applied || s.AppliedAt == nil {
+			t.Errorf("Expected migration %d (%s) to be applied with a timestamp", s.Version, s.Name)
+		}
+	}
+}
+
+func TestDatabaseMigration_RefusesNewerSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "future.db")
+
+	db, err := NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	future := LatestSchemaVersion() + 1
+	if _, err := db.conn.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, 'from_the_future')`, future); err != nil {
+		t.Fatalf("Failed to record future migration: %v", err)
+	}
+	db.Close()
+
+	if _, err := NewDB(dbPath); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Expected ErrSchemaTooNew, got %v", err)
+	}
+}